@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	db "github.com/rouclec/simplebank/db/sqlc"
 	"github.com/rouclec/simplebank/token"
+	"github.com/shopspring/decimal"
 )
 
 type createAccountRequest struct {
@@ -26,12 +28,16 @@ func (server *Server) createAccount(ctx *gin.Context) {
 	arg := db.CreateAccountParams{
 		Owner:    authPayload.Username,
 		Currency: req.Currency,
-		Balance:  0,
+		Balance:  decimal.Zero,
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	account, err := server.store.CreateAccountTx(ctx, db.CreateAccountTxRequest{
+		CreateAccountParams: arg,
+		Audit:               auditContext(ctx, authPayload.Username),
+	})
 
 	if err != nil {
+		reportRolledBack(ctx)
 		errCode := db.ErrorCode(err)
 		if errCode == db.ForeignKeyViolation || errCode == db.UniqueViolation {
 			ctx.JSON(http.StatusForbidden, errorResponse(err))
@@ -84,29 +90,38 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	})
 }
 
-type addAccountBalanceRequest struct {
-	ID     int64   `uri:"id" binding:"required,min=1"`
-	Amount float64 `uri:"amount" binding:"required,gt=0"`
+type accountMovementRequest struct {
+	Amount decimal.Decimal `json:"amount" binding:"required,gt=0"`
 }
 
-func (server *Server) addAccountBalance(ctx *gin.Context) {
-	var req addAccountBalanceRequest
+// accountTx is a Store transaction moving money in or out of a single account
+type accountTx func(ctx context.Context, arg db.AccountTxRequest) (db.AccountTxResponse, error)
 
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+func (server *Server) createDeposit(ctx *gin.Context) {
+	server.moveMoney(ctx, server.store.DepositTx)
+}
+
+func (server *Server) createWithdrawal(ctx *gin.Context) {
+	server.moveMoney(ctx, server.store.WithdrawTx)
+}
+
+// moveMoney runs tx against the account in the uri once the caller is known to own it
+func (server *Server) moveMoney(ctx *gin.Context, tx accountTx) {
+	var uri getAccountRequest
+	var req accountMovementRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 
-	if err != nil {
-		if errors.Is(err, db.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"message": fmt.Sprintf("Account with id %v not found", req.ID),
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	account, valid := server.validAccount(ctx, uri.ID)
+	if !valid {
 		return
 	}
 
@@ -118,26 +133,33 @@ func (server *Server) addAccountBalance(ctx *gin.Context) {
 		return
 	}
 
-	args := db.AddAccountBalanceParams{
-		Amount: req.Amount,
-		ID:     req.ID,
+	if !validAmount(ctx, req.Amount, account.Currency) {
+		return
 	}
 
-	updatedAccount, err := server.store.AddAccountBalance(ctx, args)
+	result, err := tx(ctx, db.AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    req.Amount,
+		Audit:     auditContext(ctx, authPayload.Username),
+	})
 
 	if err != nil {
+		reportRolledBack(ctx)
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"data": updatedAccount,
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": result,
 	})
 }
 
 type listAccountsRequest struct {
-	PageId   uint16 `form:"page_id" binding:"required,min=1"`
-	PageSize uint16 `form:"page_size" binding:"required,min=1,max=10"`
+	pageRequest
 }
 
 func (server *Server) listAccounts(ctx *gin.Context) {
@@ -148,12 +170,18 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 		return
 	}
 
+	afterID, err := req.afterID()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
 
 	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  int32(req.PageSize),
-		Offset: int32(req.PageId-1) * int32(req.PageSize),
+		Owner:   authPayload.Username,
+		AfterID: afterID,
+		Limit:   req.limit(),
 	}
 
 	accounts, err := server.store.ListAccounts(ctx, arg)
@@ -163,7 +191,19 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"data": accounts,
+	var totalCount *int64
+	if req.IncludeTotal {
+		count, err := server.store.CountAccounts(ctx, authPayload.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		totalCount = &count
+	}
+
+	accounts, nextCursor := trimPage(accounts, req.PageSize, func(account db.Accounts) int64 {
+		return account.ID
 	})
+
+	ctx.JSON(http.StatusOK, pageEnvelope(accounts, nextCursor, totalCount))
 }
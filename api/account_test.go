@@ -17,6 +17,7 @@ import (
 	db "github.com/rouclec/simplebank/db/sqlc"
 	"github.com/rouclec/simplebank/token"
 	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,15 +38,18 @@ func TestCreateAccountApi(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateAccountParams{
-					Owner:    account.Owner,
-					Currency: account.Currency,
-					Balance:  0,
+				arg := db.CreateAccountTxRequest{
+					CreateAccountParams: db.CreateAccountParams{
+						Owner:    account.Owner,
+						Currency: account.Currency,
+						Balance:  decimal.Zero,
+					},
+					Audit: &db.AuditContext{Actor: user.Username},
 				}
-				store.EXPECT().CreateAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusCreated, recorder.Code)
@@ -58,15 +62,18 @@ func TestCreateAccountApi(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateAccountParams{
-					Owner:    account.Owner,
-					Currency: account.Currency,
-					Balance:  0,
+				arg := db.CreateAccountTxRequest{
+					CreateAccountParams: db.CreateAccountParams{
+						Owner:    account.Owner,
+						Currency: account.Currency,
+						Balance:  decimal.Zero,
+					},
+					Audit: &db.AuditContext{Actor: user.Username},
 				}
-				store.EXPECT().CreateAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Accounts{}, db.ErrUniqueViolation) // Simulate unique key violation
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Accounts{}, db.ErrUniqueViolation) // Simulate unique key violation
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusForbidden, recorder.Code) // Expect conflict due to unique key violation
@@ -80,7 +87,7 @@ func TestCreateAccountApi(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -91,10 +98,10 @@ func TestCreateAccountApi(t *testing.T) {
 			name: "BadRequest",
 			body: gin.H{},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
@@ -106,15 +113,18 @@ func TestCreateAccountApi(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateAccountParams{
-					Owner:    account.Owner,
-					Currency: account.Currency,
-					Balance:  0,
+				arg := db.CreateAccountTxRequest{
+					CreateAccountParams: db.CreateAccountParams{
+						Owner:    account.Owner,
+						Currency: account.Currency,
+						Balance:  decimal.Zero,
+					},
+					Audit: &db.AuditContext{Actor: user.Username},
 				}
-				store.EXPECT().CreateAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Accounts{}, sql.ErrConnDone)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Accounts{}, sql.ErrConnDone)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -157,150 +167,251 @@ func TestCreateAccountApi(t *testing.T) {
 
 }
 
-func TestAddMoneyApi(t *testing.T) {
+func TestMoveMoneyApi(t *testing.T) {
 	user, _ := randomUser(t)
 	user1, _ := randomUser(t)
-	var amount float64
 
 	account := generateRandomAccount(user.Username)
+	amount := decimal.NewFromInt(100)
+
+	francs := generateRandomAccount(user.Username)
+	francs.Currency = "XAF"
+
+	deposited := account
+	deposited.Balance = account.Balance.Add(amount)
+	deposit := db.AccountTxResponse{
+		Account: deposited,
+		Entry: db.Entries{
+			ID:        util.RandomInt(1, 1000),
+			AccountID: account.ID,
+			Amount:    amount,
+			Type:      util.DepositEntry,
+		},
+	}
 
-	if account.Currency == "XAF" {
-		amount = 100000.00
-	} else {
-		amount = 10000.00
+	withdrawn := account
+	withdrawn.Balance = account.Balance.Sub(amount)
+	withdrawal := db.AccountTxResponse{
+		Account: withdrawn,
+		Entry: db.Entries{
+			ID:        util.RandomInt(1, 1000),
+			AccountID: account.ID,
+			Amount:    amount.Neg(),
+			Type:      util.WithdrawalEntry,
+		},
 	}
 
-	updatedAccount := account
-	updatedAccount.Balance = account.Balance + amount
+	arg := db.AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    amount,
+		Audit:     &db.AuditContext{Actor: user.Username},
+	}
 
 	testCases := []struct {
 		name          string
+		accountID     int64
+		movement      string
 		body          gin.H
 		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name: "OK",
+			name:      "Deposit",
+			accountID: account.ID,
+			movement:  "deposits",
 			body: gin.H{
-				"id":     account.ID,
 				"amount": amount,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.AddAccountBalanceParams{
-					ID:     account.ID,
-					Amount: amount,
-				}
-
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
-				store.EXPECT().AddAccountBalance(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updatedAccount, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(deposit, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchAccount(t, recorder.Body, updatedAccount)
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				requireBodyMatchAccountTx(t, recorder.Body, deposit)
 			},
 		},
 		{
-			name: "AccountNotFound",
+			name:      "Withdrawal",
+			accountID: account.ID,
+			movement:  "withdrawals",
 			body: gin.H{
-				"id":     account.ID,
 				"amount": amount,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Accounts{}, db.ErrRecordNotFound)
-				store.EXPECT().AddAccountBalance(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(withdrawal, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				requireBodyMatchAccountTx(t, recorder.Body, withdrawal)
 			},
 		},
 		{
-			name: "InternalServerError",
+			name:      "InsufficientFunds",
+			accountID: account.ID,
+			movement:  "withdrawals",
 			body: gin.H{
-				"id":     account.ID,
 				"amount": amount,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Accounts{}, sql.ErrConnDone)
-				store.EXPECT().AddAccountBalance(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.AccountTxResponse{}, db.ErrInsufficientFunds)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
 			},
 		},
 		{
-			name: "UnauthorizedUser",
+			name:      "AccountNotFound",
+			accountID: account.ID,
+			movement:  "deposits",
 			body: gin.H{
-				"id":     account.ID,
 				"amount": amount,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Accounts{}, db.ErrRecordNotFound)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			movement:  "withdrawals",
+			body: gin.H{
+				"amount": amount,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
-				store.EXPECT().AddAccountBalance(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
-			name: "NoAuthorization",
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			movement:  "deposits",
 			body: gin.H{
-				"id":     account.ID,
 				"amount": amount,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
-				store.EXPECT().AddAccountBalance(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
-			name: "BadRequest",
-			body: gin.H{},
+			name:      "NegativeAmount",
+			accountID: account.ID,
+			movement:  "deposits",
+			body: gin.H{
+				"amount": amount.Neg(),
+			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
-				store.EXPECT().AddAccountBalance(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
-			name: "InternalServerError",
+			name:      "TooPrecise",
+			accountID: account.ID,
+			movement:  "deposits",
+			body: gin.H{
+				"amount": "10.001",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "FractionalXAF",
+			accountID: francs.ID,
+			movement:  "deposits",
+			body: gin.H{
+				"amount": "1.50",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(francs.ID)).Times(1).Return(francs, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "more decimal places than XAF allows")
+			},
+		},
+		{
+			name:      "InvalidID",
+			accountID: 0,
+			movement:  "deposits",
 			body: gin.H{
 				"amount": amount,
-				"id":     account.ID,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalServerError",
+			accountID: account.ID,
+			movement:  "deposits",
+			body: gin.H{
+				"amount": amount,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.AddAccountBalanceParams{
-					Amount: amount,
-					ID:     account.ID,
-				}
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
-				store.EXPECT().AddAccountBalance(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Accounts{}, sql.ErrConnDone)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.AccountTxResponse{}, sql.ErrConnDone)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -327,8 +438,8 @@ func TestAddMoneyApi(t *testing.T) {
 			data, err := json.Marshal(tc.body)
 			require.NoError(t, err)
 
-			url := "/api/v1/accounts"
-			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			url := fmt.Sprintf("/api/v1/accounts/%d/%s", tc.accountID, tc.movement)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 
 			require.NoError(t, err)
 
@@ -359,7 +470,7 @@ func TestGetAccountApi(t *testing.T) {
 			name:      "OK",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -376,7 +487,7 @@ func TestGetAccountApi(t *testing.T) {
 			name:      "UnauthorizedAccess",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user2.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user2.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -392,7 +503,7 @@ func TestGetAccountApi(t *testing.T) {
 			name:      "NotFound",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -408,7 +519,7 @@ func TestGetAccountApi(t *testing.T) {
 			name:      "InternalServerError",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -424,7 +535,7 @@ func TestGetAccountApi(t *testing.T) {
 			name:      "BadRequest",
 			accountID: 0,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -477,8 +588,9 @@ func TestListAccountsApi(t *testing.T) {
 	}
 
 	type Query struct {
-		pageID   int
-		pageSize int
+		cursor       string
+		pageSize     int
+		includeTotal bool
 	}
 
 	testCases := []struct {
@@ -491,33 +603,90 @@ func TestListAccountsApi(t *testing.T) {
 		{
 			name: "OK",
 			query: Query{
-				pageID:   1,
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				arg := db.ListAccountsParams{
-					Owner:  user.Username,
-					Limit:  int32(n),
-					Offset: 0,
+					Owner: user.Username,
+					Limit: int32(n + 1),
 				}
 
 				store.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
 					Return(accounts, nil)
+				store.EXPECT().CountAccounts(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+				requirePage(t, recorder.Body, "", nil)
 				requireBodyMatchAccounts(t, recorder.Body, accounts)
 			},
 		},
+		{
+			name: "HasNextPage",
+			query: Query{
+				pageSize: 2,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{
+					Owner: user.Username,
+					Limit: 3,
+				}
+
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts[:3], nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requirePage(t, recorder.Body, encodeCursor(accounts[1].ID), nil)
+				requireBodyMatchAccounts(t, recorder.Body, accounts[:2])
+			},
+		},
+		{
+			name: "Cursor",
+			query: Query{
+				cursor:       encodeCursor(accounts[1].ID),
+				pageSize:     n,
+				includeTotal: true,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{
+					Owner:   user.Username,
+					AfterID: accounts[1].ID,
+					Limit:   int32(n + 1),
+				}
+
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts[2:], nil)
+				store.EXPECT().
+					CountAccounts(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(int64(n), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				total := int64(n)
+				requirePage(t, recorder.Body, "", &total)
+				requireBodyMatchAccounts(t, recorder.Body, accounts[2:])
+			},
+		},
 		{
 			name: "NoAuthorization",
 			query: Query{
-				pageID:   1,
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
@@ -533,11 +702,10 @@ func TestListAccountsApi(t *testing.T) {
 		{
 			name: "InternalError",
 			query: Query{
-				pageID:   1,
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -550,13 +718,13 @@ func TestListAccountsApi(t *testing.T) {
 			},
 		},
 		{
-			name: "InvalidPageID",
+			name: "InvalidCursor",
 			query: Query{
-				pageID:   -1,
+				cursor:   "not-a-cursor",
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -570,11 +738,10 @@ func TestListAccountsApi(t *testing.T) {
 		{
 			name: "InvalidPageSize",
 			query: Query{
-				pageID:   1,
 				pageSize: 100000,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -606,7 +773,12 @@ func TestListAccountsApi(t *testing.T) {
 
 			// Add query parameters to request URL
 			q := request.URL.Query()
-			q.Add("page_id", fmt.Sprintf("%d", tc.query.pageID))
+			if tc.query.cursor != "" {
+				q.Add("cursor", tc.query.cursor)
+			}
+			if tc.query.includeTotal {
+				q.Add("include_total", "true")
+			}
 			q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
 			request.URL.RawQuery = q.Encode()
 
@@ -626,6 +798,7 @@ func generateRandomAccount(owner string) db.Accounts {
 		Owner:    owner,
 		Balance:  util.RandomBalance(currency),
 		Currency: currency,
+		Kind:     util.CustomerAccount,
 	}
 }
 
@@ -657,3 +830,12 @@ func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, account []db.Acc
 	require.NoError(t, err)
 	require.Equal(t, responses.Data, account)
 }
+
+func requireBodyMatchAccountTx(t *testing.T, body *bytes.Buffer, result db.AccountTxResponse) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	expected, err := json.Marshal(gin.H{"data": result})
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(data))
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rouclec/simplebank/db/sqlc"
+)
+
+// auditContext is who is making the request, for the audit log
+func auditContext(ctx *gin.Context, actor string) *db.AuditContext {
+	return &db.AuditContext{
+		Actor:     actor,
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
+
+type auditEventResponse struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	IP        string          `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	Action    string          `json:"action"`
+	Resource  string          `json:"resource"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// bindAuditEventResponse embeds the states, JSON already, as they are and shows the hashes in hex
+func bindAuditEventResponse(event db.AuditEvents) auditEventResponse {
+	return auditEventResponse{
+		ID:        event.ID,
+		Actor:     event.Actor,
+		IP:        event.Ip,
+		UserAgent: event.UserAgent,
+		Action:    event.Action,
+		Resource:  event.Resource,
+		Before:    event.Before,
+		After:     event.After,
+		PrevHash:  hex.EncodeToString(event.PrevHash),
+		Hash:      hex.EncodeToString(event.Hash),
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+type listAuditEventsRequest struct {
+	Actor    string    `form:"actor"`
+	Action   string    `form:"action"`
+	Resource string    `form:"resource"`
+	FromDate time.Time `form:"from_date" time_format:"2006-01-02T15:04:05Z07:00"`
+	ToDate   time.Time `form:"to_date" time_format:"2006-01-02T15:04:05Z07:00"`
+	pageRequest
+}
+
+// listAuditEvents lists the audit log, oldest first, narrowed down by any of the filters
+func (server *Server) listAuditEvents(ctx *gin.Context) {
+	var req listAuditEventsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.FromDate.IsZero() && !req.ToDate.IsZero() && !req.FromDate.Before(req.ToDate) {
+		err := errors.New("from_date must be before to_date")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := req.afterID()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListAuditEventsParams{
+		Actor:    pgtype.Text{String: req.Actor, Valid: req.Actor != ""},
+		Action:   pgtype.Text{String: req.Action, Valid: req.Action != ""},
+		Resource: pgtype.Text{String: req.Resource, Valid: req.Resource != ""},
+		FromDate: pgtype.Timestamptz{Time: req.FromDate, Valid: !req.FromDate.IsZero()},
+		ToDate:   pgtype.Timestamptz{Time: req.ToDate, Valid: !req.ToDate.IsZero()},
+		AfterID:  afterID,
+		Limit:    req.limit(),
+	}
+
+	events, err := server.store.ListAuditEvents(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var totalCount *int64
+	if req.IncludeTotal {
+		count, err := server.store.CountAuditEvents(ctx, db.CountAuditEventsParams{
+			Actor:    arg.Actor,
+			Action:   arg.Action,
+			Resource: arg.Resource,
+			FromDate: arg.FromDate,
+			ToDate:   arg.ToDate,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		totalCount = &count
+	}
+
+	events, nextCursor := trimPage(events, req.PageSize, func(event db.AuditEvents) int64 {
+		return event.ID
+	})
+
+	response := make([]auditEventResponse, len(events))
+	for i, event := range events {
+		response[i] = bindAuditEventResponse(event)
+	}
+
+	ctx.JSON(http.StatusOK, pageEnvelope(response, nextCursor, totalCount))
+}
+
+// verifyAuditChain checks no audit event was changed or removed since it was written
+func (server *Server) verifyAuditChain(ctx *gin.Context) {
+	report, err := server.store.VerifyAuditChain(ctx)
+	if err != nil {
+		if errors.Is(err, db.ErrAuditChainBroken) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"events":    report.Events,
+			"last_hash": hex.EncodeToString(report.LastHash),
+		},
+	})
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/rouclec/simplebank/db/mock"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAuditEventsApi(t *testing.T) {
+	user, _ := randomUser(t)
+
+	events := []db.AuditEvents{
+		{
+			ID:       1,
+			Actor:    user.Username,
+			Action:   util.AuditAccountCreate,
+			Resource: "account/7",
+			After:    []byte(`{"id":7}`),
+			PrevHash: []byte{},
+			Hash:     []byte{0xab, 0xcd},
+		},
+		{
+			ID:       2,
+			Actor:    user.Username,
+			Action:   util.AuditDeposit,
+			Resource: "account/7",
+			Before:   []byte(`{"balance":"0"}`),
+			After:    []byte(`{"balance":"10"}`),
+			PrevHash: []byte{0xab, 0xcd},
+			Hash:     []byte{0xef},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: fmt.Sprintf("page_size=5&actor=%s&resource=account/7", user.Username),
+			role:  util.AuditorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAuditEventsParams{
+					Actor:    pgtype.Text{String: user.Username, Valid: true},
+					Resource: pgtype.Text{String: "account/7", Valid: true},
+					AfterID:  0,
+					Limit:    6,
+				}
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Eq(arg)).Times(1).Return(events, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Data []auditEventResponse `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Len(t, response.Data, 2)
+				require.Equal(t, "null", string(response.Data[0].Before))
+				require.JSONEq(t, `{"id":7}`, string(response.Data[0].After))
+				require.Equal(t, "abcd", response.Data[0].Hash)
+				require.Equal(t, "abcd", response.Data[1].PrevHash)
+			},
+		},
+		{
+			name:  "BankerCanRead",
+			query: "page_size=5&action=account.deposit",
+			role:  util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(1).Return(events[1:], nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "NotAllowed",
+			query: "page_size=5",
+			role:  util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidDateRange",
+			query: "page_size=5&from_date=2024-02-01T00:00:00Z&to_date=2024-01-01T00:00:00Z",
+			role:  util.AuditorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalServerError",
+			query: "page_size=5",
+			role:  util.AuditorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/api/v1/admin/audit-events?" + tc.query
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, user.Username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVerifyAuditChainApi(t *testing.T) {
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Intact",
+			buildStubs: func(store *mockdb.MockStore) {
+				report := db.AuditChainReport{Events: 12, LastHash: []byte{0x01, 0xff}}
+				store.EXPECT().VerifyAuditChain(gomock.Any()).Times(1).Return(report, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"data":{"events":12,"last_hash":"01ff"}}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "Broken",
+			buildStubs: func(store *mockdb.MockStore) {
+				err := fmt.Errorf("%w at event %d", db.ErrAuditChainBroken, 5)
+				store.EXPECT().VerifyAuditChain(gomock.Any()).Times(1).Return(db.AuditChainReport{Events: 4}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "at event 5")
+			},
+		},
+		{
+			name: "InternalServerError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyAuditChain(gomock.Any()).Times(1).Return(db.AuditChainReport{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/admin/audit-events/verify", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, "auditor", util.AuditorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/shopspring/decimal"
+)
+
+type listEntriesRequest struct {
+	FromDate time.Time `form:"from_date" time_format:"2006-01-02T15:04:05Z07:00"`
+	ToDate   time.Time `form:"to_date" time_format:"2006-01-02T15:04:05Z07:00"`
+	pageRequest
+}
+
+type entryResponse struct {
+	ID     int64           `json:"id"`
+	Type   string          `json:"type"`
+	Amount decimal.Decimal `json:"amount"`
+	// Balance is the account balance once the entry was applied
+	Balance    decimal.Decimal `json:"balance"`
+	TransferID *int64          `json:"transfer_id,omitempty"`
+	// Link points at the transfer that produced the entry
+	Link      string    `json:"link,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type listEntriesResponse struct {
+	AccountID      int64           `json:"account_id"`
+	Currency       string          `json:"currency"`
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+	ClosingBalance decimal.Decimal `json:"closing_balance"`
+	Entries        []entryResponse `json:"entries"`
+}
+
+func bindEntryResponse(entry db.ListEntriesWithBalanceRow) entryResponse {
+	response := entryResponse{
+		ID:        entry.ID,
+		Type:      entry.Type,
+		Amount:    entry.Amount,
+		Balance:   entry.Balance,
+		CreatedAt: entry.CreatedAt,
+	}
+
+	if entry.TransferID.Valid {
+		transferID := entry.TransferID.Int64
+		response.TransferID = &transferID
+		response.Link = fmt.Sprintf("/api/v1/transfers/%d", transferID)
+	}
+
+	return response
+}
+
+// listAccountEntries lists the entries of an account between from_date and to_date,
+// with the balance before the first and after the last entry in that range
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req listEntriesRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.FromDate.IsZero() && !req.ToDate.IsZero() && !req.FromDate.Before(req.ToDate) {
+		err := errors.New("from_date must be before to_date")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := req.afterID()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.validAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+
+	if !canReadAllAccounts(authPayload) && account.Owner != authPayload.Username {
+		err := errors.New("unauthorized access to account")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	fromDate := pgtype.Timestamptz{Time: req.FromDate, Valid: !req.FromDate.IsZero()}
+	toDate := pgtype.Timestamptz{Time: req.ToDate, Valid: !req.ToDate.IsZero()}
+
+	balance, err := server.store.GetEntriesBalance(ctx, db.GetEntriesBalanceParams{
+		AccountID: account.ID,
+		FromDate:  fromDate,
+		ToDate:    toDate,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	entries, err := server.store.ListEntriesWithBalance(ctx, db.ListEntriesWithBalanceParams{
+		AccountID: account.ID,
+		FromDate:  fromDate,
+		ToDate:    toDate,
+		AfterID:   afterID,
+		Limit:     req.limit(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var totalCount *int64
+	if req.IncludeTotal {
+		count, err := server.store.CountEntries(ctx, db.CountEntriesParams{
+			AccountID: account.ID,
+			FromDate:  fromDate,
+			ToDate:    toDate,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		totalCount = &count
+	}
+
+	entries, nextCursor := trimPage(entries, req.PageSize, func(entry db.ListEntriesWithBalanceRow) int64 {
+		return entry.ID
+	})
+
+	response := listEntriesResponse{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		OpeningBalance: balance.OpeningBalance,
+		ClosingBalance: balance.ClosingBalance,
+		Entries:        make([]entryResponse, len(entries)),
+	}
+	for i, entry := range entries {
+		response.Entries[i] = bindEntryResponse(entry)
+	}
+
+	ctx.JSON(http.StatusOK, pageEnvelope(response, nextCursor, totalCount))
+}
@@ -0,0 +1,343 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/rouclec/simplebank/db/mock"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountEntriesApi(t *testing.T) {
+	user, _ := randomUser(t)
+	account := generateRandomAccount(user.Username)
+	user2, _ := randomUser(t)
+
+	fromDate := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	opening := decimal.NewFromInt(100)
+	entries := []db.ListEntriesWithBalanceRow{
+		{
+			ID:         1,
+			AccountID:  account.ID,
+			Amount:     decimal.NewFromInt(-40),
+			Type:       util.TransferEntry,
+			TransferID: pgtype.Int8{Int64: 7, Valid: true},
+			Balance:    decimal.NewFromInt(60),
+			CreatedAt:  fromDate.Add(time.Hour),
+		},
+		{
+			ID:        2,
+			AccountID: account.ID,
+			Amount:    decimal.NewFromInt(15),
+			Type:      util.DepositEntry,
+			Balance:   decimal.NewFromInt(75),
+			CreatedAt: fromDate.Add(2 * time.Hour),
+		},
+	}
+	balance := db.GetEntriesBalanceRow{
+		OpeningBalance: opening,
+		ClosingBalance: decimal.NewFromInt(75),
+	}
+
+	type Query struct {
+		fromDate string
+		toDate   string
+		cursor   string
+		pageSize int
+		total    bool
+	}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		query         Query
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			query: Query{
+				fromDate: fromDate.Format(time.RFC3339),
+				toDate:   toDate.Format(time.RFC3339),
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				balanceArg := db.GetEntriesBalanceParams{
+					AccountID: account.ID,
+					FromDate:  pgtype.Timestamptz{Time: fromDate, Valid: true},
+					ToDate:    pgtype.Timestamptz{Time: toDate, Valid: true},
+				}
+				store.EXPECT().GetEntriesBalance(gomock.Any(), gomock.Eq(balanceArg)).Times(1).Return(balance, nil)
+
+				listArg := db.ListEntriesWithBalanceParams{
+					AccountID: account.ID,
+					FromDate:  pgtype.Timestamptz{Time: fromDate, Valid: true},
+					ToDate:    pgtype.Timestamptz{Time: toDate, Valid: true},
+					Limit:     6,
+				}
+				store.EXPECT().ListEntriesWithBalance(gomock.Any(), gomock.Eq(listArg)).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requirePage(t, recorder.Body, "", nil)
+
+				var body struct {
+					Data listEntriesResponse `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &body)
+				require.NoError(t, err)
+
+				require.Equal(t, account.ID, body.Data.AccountID)
+				require.Equal(t, account.Currency, body.Data.Currency)
+				require.True(t, opening.Equal(body.Data.OpeningBalance))
+				require.True(t, balance.ClosingBalance.Equal(body.Data.ClosingBalance))
+				require.Len(t, body.Data.Entries, len(entries))
+
+				transferEntry := body.Data.Entries[0]
+				require.Equal(t, util.TransferEntry, transferEntry.Type)
+				require.NotNil(t, transferEntry.TransferID)
+				require.Equal(t, int64(7), *transferEntry.TransferID)
+				require.Equal(t, "/api/v1/transfers/7", transferEntry.Link)
+				require.True(t, entries[0].Balance.Equal(transferEntry.Balance))
+
+				depositEntry := body.Data.Entries[1]
+				require.Equal(t, util.DepositEntry, depositEntry.Type)
+				require.Nil(t, depositEntry.TransferID)
+				require.Empty(t, depositEntry.Link)
+			},
+		},
+		{
+			name:      "Auditor",
+			accountID: account.ID,
+			query: Query{
+				cursor:   encodeCursor(entries[0].ID),
+				pageSize: 1,
+				total:    true,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user2.Username, util.AuditorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetEntriesBalance(gomock.Any(), gomock.Eq(db.GetEntriesBalanceParams{AccountID: account.ID})).
+					Times(1).
+					Return(balance, nil)
+				listArg := db.ListEntriesWithBalanceParams{
+					AccountID: account.ID,
+					AfterID:   entries[0].ID,
+					Limit:     2,
+				}
+				store.EXPECT().ListEntriesWithBalance(gomock.Any(), gomock.Eq(listArg)).Times(1).Return(entries, nil)
+				store.EXPECT().
+					CountEntries(gomock.Any(), gomock.Eq(db.CountEntriesParams{AccountID: account.ID})).
+					Times(1).
+					Return(int64(len(entries)), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				total := int64(len(entries))
+				requirePage(t, recorder.Body, encodeCursor(entries[0].ID), &total)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			query: Query{
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetEntriesBalance(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListEntriesWithBalance(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			query: Query{
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListEntriesWithBalance(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "AccountNotFound",
+			accountID: account.ID,
+			query: Query{
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Accounts{}, db.ErrRecordNotFound)
+				store.EXPECT().ListEntriesWithBalance(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalServerError",
+			accountID: account.ID,
+			query: Query{
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetEntriesBalance(gomock.Any(), gomock.Any()).Times(1).Return(balance, nil)
+				store.EXPECT().
+					ListEntriesWithBalance(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListEntriesWithBalanceRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidDateRange",
+			accountID: account.ID,
+			query: Query{
+				fromDate: toDate.Format(time.RFC3339),
+				toDate:   fromDate.Format(time.RFC3339),
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidCursor",
+			accountID: account.ID,
+			query: Query{
+				cursor:   "bad",
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidPageSize",
+			accountID: account.ID,
+			query: Query{
+				pageSize: 10000,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidID",
+			accountID: 0,
+			query: Query{
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/accounts/%d/entries", tc.accountID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			q := request.URL.Query()
+			if tc.query.fromDate != "" {
+				q.Add("from_date", tc.query.fromDate)
+			}
+			if tc.query.toDate != "" {
+				q.Add("to_date", tc.query.toDate)
+			}
+			if tc.query.cursor != "" {
+				q.Add("cursor", tc.query.cursor)
+			}
+			if tc.query.total {
+				q.Add("include_total", "true")
+			}
+			q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
+			request.URL.RawQuery = q.Encode()
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
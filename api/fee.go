@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+type createFeeScheduleRequest struct {
+	Currency   string              `json:"currency" binding:"required,currency"`
+	Kind       string              `json:"kind" binding:"required,oneof=flat percentage tiered fx_markup"`
+	FlatAmount decimal.Decimal     `json:"flat_amount" binding:"gte=0"`
+	Rate       decimal.Decimal     `json:"rate" binding:"gte=0,lt=1"`
+	MinAmount  decimal.Decimal     `json:"min_amount" binding:"gte=0"`
+	MaxAmount  decimal.NullDecimal `json:"max_amount"`
+}
+
+// validate checks the request sets what its kind of schedule charges by
+func (req createFeeScheduleRequest) validate() error {
+	switch req.Kind {
+	case util.FlatFee:
+		if !req.FlatAmount.IsPositive() {
+			return errors.New("flat fee schedules need a positive flat_amount")
+		}
+	case util.PercentageFee, util.FXMarkupFee:
+		if !req.Rate.IsPositive() {
+			return fmt.Errorf("%s fee schedules need a positive rate", req.Kind)
+		}
+	case util.TieredFee:
+		if !req.FlatAmount.IsPositive() && !req.Rate.IsPositive() {
+			return errors.New("tiered fee schedules need a positive flat_amount or rate")
+		}
+		if req.MaxAmount.Valid && !req.MaxAmount.Decimal.GreaterThan(req.MinAmount) {
+			return errors.New("max_amount must be greater than min_amount")
+		}
+	}
+	return nil
+}
+
+func (server *Server) createFeeSchedule(ctx *gin.Context) {
+	var req createFeeScheduleRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !validAmount(ctx, req.FlatAmount, req.Currency) {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	schedule, err := server.store.CreateFeeScheduleTx(ctx, db.CreateFeeScheduleTxRequest{
+		CreateFeeScheduleParams: db.CreateFeeScheduleParams{
+			Currency:   req.Currency,
+			Kind:       req.Kind,
+			FlatAmount: req.FlatAmount,
+			Rate:       req.Rate,
+			MinAmount:  req.MinAmount,
+			MaxAmount:  req.MaxAmount,
+		},
+		Audit: auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": schedule,
+	})
+}
+
+type listFeeSchedulesRequest struct {
+	Currency string `form:"currency" binding:"omitempty,currency"`
+}
+
+func (server *Server) listFeeSchedules(ctx *gin.Context) {
+	var req listFeeSchedulesRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedules, err := server.store.ListFeeSchedules(ctx, pgtype.Text{String: req.Currency, Valid: req.Currency != ""})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": schedules,
+	})
+}
+
+type deleteFeeScheduleRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deleteFeeSchedule(ctx *gin.Context) {
+	var req deleteFeeScheduleRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	schedule, err := server.store.DeleteFeeScheduleTx(ctx, db.DeleteFeeScheduleTxRequest{
+		ID:    req.ID,
+		Audit: auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Fee schedule with id %v not found", req.ID),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": schedule,
+	})
+}
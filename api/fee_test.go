@@ -0,0 +1,370 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/rouclec/simplebank/db/mock"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func randomFeeSchedule() db.FeeSchedules {
+	return db.FeeSchedules{
+		ID:        util.RandomInt(1, 1000),
+		Currency:  "USD",
+		Kind:      util.PercentageFee,
+		Rate:      decimal.RequireFromString("0.015"),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func requireBodyMatchFeeSchedule(t *testing.T, body *bytes.Buffer, schedule db.FeeSchedules) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var response struct {
+		Data db.FeeSchedules `json:"data"`
+	}
+	err = json.Unmarshal(data, &response)
+	require.NoError(t, err)
+	require.Equal(t, schedule.ID, response.Data.ID)
+	require.Equal(t, schedule.Kind, response.Data.Kind)
+	require.True(t, schedule.Rate.Equal(response.Data.Rate))
+}
+
+func TestCreateFeeScheduleAPI(t *testing.T) {
+	schedule := randomFeeSchedule()
+
+	bankerAuth := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authTypeBearer, "banker", util.BankerRole, time.Minute)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			body:      gin.H{"currency": "USD", "kind": util.PercentageFee, "rate": "0.015"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateFeeScheduleTxRequest{
+					CreateFeeScheduleParams: db.CreateFeeScheduleParams{
+						Currency: "USD",
+						Kind:     util.PercentageFee,
+						Rate:     decimal.RequireFromString("0.015"),
+					},
+					Audit: &db.AuditContext{Actor: "banker"},
+				}
+				store.EXPECT().
+					CreateFeeScheduleTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(schedule, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				requireBodyMatchFeeSchedule(t, recorder.Body, schedule)
+			},
+		},
+		{
+			name: "Tiered",
+			body: gin.H{
+				"currency":    "EUR",
+				"kind":        util.TieredFee,
+				"flat_amount": "1.50",
+				"min_amount":  "0",
+				"max_amount":  "100",
+			},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFeeScheduleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateFeeScheduleTxRequest) (db.FeeSchedules, error) {
+						require.True(t, arg.MaxAmount.Valid)
+						require.True(t, decimal.NewFromInt(100).Equal(arg.MaxAmount.Decimal))
+						return schedule, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "NotABanker",
+			body: gin.H{"currency": "USD", "kind": util.PercentageFee, "rate": "0.015"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidKind",
+			body:      gin.H{"currency": "USD", "kind": "monthly", "rate": "0.015"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingRate",
+			body:      gin.H{"currency": "USD", "kind": util.FXMarkupFee},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "RateTooHigh",
+			body:      gin.H{"currency": "USD", "kind": util.PercentageFee, "rate": "1.5"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidTier",
+			body:      gin.H{"currency": "USD", "kind": util.TieredFee, "flat_amount": "1", "min_amount": "100", "max_amount": "50"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "TooManyDecimals",
+			body:      gin.H{"currency": "XAF", "kind": util.FlatFee, "flat_amount": "1.5"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalServerError",
+			body:      gin.H{"currency": "USD", "kind": util.FlatFee, "flat_amount": "2"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFeeScheduleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FeeSchedules{}, sql.ErrTxDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/admin/fee-schedules", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListFeeSchedulesAPI(t *testing.T) {
+	schedules := []db.FeeSchedules{randomFeeSchedule(), randomFeeSchedule()}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFeeSchedules(gomock.Any(), gomock.Eq(pgtype.Text{})).
+					Times(1).
+					Return(schedules, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Data []db.FeeSchedules `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Len(t, response.Data, len(schedules))
+			},
+		},
+		{
+			name:  "Currency",
+			query: "?currency=USD",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFeeSchedules(gomock.Any(), gomock.Eq(pgtype.Text{String: "USD", Valid: true})).
+					Times(1).
+					Return(schedules, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidCurrency",
+			query: "?currency=XYZ",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListFeeSchedules(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/admin/fee-schedules"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, "banker", util.BankerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteFeeScheduleAPI(t *testing.T) {
+	schedule := randomFeeSchedule()
+
+	testCases := []struct {
+		name          string
+		id            int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			id:   schedule.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.DeleteFeeScheduleTxRequest{
+					ID:    schedule.ID,
+					Audit: &db.AuditContext{Actor: "banker"},
+				}
+				store.EXPECT().
+					DeleteFeeScheduleTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(schedule, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchFeeSchedule(t, recorder.Body, schedule)
+			},
+		},
+		{
+			name: "NotFound",
+			id:   schedule.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteFeeScheduleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FeeSchedules{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/admin/fee-schedules/%d", tc.id)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, "banker", util.BankerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
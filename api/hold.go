@@ -0,0 +1,308 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/fx"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+type createHoldRequest struct {
+	Amount    decimal.Decimal `json:"amount" binding:"required,gt=0"`
+	Reason    string          `json:"reason" binding:"max=255"`
+	ExpiresAt time.Time       `json:"expires_at" binding:"required"`
+}
+
+// canManageHolds reports whether the caller may place, capture and release
+// holds on the account: its owner or a banker
+func canManageHolds(authPayload *token.Payload, account db.Accounts) bool {
+	return authPayload.Role == util.BankerRole || account.Owner == authPayload.Username
+}
+
+// createHold reserves money on the account until the hold is captured,
+// released or expires
+func (server *Server) createHold(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req createHoldRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.ExpiresAt.After(time.Now()) {
+		err := errors.New("expires_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.validAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if !canManageHolds(authPayload, account) {
+		err := errors.New("unauthorized access to account")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if !validAmount(ctx, req.Amount, account.Currency) {
+		return
+	}
+
+	hold, err := server.store.PlaceHoldTx(ctx, db.PlaceHoldTxRequest{
+		AccountID: account.ID,
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+		CreatedBy: authPayload.Username,
+		ExpiresAt: req.ExpiresAt,
+		Audit:     auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		reportRolledBack(ctx)
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": hold,
+	})
+}
+
+type listHoldsRequest struct {
+	pageRequest
+}
+
+func (server *Server) listHolds(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req listHoldsRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := req.afterID()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.validAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if !canReadAllAccounts(authPayload) && account.Owner != authPayload.Username {
+		err := errors.New("unauthorized access to account")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	holds, err := server.store.ListAccountHolds(ctx, db.ListAccountHoldsParams{
+		AccountID: account.ID,
+		AfterID:   afterID,
+		Limit:     req.limit(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var totalCount *int64
+	if req.IncludeTotal {
+		count, err := server.store.CountAccountHolds(ctx, account.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		totalCount = &count
+	}
+
+	holds, nextCursor := trimPage(holds, req.PageSize, func(hold db.AccountHolds) int64 {
+		return hold.ID
+	})
+
+	ctx.JSON(http.StatusOK, pageEnvelope(holds, nextCursor, totalCount))
+}
+
+type holdRequest struct {
+	AccountID int64 `uri:"id" binding:"required,min=1"`
+	HoldID    int64 `uri:"hold_id" binding:"required,min=1"`
+}
+
+// validHold loads the hold in the uri, writing the error response when it is
+// not on the account or the caller may not manage the account's holds
+func (server *Server) validHold(ctx *gin.Context, uri holdRequest) (db.AccountHolds, db.Accounts, bool) {
+	account, valid := server.validAccount(ctx, uri.AccountID)
+	if !valid {
+		return db.AccountHolds{}, account, false
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if !canManageHolds(authPayload, account) {
+		err := errors.New("unauthorized access to account")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return db.AccountHolds{}, account, false
+	}
+
+	hold, err := server.store.GetAccountHold(ctx, uri.HoldID)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, account, false
+	}
+
+	if err != nil || hold.AccountID != account.ID {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": fmt.Sprintf("Hold with id %v not found", uri.HoldID),
+		})
+		return hold, account, false
+	}
+
+	return hold, account, true
+}
+
+type captureHoldRequest struct {
+	ToAccountID int64 `json:"to_account_id" binding:"required,min=1"`
+	// Amount to transfer, the whole hold when left out
+	Amount decimal.Decimal `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// captureHold turns the hold into a transfer to another account. Capturing
+// less than the hold releases the rest.
+func (server *Server) captureHold(ctx *gin.Context) {
+	var uri holdRequest
+	var req captureHoldRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, account, valid := server.validHold(ctx, uri)
+	if !valid {
+		return
+	}
+
+	if !req.Amount.IsZero() && !validAmount(ctx, req.Amount, account.Currency) {
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	result, err := server.store.CaptureHoldTx(ctx, db.CaptureHoldTxRequest{
+		HoldID:      hold.ID,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+		Audit:       auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		reportRolledBack(ctx)
+		if errors.Is(err, db.ErrHoldNotActive) || errors.Is(err, db.ErrTransferHold) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrHoldExpired) {
+			ctx.JSON(http.StatusGone, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrHoldMismatch) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrTransferLimitExceeded) || errors.Is(err, db.ErrTransferBlocked) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrTransferVelocityExceeded) {
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
+		if errors.Is(err, fx.ErrStaleRates) {
+			ctx.JSON(http.StatusServiceUnavailable, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// captures held for review move no money until a banker approves them
+	status := http.StatusCreated
+	if result.Transfer.Status == util.TransferPending {
+		status = http.StatusAccepted
+	}
+
+	ctx.JSON(status, gin.H{
+		"data": result,
+	})
+}
+
+// releaseHold gives the hold up, making its amount available again
+func (server *Server) releaseHold(ctx *gin.Context) {
+	var uri holdRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, _, valid := server.validHold(ctx, uri)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	hold, err := server.store.ReleaseHoldTx(ctx, db.ReleaseHoldTxRequest{
+		HoldID: hold.ID,
+		Audit:  auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrHoldNotActive) || errors.Is(err, db.ErrTransferHold) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": hold,
+	})
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	idempotencyKeyInProgress = 0
+	jsonContentType          = "application/json; charset=utf-8"
+	// idempotencyKeyLease is how long a request holds its key. A key still in
+	// progress after that, its request lost to a crash, can be taken over by a retry.
+	idempotencyKeyLease = time.Minute
+	// rolledBackKey is set by handlers whose failure committed nothing
+	rolledBackKey = "rolled_back"
+)
+
+// reportRolledBack tells idempotencyMiddleware that the request failed without
+// committing anything, so its key can be released for the client to retry.
+// Server errors are otherwise stored and replayed like any other response, as
+// the handler may have failed after its transaction committed.
+func reportRolledBack(ctx *gin.Context) {
+	ctx.Set(rolledBackKey, true)
+}
+
+// responseRecorder keeps a copy of everything the handler writes so it can be replayed
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+func (recorder *responseRecorder) WriteString(s string) (int, error) {
+	recorder.body.WriteString(s)
+	return recorder.ResponseWriter.WriteString(s)
+}
+
+// requestHash fingerprints a request so a reused key can be told apart from a retry
+func requestHash(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotencyMiddleware makes the route safe to retry when the client sends an Idempotency-Key header.
+// The first request with a key runs the handler and stores its response; later requests with the
+// same key and body get that response back without running the handler again.
+func idempotencyMiddleware(store db.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			err := errors.New("idempotency key is too long")
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+		hash := requestHash(ctx.Request.Method, ctx.Request.URL.Path, body)
+
+		_, err = store.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+			Username:    authPayload.Username,
+			Key:         key,
+			RequestHash: hash,
+			LockedUntil: time.Now().Add(idempotencyKeyLease),
+		})
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// the key has been used before
+			replayIdempotentResponse(ctx, store, authPayload.Username, key, hash)
+			return
+		}
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				// the handler may have committed before panicking, so a retry must not run it again
+				responseBody, _ := json.Marshal(errorResponse(errors.New("internal server error")))
+				completeIdempotencyKey(ctx, store, authPayload.Username, key, http.StatusInternalServerError, responseBody)
+				panic(recovered)
+			}
+		}()
+
+		ctx.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError && ctx.GetBool(rolledBackKey) {
+			// nothing was committed, let the client retry with the same key
+			err := store.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{
+				Username: authPayload.Username,
+				Key:      key,
+			})
+			if err != nil {
+				// the key is released anyway once its lease runs out
+				log.Println("error releasing idempotency key: ", err)
+			}
+			return
+		}
+
+		var responseBody []byte
+		if recorder.body.Len() > 0 {
+			responseBody = recorder.body.Bytes()
+		}
+
+		completeIdempotencyKey(ctx, store, authPayload.Username, key, status, responseBody)
+	}
+}
+
+// completeIdempotencyKey stores the response to replay for the key. The client
+// has its response already, so a failure can only be logged; the key is then
+// released once its lease runs out.
+func completeIdempotencyKey(ctx *gin.Context, store db.Store, username string, key string, status int, responseBody []byte) {
+	_, err := store.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		ResponseStatus: int32(status),
+		ResponseBody:   responseBody,
+		Username:       username,
+		Key:            key,
+	})
+	if err != nil {
+		log.Println("error storing idempotent response: ", err)
+	}
+}
+
+func replayIdempotentResponse(ctx *gin.Context, store db.Store, username string, key string, hash string) {
+	idempotencyKey, err := store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: username,
+		Key:      key,
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if idempotencyKey.RequestHash != hash {
+		err := errors.New("idempotency key was already used with a different request")
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	if idempotencyKey.ResponseStatus == idempotencyKeyInProgress {
+		// the key can be retried once the lease of the request holding it runs out
+		retryAfter := max(time.Until(idempotencyKey.LockedUntil), time.Second)
+		ctx.Header("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second).Seconds())))
+
+		err := errors.New("a request with this idempotency key is still being processed")
+		ctx.AbortWithStatusJSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	ctx.Header(idempotentReplayedHeader, "true")
+	ctx.Data(int(idempotencyKey.ResponseStatus), jsonContentType, idempotencyKey.ResponseBody)
+	ctx.Abort()
+}
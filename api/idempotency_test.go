@@ -0,0 +1,393 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/rouclec/simplebank/db/mock"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentCreateTransfer(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := generateRandomAccount(user1.Username)
+	account2 := generateRandomAccount(user2.Username)
+	account1.Currency = "USD"
+	account2.Currency = "USD"
+
+	body := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          10,
+		"currency":        "USD",
+	}
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	key := util.RandomString(32)
+	hash := requestHash(http.MethodPost, "/api/v1/transfers", data)
+	storedResponse := []byte(`{"data":{"transfer":{"id":1}}}`)
+
+	testCases := []struct {
+		name          string
+		key           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "NoKey",
+			key:  "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "FirstRequest",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateIdempotencyKeyParams) (db.IdempotencyKeys, error) {
+						require.Equal(t, user1.Username, arg.Username)
+						require.Equal(t, key, arg.Key)
+						require.Equal(t, hash, arg.RequestHash)
+						require.WithinDuration(t, time.Now().Add(idempotencyKeyLease), arg.LockedUntil, time.Second)
+						return db.IdempotencyKeys{Username: user1.Username, Key: key, RequestHash: hash}, nil
+					})
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CompleteIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CompleteIdempotencyKeyParams) (db.IdempotencyKeys, error) {
+						require.Equal(t, int32(http.StatusCreated), arg.ResponseStatus)
+						require.NotEmpty(t, arg.ResponseBody)
+						return db.IdempotencyKeys{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Empty(t, recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name: "Replay",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKeys{}, db.ErrRecordNotFound)
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(db.GetIdempotencyKeyParams{
+					Username: user1.Username,
+					Key:      key,
+				})).Times(1).Return(db.IdempotencyKeys{
+					Username:       user1.Username,
+					Key:            key,
+					RequestHash:    hash,
+					ResponseStatus: http.StatusCreated,
+					ResponseBody:   storedResponse,
+				}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+				require.JSONEq(t, string(storedResponse), recorder.Body.String())
+			},
+		},
+		{
+			name: "DifferentBody",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKeys{}, db.ErrRecordNotFound)
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKeys{
+					Username:       user1.Username,
+					Key:            key,
+					RequestHash:    "another request",
+					ResponseStatus: http.StatusCreated,
+					ResponseBody:   storedResponse,
+				}, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "InProgress",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKeys{}, db.ErrRecordNotFound)
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKeys{
+					Username:    user1.Username,
+					Key:         key,
+					RequestHash: hash,
+					LockedUntil: time.Now().Add(30 * time.Second),
+				}, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+
+				// the client is told when the lease on the key runs out
+				retryAfter, err := strconv.Atoi(recorder.Header().Get("Retry-After"))
+				require.NoError(t, err)
+				require.InDelta(t, 30, retryAfter, 1)
+			},
+		},
+		{
+			name: "ServerErrorReleasesKey",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKeys{}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransfersTxResponse{}, db.ErrUniqueViolation)
+				store.EXPECT().CompleteIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DeleteIdempotencyKey(gomock.Any(), gomock.Eq(db.DeleteIdempotencyKeyParams{
+					Username: user1.Username,
+					Key:      key,
+				})).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "KeyTooLong",
+			key:  strings.Repeat("k", maxIdempotencyKeyLength+1),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			if tc.key != "" {
+				request.Header.Set(idempotencyKeyHeader, tc.key)
+			}
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestIdempotencyServerErrors(t *testing.T) {
+	username := util.RandomOwner()
+	key := util.RandomString(32)
+
+	testCases := []struct {
+		name          string
+		handler       gin.HandlerFunc
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FailedAfterCommit",
+			handler: func(ctx *gin.Context) {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(errors.New("error building response")))
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().DeleteIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CompleteIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CompleteIdempotencyKeyParams) (db.IdempotencyKeys, error) {
+						// retries replay the error rather than running the handler again
+						require.Equal(t, int32(http.StatusInternalServerError), arg.ResponseStatus)
+						require.Contains(t, string(arg.ResponseBody), "error building response")
+						return db.IdempotencyKeys{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "RolledBack",
+			handler: func(ctx *gin.Context) {
+				reportRolledBack(ctx)
+				ctx.JSON(http.StatusServiceUnavailable, errorResponse(errors.New("rates are stale")))
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CompleteIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DeleteIdempotencyKey(gomock.Any(), gomock.Eq(db.DeleteIdempotencyKeyParams{
+					Username: username,
+					Key:      key,
+				})).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+		{
+			name: "DeleteError",
+			handler: func(ctx *gin.Context) {
+				reportRolledBack(ctx)
+				ctx.JSON(http.StatusInternalServerError, errorResponse(sql.ErrConnDone))
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().DeleteIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "Panic",
+			handler: func(ctx *gin.Context) {
+				panic("nil map")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CompleteIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CompleteIdempotencyKeyParams) (db.IdempotencyKeys, error) {
+						require.Equal(t, int32(http.StatusInternalServerError), arg.ResponseStatus)
+						return db.IdempotencyKeys{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "CompleteError",
+			handler: func(ctx *gin.Context) {
+				ctx.JSON(http.StatusCreated, gin.H{"data": "done"})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CompleteIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKeys{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// the handler's response stands, the key is released when its lease runs out
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.router.POST(
+				"/idempotent",
+				authMiddleware(server.tokenMaker, fakeRevocations{}),
+				idempotencyMiddleware(store),
+				tc.handler,
+			)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/idempotent", strings.NewReader("{}"))
+			require.NoError(t, err)
+
+			request.Header.Set(idempotencyKeyHeader, key)
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, username, util.DepositorRole, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestIdempotencyKeyReusedOnAnotherAccount(t *testing.T) {
+	user, _ := randomUser(t)
+	account1 := generateRandomAccount(user.Username)
+	account2 := generateRandomAccount(user.Username)
+	account2.ID = account1.ID + 1
+	key := util.RandomString(16)
+
+	data, err := json.Marshal(gin.H{"amount": "10"})
+	require.NoError(t, err)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := mockdb.NewMockStore(controller)
+
+	// the first deposit claims the key for its own account's path
+	var stored db.IdempotencyKeys
+	store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ any, arg db.CreateIdempotencyKeyParams) (db.IdempotencyKeys, error) {
+			stored = db.IdempotencyKeys{Username: arg.Username, Key: arg.Key, RequestHash: arg.RequestHash}
+			return stored, nil
+		})
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+	store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountTxResponse{Account: account1}, nil)
+	store.EXPECT().CompleteIdempotencyKey(gomock.Any(), gomock.Any()).Times(1)
+
+	// the same key and body against another account is a different request
+	store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKeys{}, db.ErrRecordNotFound)
+	store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ any, _ db.GetIdempotencyKeyParams) (db.IdempotencyKeys, error) {
+			return stored, nil
+		})
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+	store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+
+	codes := []int{}
+	for _, account := range []db.Accounts{account1, account2} {
+		recorder := httptest.NewRecorder()
+
+		url := fmt.Sprintf("/api/v1/accounts/%d/deposits", account.ID)
+		request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+		require.NoError(t, err)
+
+		request.Header.Set(idempotencyKeyHeader, key)
+		addAuthorization(t, request, server.tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		codes = append(codes, recorder.Code)
+	}
+
+	require.Equal(t, []int{http.StatusCreated, http.StatusUnprocessableEntity}, codes)
+}
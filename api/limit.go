@@ -0,0 +1,160 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+type createTransferLimitRequest struct {
+	// Tier limits every user on it, across all their accounts
+	Tier string `json:"tier" binding:"required_without=AccountID,excluded_with=AccountID,omitempty,tier"`
+	// AccountID limits a single account
+	AccountID     int64           `json:"account_id" binding:"omitempty,min=1"`
+	Kind          string          `json:"kind" binding:"required,oneof=daily monthly single velocity"`
+	Currency      string          `json:"currency" binding:"omitempty,currency"`
+	Amount        decimal.Decimal `json:"amount" binding:"gte=0"`
+	MaxCount      int32           `json:"max_count" binding:"gte=0"`
+	WindowMinutes int32           `json:"window_minutes" binding:"gte=0"`
+}
+
+// validate checks the request sets what its kind of limit caps
+func (req createTransferLimitRequest) validate() error {
+	if req.Kind == util.VelocityLimit {
+		if req.MaxCount <= 0 || req.WindowMinutes <= 0 {
+			return errors.New("velocity limits need a positive max_count and window_minutes")
+		}
+		if req.Currency != "" || !req.Amount.IsZero() {
+			return errors.New("velocity limits count transfers in any currency")
+		}
+		return nil
+	}
+
+	if req.Currency == "" || !req.Amount.IsPositive() {
+		return fmt.Errorf("%s limits need a currency and a positive amount", req.Kind)
+	}
+	if req.MaxCount != 0 || req.WindowMinutes != 0 {
+		return fmt.Errorf("%s limits cap amounts, not the number of transfers", req.Kind)
+	}
+	return nil
+}
+
+func (server *Server) createTransferLimit(ctx *gin.Context) {
+	var req createTransferLimitRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Currency != "" && !validAmount(ctx, req.Amount, req.Currency) {
+		return
+	}
+
+	if req.AccountID != 0 {
+		account, valid := server.validAccount(ctx, req.AccountID)
+		if !valid {
+			return
+		}
+
+		if req.Currency != "" && req.Currency != account.Currency {
+			err := errors.New("account limits are in the account currency")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	limit, err := server.store.CreateTransferLimitTx(ctx, db.CreateTransferLimitTxRequest{
+		CreateTransferLimitParams: db.CreateTransferLimitParams{
+			Tier:          req.Tier,
+			AccountID:     pgtype.Int8{Int64: req.AccountID, Valid: req.AccountID != 0},
+			Kind:          req.Kind,
+			Currency:      req.Currency,
+			Amount:        req.Amount,
+			MaxCount:      req.MaxCount,
+			WindowMinutes: req.WindowMinutes,
+		},
+		Audit: auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": limit,
+	})
+}
+
+type listTransferLimitsRequest struct {
+	Tier      string `form:"tier" binding:"omitempty,tier"`
+	AccountID int64  `form:"account_id" binding:"omitempty,min=1"`
+}
+
+func (server *Server) listTransferLimits(ctx *gin.Context) {
+	var req listTransferLimitsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	limits, err := server.store.ListTransferLimits(ctx, db.ListTransferLimitsParams{
+		Tier:      pgtype.Text{String: req.Tier, Valid: req.Tier != ""},
+		AccountID: pgtype.Int8{Int64: req.AccountID, Valid: req.AccountID != 0},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": limits,
+	})
+}
+
+type deleteTransferLimitRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deleteTransferLimit(ctx *gin.Context) {
+	var req deleteTransferLimitRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	limit, err := server.store.DeleteTransferLimitTx(ctx, db.DeleteTransferLimitTxRequest{
+		ID:    req.ID,
+		Audit: auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Transfer limit with id %v not found", req.ID),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": limit,
+	})
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/rouclec/simplebank/db/mock"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTransferLimitAPI(t *testing.T) {
+	account := generateRandomAccount(util.RandomOwner())
+	account.Currency = "USD"
+
+	limit := db.TransferLimits{
+		ID:       util.RandomInt(1, 1000),
+		Tier:     util.StandardTier,
+		Kind:     util.DailyLimit,
+		Currency: "USD",
+		Amount:   decimal.NewFromInt(1000),
+	}
+
+	bankerAuth := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authTypeBearer, "banker", util.BankerRole, time.Minute)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "TierDailyLimit",
+			body:      gin.H{"tier": util.StandardTier, "kind": util.DailyLimit, "currency": "USD", "amount": "1000"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateTransferLimitTxRequest{
+					CreateTransferLimitParams: db.CreateTransferLimitParams{
+						Tier:     util.StandardTier,
+						Kind:     util.DailyLimit,
+						Currency: "USD",
+						Amount:   decimal.NewFromInt(1000),
+					},
+					Audit: &db.AuditContext{Actor: "banker"},
+				}
+				store.EXPECT().
+					CreateTransferLimitTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(limit, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var response struct {
+					Data db.TransferLimits `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, limit.ID, response.Data.ID)
+			},
+		},
+		{
+			name:      "AccountVelocityLimit",
+			body:      gin.H{"account_id": account.ID, "kind": util.VelocityLimit, "max_count": 3, "window_minutes": 10},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.CreateTransferLimitTxRequest{
+					CreateTransferLimitParams: db.CreateTransferLimitParams{
+						AccountID:     pgtype.Int8{Int64: account.ID, Valid: true},
+						Kind:          util.VelocityLimit,
+						MaxCount:      3,
+						WindowMinutes: 10,
+					},
+					Audit: &db.AuditContext{Actor: "banker"},
+				}
+				store.EXPECT().
+					CreateTransferLimitTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(limit, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name:      "TierAndAccount",
+			body:      gin.H{"tier": util.StandardTier, "account_id": account.ID, "kind": util.SingleLimit, "currency": "USD", "amount": "500"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "AccountInAnotherCurrency",
+			body:      gin.H{"account_id": account.ID, "kind": util.MonthlyLimit, "currency": "EUR", "amount": "500"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreateTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "AmountLimitWithoutAmount",
+			body:      gin.H{"tier": util.PremiumTier, "kind": util.SingleLimit, "currency": "USD"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "VelocityLimitWithoutWindow",
+			body:      gin.H{"tier": util.PremiumTier, "kind": util.VelocityLimit, "max_count": 3},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidTier",
+			body:      gin.H{"tier": "gold", "kind": util.DailyLimit, "currency": "USD", "amount": "1000"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotABanker",
+			body: gin.H{"tier": util.StandardTier, "kind": util.DailyLimit, "currency": "USD", "amount": "1000"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalServerError",
+			body:      gin.H{"tier": util.StandardTier, "kind": util.DailyLimit, "currency": "USD", "amount": "1000"},
+			setupAuth: bankerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTransferLimitTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferLimits{}, sql.ErrTxDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/admin/transfer-limits", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteTransferLimitAPI(t *testing.T) {
+	limit := db.TransferLimits{
+		ID:       util.RandomInt(1, 1000),
+		Tier:     util.StandardTier,
+		Kind:     util.DailyLimit,
+		Currency: "USD",
+		Amount:   decimal.NewFromInt(1000),
+	}
+
+	testCases := []struct {
+		name          string
+		id            int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			id:   limit.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.DeleteTransferLimitTxRequest{
+					ID:    limit.ID,
+					Audit: &db.AuditContext{Actor: "banker"},
+				}
+				store.EXPECT().
+					DeleteTransferLimitTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(limit, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			id:   limit.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteTransferLimitTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferLimits{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/admin/transfer-limits/%d", tc.id)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, "banker", util.BankerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/rouclec/simplebank/db/mock"
 	db "github.com/rouclec/simplebank/db/sqlc"
 	"github.com/rouclec/simplebank/util"
 	"github.com/stretchr/testify/require"
@@ -13,14 +15,23 @@ import (
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}
+
+	// most tests are not about revocation, so tokens are never revoked unless a test expects otherwise first
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
 	}
 
 	server, err := NewServer(config, store)
 
 	require.NoError(t, err)
 
+	// webhook hosts are looked up in a fixed table rather than DNS
+	server.resolver = testResolver
+
 	return server
 }
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
 )
 
 const (
@@ -22,30 +23,68 @@ func extractTokenFromHeader(authorizationHeader string) (string, error) {
 	return strings.TrimSpace(strings.TrimPrefix(authorizationHeader, authTypeBearer)), nil // Extract and trim token
 }
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// extractAuthToken reads the access token from the auth cookie, falling back to the authorization header
+func extractAuthToken(ctx *gin.Context) (string, error) {
+	authToken, err := ctx.Cookie(authCookieName)
+	if err == nil {
+		return authToken, nil
+	}
+
+	authorizationHeader := ctx.GetHeader(authHeaderKey)
+	if len(authorizationHeader) == 0 {
+		return "", errors.New("authorization header is not provided")
+	}
+	return extractTokenFromHeader(authorizationHeader)
+}
+
+// requireRoles lets the request through only if the authenticated user holds one of the roles.
+// It must run after authMiddleware.
+func requireRoles(roles ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		var authToken string
-		var err error
-		authToken, err = ctx.Cookie("auth")
+		authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
 
-		if err != nil {
-			authorizationHeader := ctx.GetHeader(authHeaderKey)
-			if len(authorizationHeader) == 0 {
-				err := errors.New("authorization header is not provided")
-				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		for _, role := range roles {
+			if authPayload.Role == role {
+				ctx.Next()
 				return
 			}
-			authToken, err = extractTokenFromHeader(authorizationHeader)
+		}
 
-			if err != nil {
-				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
-				return
-			}
+		err := errors.New("permission denied for this role")
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+	}
+}
+
+// canReadAllAccounts reports whether the user may read accounts and transfers owned by other users
+func canReadAllAccounts(payload *token.Payload) bool {
+	return payload.Role == util.BankerRole || payload.Role == util.AuditorRole
+}
+
+func authMiddleware(tokenMaker token.Maker, revocations tokenRevocationChecker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authToken, err := extractAuthToken(ctx)
+
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		payload, err := tokenMaker.VerifyToken(authToken, token.AccessToken)
+
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
 		}
 
-		payload, err := tokenMaker.VerifyToken(authToken)
+		revoked, err := revocations.IsRevoked(ctx, payload)
 
 		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if revoked {
+			err := errors.New("token has been revoked")
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
 			return
 		}
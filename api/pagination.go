@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageRequest is embedded in the requests of list endpoints. Pages are keyed on
+// the id of the last row of the previous page rather than an offset, so they
+// stay fast and stable while rows are being inserted.
+type pageRequest struct {
+	Cursor       string `form:"cursor"`
+	PageSize     int32  `form:"page_size" binding:"required,min=1,max=100"`
+	IncludeTotal bool   `form:"include_total"`
+}
+
+// cursor is the position a page resumes from, handed to clients as an opaque string
+type cursor struct {
+	ID int64 `json:"id"`
+}
+
+func encodeCursor(id int64) string {
+	data, _ := json.Marshal(cursor{ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// afterID returns the id the requested page starts after, 0 for the first page
+func (req pageRequest) afterID() (int64, error) {
+	if req.Cursor == "" {
+		return 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(req.Cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID < 1 {
+		return 0, errInvalidCursor
+	}
+
+	return c.ID, nil
+}
+
+// limit is how many rows to read: one more than the page size, to tell whether
+// there is a next page
+func (req pageRequest) limit() int32 {
+	return req.PageSize + 1
+}
+
+// trimPage drops the extra row read by limit and returns the cursor of the
+// next page, empty when this is the last one
+func trimPage[T any](items []T, pageSize int32, id func(T) int64) ([]T, string) {
+	if int32(len(items)) <= pageSize {
+		return items, ""
+	}
+
+	items = items[:pageSize]
+	return items, encodeCursor(id(items[len(items)-1]))
+}
+
+// pageEnvelope wraps a page of data with the cursor of the next page and,
+// when requested, the total number of rows across all pages
+func pageEnvelope(data interface{}, nextCursor string, totalCount *int64) gin.H {
+	response := gin.H{
+		"data":        data,
+		"next_cursor": nil,
+	}
+	if nextCursor != "" {
+		response["next_cursor"] = nextCursor
+	}
+	if totalCount != nil {
+		response["total_count"] = *totalCount
+	}
+	return response
+}
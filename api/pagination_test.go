@@ -0,0 +1,55 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	req := pageRequest{Cursor: encodeCursor(42)}
+	afterID, err := req.afterID()
+	require.NoError(t, err)
+	require.Equal(t, int64(42), afterID)
+
+	afterID, err = pageRequest{}.afterID()
+	require.NoError(t, err)
+	require.Zero(t, afterID)
+
+	for _, cursor := range []string{"%%%", "bm90IGpzb24", encodeCursor(0), encodeCursor(-1)} {
+		_, err := pageRequest{Cursor: cursor}.afterID()
+		require.ErrorIs(t, err, errInvalidCursor, cursor)
+	}
+}
+
+func TestTrimPage(t *testing.T) {
+	id := func(i int64) int64 { return i }
+
+	items, next := trimPage([]int64{1, 2, 3}, 3, id)
+	require.Equal(t, []int64{1, 2, 3}, items)
+	require.Empty(t, next)
+
+	items, next = trimPage([]int64{1, 2, 3, 4}, 3, id)
+	require.Equal(t, []int64{1, 2, 3}, items)
+	require.Equal(t, encodeCursor(3), next)
+}
+
+// requirePage checks the pagination fields of a list response without consuming body
+func requirePage(t *testing.T, body *bytes.Buffer, nextCursor string, totalCount *int64) {
+	var page struct {
+		NextCursor *string `json:"next_cursor"`
+		TotalCount *int64  `json:"total_count"`
+	}
+	err := json.Unmarshal(body.Bytes(), &page)
+	require.NoError(t, err)
+
+	if nextCursor == "" {
+		require.Nil(t, page.NextCursor)
+	} else {
+		require.NotNil(t, page.NextCursor)
+		require.Equal(t, nextCursor, *page.NextCursor)
+	}
+	require.Equal(t, totalCount, page.TotalCount)
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/fx"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+// defaultQuoteDuration is how long quotes last when the config leaves it out
+const defaultQuoteDuration = 30 * time.Second
+
+type transferQuoteRequest struct {
+	FromAccountID int64           `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64           `json:"to_account_id" binding:"required,min=1"`
+	Amount        decimal.Decimal `json:"amount" binding:"required,gt=0"`
+	Currency      string          `json:"currency" binding:"required,currency"`
+}
+
+type transferQuoteResponse struct {
+	db.TransferQuotes
+	// Rate is how many units of the destination currency one unit of the source currency buys
+	Rate decimal.Decimal `json:"rate"`
+	// TotalDebit is the amount leaving the source account, fee included
+	TotalDebit decimal.Decimal `json:"total_debit"`
+}
+
+func bindTransferQuoteResponse(quote db.TransferQuotes) transferQuoteResponse {
+	return transferQuoteResponse{
+		TransferQuotes: quote,
+		Rate:           util.CrossRate(quote.FromRate, quote.ToRate),
+		TotalDebit:     quote.FromAmount.Add(quote.Fee),
+	}
+}
+
+// quoteDuration returns how long a new quote can be executed for
+func (server *Server) quoteDuration() time.Duration {
+	if server.config.QuoteDuration > 0 {
+		return server.config.QuoteDuration
+	}
+	return defaultQuoteDuration
+}
+
+// createTransferQuote prices a transfer without moving any money. Executing
+// the quote through createTransfer before it expires gets exactly this price.
+func (server *Server) createTransferQuote(ctx *gin.Context) {
+	var req transferQuoteRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !validAmount(ctx, req.Amount, req.Currency) {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID)
+	if !valid {
+		return
+	}
+
+	quote, err := server.store.QuoteTransfer(ctx, db.QuoteTransferRequest{
+		Username:      authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		ExpiresAt:     time.Now().Add(server.quoteDuration()),
+		Audit:         auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		if errors.Is(err, fx.ErrStaleRates) {
+			ctx.JSON(http.StatusServiceUnavailable, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": bindTransferQuoteResponse(quote),
+	})
+}
+
+// applyQuote fills the fields the transfer request leaves out from the quote it
+// executes, rejecting quotes given to another user or for a different transfer
+func (server *Server) applyQuote(ctx *gin.Context, req *transferRequest) (uuid.UUID, bool) {
+	quoteID, err := uuid.Parse(req.QuoteID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return quoteID, false
+	}
+
+	quote, err := server.store.GetTransferQuote(ctx, quoteID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return quoteID, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return quoteID, false
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if quote.Username != authPayload.Username {
+		err := errors.New("quote doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return quoteID, false
+	}
+
+	if req.FromAccountID == 0 {
+		req.FromAccountID = quote.FromAccountID
+	}
+	if req.ToAccountID == 0 {
+		req.ToAccountID = quote.ToAccountID
+	}
+	if req.Amount.IsZero() {
+		req.Amount = quote.Amount
+	}
+	if req.Currency == "" {
+		req.Currency = quote.Currency
+	}
+
+	if req.FromAccountID != quote.FromAccountID || req.ToAccountID != quote.ToAccountID ||
+		!req.Amount.Equal(quote.Amount) || req.Currency != quote.Currency {
+		ctx.JSON(http.StatusBadRequest, errorResponse(db.ErrQuoteMismatch))
+		return quoteID, false
+	}
+
+	return quoteID, true
+}
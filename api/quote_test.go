@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockdb "github.com/rouclec/simplebank/db/mock"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/fx"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// eqQuoteTransferRequestMatcher matches the request bar its expiry, which depends on the clock
+type eqQuoteTransferRequestMatcher struct {
+	arg db.QuoteTransferRequest
+}
+
+func (expected eqQuoteTransferRequestMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.QuoteTransferRequest)
+	if !ok {
+		return false
+	}
+
+	if arg.ExpiresAt.Before(time.Now().Add(defaultQuoteDuration-time.Minute)) || arg.ExpiresAt.After(time.Now().Add(defaultQuoteDuration)) {
+		return false
+	}
+
+	return arg.Username == expected.arg.Username &&
+		arg.FromAccountID == expected.arg.FromAccountID &&
+		arg.ToAccountID == expected.arg.ToAccountID &&
+		arg.Amount.Equal(expected.arg.Amount) &&
+		arg.Currency == expected.arg.Currency &&
+		reflect.DeepEqual(arg.Audit, expected.arg.Audit)
+}
+
+func (expected eqQuoteTransferRequestMatcher) String() string {
+	return "matches quote request " + expected.arg.Currency
+}
+
+func TestCreateTransferQuoteAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := generateRandomAccount(user1.Username)
+	account2 := generateRandomAccount(user2.Username)
+
+	quote := db.TransferQuotes{
+		ID:            uuid.New(),
+		Username:      user1.Username,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        decimal.NewFromInt(100),
+		Currency:      "EUR",
+		FromRate:      decimal.NewFromInt(1),
+		FromAmount:    decimal.NewFromInt(100),
+		ToRate:        decimal.RequireFromString("655.957"),
+		ToAmount:      decimal.NewFromInt(65596),
+		Fee:           decimal.RequireFromString("1.5"),
+		ExpiresAt:     time.Now().Add(defaultQuoteDuration).UTC(),
+	}
+
+	body := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          100,
+		"currency":        "EUR",
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.QuoteTransferRequest{
+					Username:      user1.Username,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        decimal.NewFromInt(100),
+					Currency:      "EUR",
+					Audit:         &db.AuditContext{Actor: user1.Username},
+				}
+				store.EXPECT().
+					QuoteTransfer(gomock.Any(), eqQuoteTransferRequestMatcher{arg}).
+					Times(1).
+					Return(quote, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var response struct {
+					Data struct {
+						ID         uuid.UUID       `json:"id"`
+						FromAmount decimal.Decimal `json:"from_amount"`
+						ToAmount   decimal.Decimal `json:"to_amount"`
+						Fee        decimal.Decimal `json:"fee"`
+						Rate       decimal.Decimal `json:"rate"`
+						TotalDebit decimal.Decimal `json:"total_debit"`
+						ExpiresAt  time.Time       `json:"expires_at"`
+					} `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+
+				require.Equal(t, quote.ID, response.Data.ID)
+				require.True(t, quote.ToAmount.Equal(response.Data.ToAmount))
+				require.True(t, quote.Fee.Equal(response.Data.Fee))
+				require.True(t, decimal.RequireFromString("655.957").Equal(response.Data.Rate))
+				require.True(t, decimal.RequireFromString("101.5").Equal(response.Data.TotalDebit))
+				require.WithinDuration(t, quote.ExpiresAt, response.Data.ExpiresAt, time.Second)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().QuoteTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ToAccountNotFound",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(db.Accounts{}, db.ErrRecordNotFound)
+				store.EXPECT().QuoteTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCurrency",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          100,
+				"currency":        "XYZ",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().QuoteTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "StaleRates",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+				store.EXPECT().QuoteTransfer(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferQuotes{}, fx.ErrStaleRates)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().QuoteTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/transfers/quote", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+type reverseTransferRequest struct {
+	// Amount to refund in the currency of the transfer, everything left to reverse when left out
+	Amount decimal.Decimal `json:"amount" binding:"omitempty,gt=0"`
+	Reason string          `json:"reason" binding:"max=255"`
+}
+
+// reverseTransfer moves a transfer's money back to the sender, in full or in
+// part. Only the recipient, who gives the money back, or a banker can reverse.
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	var req reverseTransferRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the body can be left out to reverse the whole transfer
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Transaction with id %v not found", uri.ID),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !req.Amount.IsZero() && !validAmount(ctx, req.Amount, transfer.Currency) {
+		return
+	}
+
+	recipient, valid := server.validAccount(ctx, transfer.ToAccountID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if authPayload.Role != util.BankerRole && recipient.Owner != authPayload.Username {
+		err := errors.New("only the recipient or a banker can reverse a transfer")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, db.ReverseTransferTxRequest{
+		TransferID:  transfer.ID,
+		Amount:      req.Amount,
+		InitiatedBy: authPayload.Username,
+		Reason:      req.Reason,
+		Audit:       auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		reportRolledBack(ctx)
+		if errors.Is(err, db.ErrTransferReversed) || errors.Is(err, db.ErrInvalidTransferStatus) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrReversalExceedsTransfer) || errors.Is(err, db.ErrReversalOfReversal) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": result,
+	})
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+)
+
+type listTransferReviewsRequest struct {
+	pageRequest
+}
+
+// listTransferReviews lists the transfers screening held for review, oldest first
+func (server *Server) listTransferReviews(ctx *gin.Context) {
+	var req listTransferReviewsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := req.afterID()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	reviews, err := server.store.ListPendingTransferReviews(ctx, db.ListPendingTransferReviewsParams{
+		AfterID: afterID,
+		Limit:   req.limit(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var totalCount *int64
+	if req.IncludeTotal {
+		count, err := server.store.CountPendingTransferReviews(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		totalCount = &count
+	}
+
+	reviews, nextCursor := trimPage(reviews, req.PageSize, func(review db.TransferReviews) int64 {
+		return review.ID
+	})
+
+	ctx.JSON(http.StatusOK, pageEnvelope(reviews, nextCursor, totalCount))
+}
+
+type reviewTransferRequest struct {
+	Note string `json:"note" binding:"max=255"`
+}
+
+// approveTransfer completes a transfer held for review
+func (server *Server) approveTransfer(ctx *gin.Context) {
+	server.reviewTransfer(ctx, true)
+}
+
+// rejectTransfer fails a transfer held for review
+func (server *Server) rejectTransfer(ctx *gin.Context) {
+	server.reviewTransfer(ctx, false)
+}
+
+func (server *Server) reviewTransfer(ctx *gin.Context, approve bool) {
+	var uri getTransferRequest
+	var req reviewTransferRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the note is optional, and so is the body carrying it
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+
+	result, err := server.store.ReviewTransferTx(ctx, db.ReviewTransferTxRequest{
+		TransferID: uri.ID,
+		Approve:    approve,
+		ReviewedBy: authPayload.Username,
+		Note:       req.Note,
+		Audit:      auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Transfer review for transfer with id %v not found", uri.ID),
+			})
+			return
+		}
+		if errors.Is(err, db.ErrReviewClosed) || errors.Is(err, db.ErrInvalidTransferStatus) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
@@ -0,0 +1,369 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/rouclec/simplebank/db/mock"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTransferScreeningApi(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := generateRandomAccount(user1.Username)
+	account2 := generateRandomAccount(user2.Username)
+
+	body := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          10,
+		"currency":        "USD",
+	}
+
+	arg := db.TransferTxRequest{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Currency:      "USD",
+		Amount:        decimal.NewFromInt(10),
+		Audit:         &db.AuditContext{Actor: user1.Username},
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Allowed",
+			buildStubs: func(store *mockdb.MockStore) {
+				result := db.TransfersTxResponse{Transfer: db.Transfers{ID: 1, Status: util.TransferCompleted}}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "HeldForReview",
+			buildStubs: func(store *mockdb.MockStore) {
+				result := db.TransfersTxResponse{Transfer: db.Transfers{ID: 1, Status: util.TransferPending}}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var response struct {
+					Data db.TransfersTxResponse `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, util.TransferPending, response.Data.Transfer.Status)
+			},
+		},
+		{
+			name: "Blocked",
+			buildStubs: func(store *mockdb.MockStore) {
+				err := fmt.Errorf("%w: %s", db.ErrTransferBlocked, "new payee soon after a password change")
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransfersTxResponse{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "new payee soon after a password change")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListTransferReviewsApi(t *testing.T) {
+	reviews := []db.TransferReviews{
+		{ID: 1, TransferID: 10, Reasons: "unusual amount", Status: util.ReviewPending},
+		{ID: 2, TransferID: 11, Reasons: "rapid hops", Status: util.ReviewPending},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "page_size=5&include_total=true",
+			role:  util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListPendingTransferReviewsParams{
+					AfterID: 0,
+					Limit:   6,
+				}
+				store.EXPECT().ListPendingTransferReviews(gomock.Any(), gomock.Eq(arg)).Times(1).Return(reviews, nil)
+				store.EXPECT().CountPendingTransferReviews(gomock.Any()).Times(1).Return(int64(2), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Data       []db.TransferReviews `json:"data"`
+					TotalCount int64                `json:"total_count"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Len(t, response.Data, 2)
+				require.Equal(t, int64(2), response.TotalCount)
+			},
+		},
+		{
+			name:  "MissingPageSize",
+			query: "",
+			role:  util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListPendingTransferReviews(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "NotABanker",
+			query: "page_size=5",
+			role:  util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListPendingTransferReviews(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalServerError",
+			query: "page_size=5",
+			role:  util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListPendingTransferReviews(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrTxDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/api/v1/admin/transfer-reviews?" + tc.query
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, "banker", tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestReviewTransferApi(t *testing.T) {
+	transferID := util.RandomInt(1, 1000)
+
+	testCases := []struct {
+		name          string
+		action        string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Approve",
+			action: "approve",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ReviewTransferTxRequest{
+					TransferID: transferID,
+					Approve:    true,
+					ReviewedBy: "banker",
+					Audit:      &db.AuditContext{Actor: "banker"},
+				}
+				result := db.ReviewTransferTxResponse{
+					TransfersTxResponse: db.TransfersTxResponse{Transfer: db.Transfers{ID: transferID, Status: util.TransferCompleted}},
+					Review:              db.TransferReviews{TransferID: transferID, Status: util.ReviewApproved},
+				}
+				store.EXPECT().ReviewTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Data db.ReviewTransferTxResponse `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, util.TransferCompleted, response.Data.Transfer.Status)
+				require.Equal(t, util.ReviewApproved, response.Data.Review.Status)
+			},
+		},
+		{
+			name:   "RejectWithNote",
+			action: "reject",
+			body:   gin.H{"note": "payee flagged by the bank"},
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ReviewTransferTxRequest{
+					TransferID: transferID,
+					ReviewedBy: "banker",
+					Note:       "payee flagged by the bank",
+					Audit:      &db.AuditContext{Actor: "banker"},
+				}
+				store.EXPECT().ReviewTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "AlreadyDecided",
+			action: "approve",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReviewTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReviewTransferTxResponse{}, db.ErrReviewClosed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "CancelledBySender",
+			action: "approve",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				err := fmt.Errorf("%w: a cancelled transfer cannot become completed", db.ErrInvalidTransferStatus)
+				store.EXPECT().ReviewTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReviewTransferTxResponse{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "InsufficientFunds",
+			action: "approve",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReviewTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReviewTransferTxResponse{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:   "NotUnderReview",
+			action: "reject",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReviewTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReviewTransferTxResponse{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "NotABanker",
+			action: "approve",
+			role:   util.AuditorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReviewTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalServerError",
+			action: "reject",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReviewTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReviewTransferTxResponse{}, sql.ErrTxDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				err := json.NewEncoder(&body).Encode(tc.body)
+				require.NoError(t, err)
+			}
+
+			url := fmt.Sprintf("/api/v1/admin/transfers/%d/%s", transferID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, &body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, "banker", tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
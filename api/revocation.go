@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+)
+
+const (
+	// revocationCheckTTL is how long a token found valid is trusted before the database is asked again,
+	// which bounds how long a token revoked by another replica keeps working here
+	revocationCheckTTL = 30 * time.Second
+	// maxCachedTokens triggers pruning of entries for expired tokens
+	maxCachedTokens = 10000
+)
+
+// tokenRevocationChecker tells authMiddleware whether a verified token has been revoked since it was issued
+type tokenRevocationChecker interface {
+	IsRevoked(ctx context.Context, payload *token.Payload) (bool, error)
+}
+
+type checkedToken struct {
+	revoked   bool
+	checkedAt time.Time
+	expiredAt time.Time
+}
+
+// tokenRevocations is a Postgres-backed revocation store with an in-memory cache keyed on the token ID
+type tokenRevocations struct {
+	store db.Store
+
+	mu      sync.Mutex
+	tokens  map[uuid.UUID]checkedToken
+	cutoffs map[string]time.Time
+}
+
+func newTokenRevocations(store db.Store) *tokenRevocations {
+	return &tokenRevocations{
+		store:   store,
+		tokens:  make(map[uuid.UUID]checkedToken),
+		cutoffs: make(map[string]time.Time),
+	}
+}
+
+// IsRevoked reports whether the token was revoked on its own or issued before its user logged out everywhere
+func (revocations *tokenRevocations) IsRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	if revoked, ok := revocations.cached(payload); ok {
+		return revoked, nil
+	}
+
+	revoked, err := revocations.store.IsTokenRevoked(ctx, db.IsTokenRevokedParams{
+		ID:       payload.ID,
+		Username: payload.Username,
+		IssuedAt: payload.IssuedAt,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	revocations.remember(payload, revoked)
+	return revoked, nil
+}
+
+func (revocations *tokenRevocations) cached(payload *token.Payload) (revoked bool, ok bool) {
+	revocations.mu.Lock()
+	defer revocations.mu.Unlock()
+
+	if cutoff, found := revocations.cutoffs[payload.Username]; found && payload.IssuedAt.Before(cutoff) {
+		return true, true
+	}
+
+	checked, found := revocations.tokens[payload.ID]
+	if !found {
+		return false, false
+	}
+
+	// revocation is permanent, validity has to be checked again from time to time
+	if checked.revoked || time.Since(checked.checkedAt) < revocationCheckTTL {
+		return checked.revoked, true
+	}
+	return false, false
+}
+
+func (revocations *tokenRevocations) remember(payload *token.Payload, revoked bool) {
+	revocations.mu.Lock()
+	defer revocations.mu.Unlock()
+
+	if len(revocations.tokens) >= maxCachedTokens {
+		revocations.prune()
+	}
+
+	revocations.tokens[payload.ID] = checkedToken{
+		revoked:   revoked,
+		checkedAt: time.Now(),
+		expiredAt: payload.ExpiredAt,
+	}
+}
+
+// prune drops tokens that have expired anyway, and everything if that is not enough
+func (revocations *tokenRevocations) prune() {
+	now := time.Now()
+	for id, checked := range revocations.tokens {
+		if now.After(checked.expiredAt) {
+			delete(revocations.tokens, id)
+		}
+	}
+
+	if len(revocations.tokens) >= maxCachedTokens {
+		revocations.tokens = make(map[uuid.UUID]checkedToken)
+	}
+}
+
+// Revoke kills a single token before it expires
+func (revocations *tokenRevocations) Revoke(ctx context.Context, payload *token.Payload) error {
+	err := revocations.store.RevokeToken(ctx, db.RevokeTokenParams{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		ExpiresAt: payload.ExpiredAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	revocations.remember(payload, true)
+	return nil
+}
+
+// RevokeAll revokes every token issued to username before the given time,
+// recording it in the audit log when audit is set
+func (revocations *tokenRevocations) RevokeAll(ctx context.Context, username string, before time.Time, audit *db.AuditContext) error {
+	_, err := revocations.store.RevokeUserTokensTx(ctx, db.RevokeUserTokensTxRequest{
+		RevokeUserTokensParams: db.RevokeUserTokensParams{
+			TokensValidAfter: before,
+			Username:         username,
+		},
+		Audit: audit,
+	})
+	if err != nil {
+		return err
+	}
+
+	revocations.RevokedBefore(username, before)
+	return nil
+}
+
+// RevokedBefore notes that every token issued to username before the given
+// time has been revoked in the database, so they are refused without asking it
+func (revocations *tokenRevocations) RevokedBefore(username string, before time.Time) {
+	revocations.mu.Lock()
+	defer revocations.mu.Unlock()
+
+	revocations.cutoffs[username] = before
+}
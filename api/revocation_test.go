@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/rouclec/simplebank/db/mock"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func randomPayload(t *testing.T, username string) *token.Payload {
+	payload, err := token.NewPayload(username, util.DepositorRole, token.AccessToken, time.Minute)
+	require.NoError(t, err)
+	return payload
+}
+
+func TestTokenRevocationsCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	revocations := newTokenRevocations(store)
+	payload := randomPayload(t, util.RandomOwner())
+
+	// a valid token is looked up once and then trusted for a while
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Eq(db.IsTokenRevokedParams{
+		ID:       payload.ID,
+		Username: payload.Username,
+		IssuedAt: payload.IssuedAt,
+	})).Times(1).Return(false, nil)
+
+	for i := 0; i < 3; i++ {
+		revoked, err := revocations.IsRevoked(context.Background(), payload)
+		require.NoError(t, err)
+		require.False(t, revoked)
+	}
+
+	// revoking it here takes effect without another lookup
+	store.EXPECT().RevokeToken(gomock.Any(), gomock.Eq(db.RevokeTokenParams{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		ExpiresAt: payload.ExpiredAt,
+	})).Times(1).Return(nil)
+
+	require.NoError(t, revocations.Revoke(context.Background(), payload))
+
+	revoked, err := revocations.IsRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestTokenRevocationsExpiredCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	revocations := newTokenRevocations(store)
+	payload := randomPayload(t, util.RandomOwner())
+
+	// another replica revoked the token after it was last checked here
+	revocations.tokens[payload.ID] = checkedToken{
+		checkedAt: time.Now().Add(-2 * revocationCheckTTL),
+		expiredAt: payload.ExpiredAt,
+	}
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+
+	revoked, err := revocations.IsRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestTokenRevocationsRevokeAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	revocations := newTokenRevocations(store)
+	username := util.RandomOwner()
+
+	oldPayload := randomPayload(t, username)
+	cutoff := time.Now()
+
+	store.EXPECT().RevokeUserTokensTx(gomock.Any(), gomock.Eq(db.RevokeUserTokensTxRequest{
+		RevokeUserTokensParams: db.RevokeUserTokensParams{
+			TokensValidAfter: cutoff,
+			Username:         username,
+		},
+	})).Times(1).Return(db.Users{Username: username, TokensValidAfter: cutoff}, nil)
+
+	require.NoError(t, revocations.RevokeAll(context.Background(), username, cutoff, nil))
+
+	revoked, err := revocations.IsRevoked(context.Background(), oldPayload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// tokens issued afterwards still go to the database
+	newPayload := randomPayload(t, username)
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+
+	revoked, err = revocations.IsRevoked(context.Background(), newPayload)
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestTokenRevocationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	revocations := newTokenRevocations(store)
+	payload := randomPayload(t, util.RandomOwner())
+
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(2).Return(false, sql.ErrConnDone)
+
+	// failures are not cached
+	for i := 0; i < 2; i++ {
+		_, err := revocations.IsRevoked(context.Background(), payload)
+		require.ErrorIs(t, err, sql.ErrConnDone)
+	}
+}
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64           `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64           `json:"to_account_id" binding:"required,min=1"`
+	Amount        decimal.Decimal `json:"amount" binding:"required,gt=0"`
+	Currency      string          `json:"currency" binding:"required,currency"`
+	Frequency     string          `json:"frequency" binding:"required,oneof=once weekly monthly"`
+	// StartAt is when the first transfer runs
+	StartAt time.Time `json:"start_at" binding:"required"`
+	// DayOfMonth monthly transfers run on, the day of StartAt when left out
+	DayOfMonth int32 `json:"day_of_month" binding:"omitempty,min=1,max=31"`
+}
+
+// validate checks the schedule starts in the future and only monthly
+// schedules pick a day of the month
+func (req *createScheduledTransferRequest) validate(now time.Time) error {
+	if !req.StartAt.After(now) {
+		return errors.New("start_at must be in the future")
+	}
+
+	if req.Frequency != util.MonthlyFrequency {
+		if req.DayOfMonth != 0 {
+			return errors.New("day_of_month only applies to monthly schedules")
+		}
+		return nil
+	}
+
+	if req.DayOfMonth == 0 {
+		req.DayOfMonth = int32(req.StartAt.UTC().Day())
+	}
+	return nil
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := req.validate(time.Now()); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !validAmount(ctx, req.Amount, req.Currency) {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID)
+	if !valid {
+		return
+	}
+
+	schedule, err := server.store.CreateScheduledTransferTx(ctx, db.CreateScheduledTransferTxRequest{
+		CreateScheduledTransferParams: db.CreateScheduledTransferParams{
+			Owner:         authPayload.Username,
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        req.Amount,
+			Currency:      req.Currency,
+			Frequency:     req.Frequency,
+			DayOfMonth:    req.DayOfMonth,
+			DueAt:         req.StartAt,
+		},
+		Audit: auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		reportRolledBack(ctx)
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": schedule,
+	})
+}
+
+type getScheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// validScheduledTransfer loads the scheduled transfer with the given id, writing
+// the error response when it does not exist or the caller may not read it
+func (server *Server) validScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfers, bool) {
+	schedule, err := server.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Scheduled transfer with id %v not found", id),
+			})
+			return schedule, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return schedule, false
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if !canReadAllAccounts(authPayload) && schedule.Owner != authPayload.Username {
+		err := errors.New("unauthorized access to scheduled transfer")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return schedule, false
+	}
+
+	return schedule, true
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedule, valid := server.validScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": schedule,
+	})
+}
+
+type listScheduledTransfersRequest struct {
+	pageRequest
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := req.afterID()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+
+	schedules, err := server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		Owner:   authPayload.Username,
+		AfterID: afterID,
+		Limit:   req.limit(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var totalCount *int64
+	if req.IncludeTotal {
+		count, err := server.store.CountScheduledTransfers(ctx, authPayload.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		totalCount = &count
+	}
+
+	schedules, nextCursor := trimPage(schedules, req.PageSize, func(schedule db.ScheduledTransfers) int64 {
+		return schedule.ID
+	})
+
+	ctx.JSON(http.StatusOK, pageEnvelope(schedules, nextCursor, totalCount))
+}
+
+// cancelScheduledTransfer stops a schedule from running any further transfer.
+// An occurrence already being run completes first.
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedule, valid := server.validScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if schedule.Owner != authPayload.Username {
+		err := errors.New("scheduled transfer doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	schedule, err := server.store.CancelScheduledTransferTx(ctx, db.CancelScheduledTransferTxRequest{
+		ID:    req.ID,
+		Audit: auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("only active scheduled transfers can be cancelled")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": schedule,
+	})
+}
+
+type listScheduledTransferRunsRequest struct {
+	pageRequest
+}
+
+// listScheduledTransferRuns lists every attempt at running the schedule's
+// transfers, oldest first
+func (server *Server) listScheduledTransferRuns(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	var req listScheduledTransferRunsRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := req.afterID()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedule, valid := server.validScheduledTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	runs, err := server.store.ListScheduledTransferRuns(ctx, db.ListScheduledTransferRunsParams{
+		ScheduledTransferID: schedule.ID,
+		AfterID:             afterID,
+		Limit:               req.limit(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var totalCount *int64
+	if req.IncludeTotal {
+		count, err := server.store.CountScheduledTransferRuns(ctx, schedule.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		totalCount = &count
+	}
+
+	runs, nextCursor := trimPage(runs, req.PageSize, func(run db.ScheduledTransferRuns) int64 {
+		return run.ID
+	})
+
+	ctx.JSON(http.StatusOK, pageEnvelope(runs, nextCursor, totalCount))
+}
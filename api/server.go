@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"net"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -10,14 +11,19 @@ import (
 	db "github.com/rouclec/simplebank/db/sqlc"
 	"github.com/rouclec/simplebank/token"
 	"github.com/rouclec/simplebank/util"
+	"github.com/rouclec/simplebank/webhook"
+	"github.com/shopspring/decimal"
 )
 
 // Server serves HTTP requests for our banking service
 type Server struct {
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
-	config     util.Config
+	store       db.Store
+	tokenMaker  token.Maker
+	revocations *tokenRevocations
+	router      *gin.Engine
+	config      util.Config
+	// resolver looks up the hosts of webhook endpoints as they are registered
+	resolver webhook.Resolver
 }
 
 // Creates a new HTTP server instance and setup routing
@@ -27,15 +33,21 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		return nil, fmt.Errorf("error creating token maker: %w", err)
 	}
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:      config,
+		store:       store,
+		tokenMaker:  tokenMaker,
+		revocations: newTokenRevocations(store),
+		resolver:    net.DefaultResolver,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validateCurrency)
 		v.RegisterValidation("email", validateEmail)
+		v.RegisterValidation("event_type", validateEventType)
 		v.RegisterValidation("password", validatePassword)
+		v.RegisterValidation("role", validateRole)
+		v.RegisterValidation("tier", validateTier)
+		v.RegisterCustomTypeFunc(decimalValue, decimal.Decimal{})
 	}
 
 	server.setupRouter()
@@ -48,20 +60,69 @@ func (server *Server) setupRouter() {
 	//add routes to router
 	router.POST("api/v1/auth/signup", server.createUser)
 	router.POST("api/v1/auth/login", server.login)
+	router.POST("api/v1/auth/refresh", server.refreshAccessToken)
+	router.POST("api/v1/auth/logout", server.logout)
 
-	authRoutes := router.Group("/api/v1").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/api/v1").Use(authMiddleware(server.tokenMaker, server.revocations))
 
-	authRoutes.POST("/accounts", server.createAccount)
+	authRoutes.POST("/auth/logout-all", server.logoutAll)
+
+	authRoutes.POST("/accounts", idempotencyMiddleware(server.store), server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccount)
+	authRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/statement", server.getAccountStatement)
 	authRoutes.GET("/accounts", server.listAccounts)
-	authRoutes.PATCH("/accounts", server.addAccountBalance)
+	authRoutes.POST("/accounts/:id/deposits", idempotencyMiddleware(server.store), server.createDeposit)
+	authRoutes.POST("/accounts/:id/withdrawals", idempotencyMiddleware(server.store), server.createWithdrawal)
+	authRoutes.POST("/accounts/:id/holds", idempotencyMiddleware(server.store), server.createHold)
+	authRoutes.GET("/accounts/:id/holds", server.listHolds)
+	authRoutes.POST("/accounts/:id/holds/:hold_id/capture", idempotencyMiddleware(server.store), server.captureHold)
+	authRoutes.POST("/accounts/:id/holds/:hold_id/release", server.releaseHold)
 
-	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/transfers", idempotencyMiddleware(server.store), server.createTransfer)
+	authRoutes.POST("/transfers/quote", server.createTransferQuote)
 	authRoutes.GET("/transfers/:id", server.getTransfer)
+	authRoutes.POST("/transfers/:id/reverse", idempotencyMiddleware(server.store), server.reverseTransfer)
+	authRoutes.POST("/transfers/:id/cancel", server.cancelTransfer)
+	authRoutes.GET("/transfers/:id/status-changes", server.listTransferStatusChanges)
 	authRoutes.GET("/transfers", server.listTransfers)
 
+	authRoutes.POST("/scheduled-transfers", idempotencyMiddleware(server.store), server.createScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers/:id", server.getScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers/:id/runs", server.listScheduledTransferRuns)
+	authRoutes.GET("/scheduled-transfers", server.listScheduledTransfers)
+	authRoutes.DELETE("/scheduled-transfers/:id", server.cancelScheduledTransfer)
+
+	authRoutes.POST("/webhooks", server.createWebhook)
+	authRoutes.GET("/webhooks/:id", server.getWebhook)
+	authRoutes.GET("/webhooks/:id/deliveries", server.listWebhookDeliveries)
+	authRoutes.GET("/webhooks", server.listWebhooks)
+	authRoutes.DELETE("/webhooks/:id", server.deleteWebhook)
+
 	authRoutes.GET("/users/:username", server.getUser)
 
+	adminRoutes := router.Group("/api/v1/admin").Use(authMiddleware(server.tokenMaker, server.revocations), requireRoles(util.BankerRole))
+
+	adminRoutes.PATCH("/users/:username/role", server.updateUserRole)
+	adminRoutes.PATCH("/users/:username/tier", server.updateUserTier)
+
+	adminRoutes.POST("/fee-schedules", server.createFeeSchedule)
+	adminRoutes.GET("/fee-schedules", server.listFeeSchedules)
+	adminRoutes.DELETE("/fee-schedules/:id", server.deleteFeeSchedule)
+
+	adminRoutes.POST("/transfer-limits", server.createTransferLimit)
+	adminRoutes.GET("/transfer-limits", server.listTransferLimits)
+	adminRoutes.DELETE("/transfer-limits/:id", server.deleteTransferLimit)
+
+	adminRoutes.GET("/transfer-reviews", server.listTransferReviews)
+	adminRoutes.POST("/transfers/:id/approve", server.approveTransfer)
+	adminRoutes.POST("/transfers/:id/reject", server.rejectTransfer)
+
+	auditRoutes := router.Group("/api/v1/admin").Use(authMiddleware(server.tokenMaker, server.revocations), requireRoles(util.BankerRole, util.AuditorRole))
+
+	auditRoutes.GET("/audit-events", server.listAuditEvents)
+	auditRoutes.GET("/audit-events/verify", server.verifyAuditChain)
+
 	server.router = router
 }
 
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/statement"
+	"github.com/rouclec/simplebank/token"
+)
+
+// statementBatchSize is how many entries are read at a time while a statement streams
+const statementBatchSize = 200
+
+type getStatementRequest struct {
+	Format string    `form:"format" binding:"required,oneof=csv ofx pdf"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+func bindStatementLine(entry db.ListEntriesWithBalanceRow) statement.Line {
+	return statement.Line{
+		EntryID:    entry.ID,
+		Date:       entry.CreatedAt,
+		Type:       entry.Type,
+		TransferID: entry.TransferID.Int64,
+		Amount:     entry.Amount,
+		Balance:    entry.Balance,
+	}
+}
+
+// getAccountStatement streams the account's entries between from and to as a
+// downloadable statement. from defaults to the account's creation and to to now.
+func (server *Server) getAccountStatement(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req getStatementRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.validAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+
+	if !canReadAllAccounts(authPayload) && account.Owner != authPayload.Username {
+		err := errors.New("unauthorized access to account")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	now := time.Now()
+	if req.From.IsZero() {
+		req.From = account.CreatedAt
+	}
+	if req.To.IsZero() {
+		req.To = now
+	}
+	if !req.From.Before(req.To) {
+		err := errors.New("from must be before to")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromDate := pgtype.Timestamptz{Time: req.From, Valid: true}
+	toDate := pgtype.Timestamptz{Time: req.To, Valid: true}
+
+	balance, err := server.store.GetEntriesBalance(ctx, db.GetEntriesBalanceParams{
+		AccountID: account.ID,
+		FromDate:  fromDate,
+		ToDate:    toDate,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.ListEntriesWithBalanceParams{
+		AccountID: account.ID,
+		FromDate:  fromDate,
+		ToDate:    toDate,
+		Limit:     statementBatchSize,
+	}
+
+	// read the first batch before anything is written, so failures can still
+	// be reported with a proper status
+	entries, err := server.store.ListEntriesWithBalance(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	stmt := statement.Statement{
+		AccountID:      account.ID,
+		Owner:          account.Owner,
+		Currency:       account.Currency,
+		From:           req.From,
+		To:             req.To,
+		OpeningBalance: balance.OpeningBalance,
+		ClosingBalance: balance.ClosingBalance,
+		GeneratedAt:    now,
+	}
+
+	encoder, err := statement.NewEncoder(req.Format, ctx.Writer)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Type", statement.ContentType(req.Format))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", statement.FileName(stmt, req.Format)))
+	ctx.Status(http.StatusOK)
+
+	if err := server.writeStatement(ctx, encoder, stmt, arg, entries); err != nil {
+		// the status line is already out, all we can do is cut the download short
+		log.Println("error writing statement: ", err)
+		ctx.Abort()
+	}
+}
+
+// writeStatement encodes the first batch of entries and keeps reading batches
+// until the period is exhausted
+func (server *Server) writeStatement(ctx *gin.Context, encoder statement.Encoder, stmt statement.Statement, arg db.ListEntriesWithBalanceParams, entries []db.ListEntriesWithBalanceRow) error {
+	if err := encoder.Begin(stmt); err != nil {
+		return err
+	}
+
+	for {
+		for _, entry := range entries {
+			if err := encoder.WriteLine(bindStatementLine(entry)); err != nil {
+				return err
+			}
+		}
+
+		if len(entries) < int(arg.Limit) {
+			break
+		}
+
+		arg.AfterID = entries[len(entries)-1].ID
+
+		var err error
+		entries, err = server.store.ListEntriesWithBalance(ctx, arg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return encoder.End()
+}
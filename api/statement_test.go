@@ -0,0 +1,240 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/rouclec/simplebank/db/mock"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountStatementApi(t *testing.T) {
+	user, _ := randomUser(t)
+	account := generateRandomAccount(user.Username)
+	account.Currency = "USD"
+	user2, _ := randomUser(t)
+
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	balance := db.GetEntriesBalanceRow{
+		OpeningBalance: decimal.NewFromInt(100),
+		ClosingBalance: decimal.RequireFromString("60.5"),
+	}
+	entries := []db.ListEntriesWithBalanceRow{
+		{
+			ID:         1,
+			AccountID:  account.ID,
+			Amount:     decimal.RequireFromString("-39.5"),
+			Type:       util.TransferEntry,
+			TransferID: pgtype.Int8{Int64: 7, Valid: true},
+			Balance:    decimal.RequireFromString("60.5"),
+			CreatedAt:  from.Add(time.Hour),
+		},
+	}
+
+	batch := make([]db.ListEntriesWithBalanceRow, statementBatchSize)
+	for i := range batch {
+		batch[i] = db.ListEntriesWithBalanceRow{
+			ID:        int64(i + 1),
+			AccountID: account.ID,
+			Amount:    decimal.NewFromInt(1),
+			Type:      util.DepositEntry,
+			Balance:   decimal.NewFromInt(int64(101 + i)),
+			CreatedAt: from.Add(time.Minute * time.Duration(i)),
+		}
+	}
+
+	listArg := db.ListEntriesWithBalanceParams{
+		AccountID: account.ID,
+		FromDate:  pgtype.Timestamptz{Time: from, Valid: true},
+		ToDate:    pgtype.Timestamptz{Time: to, Valid: true},
+		Limit:     statementBatchSize,
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "CSV",
+			query: fmt.Sprintf("format=csv&from=%s&to=%s", from.Format(time.RFC3339), to.Format(time.RFC3339)),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetEntriesBalance(gomock.Any(), gomock.Eq(db.GetEntriesBalanceParams{
+					AccountID: account.ID,
+					FromDate:  listArg.FromDate,
+					ToDate:    listArg.ToDate,
+				})).Times(1).Return(balance, nil)
+				store.EXPECT().ListEntriesWithBalance(gomock.Any(), gomock.Eq(listArg)).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+				require.Equal(t,
+					fmt.Sprintf(`attachment; filename="statement-%d-20240101-20240201.csv"`, account.ID),
+					recorder.Header().Get("Content-Disposition"),
+				)
+
+				records, err := csv.NewReader(recorder.Body).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, records, 2)
+				require.Equal(t, []string{"2024-01-01T01:00:00Z", "1", "transfer", "7", "Transfer #7", "-39.50", "60.50", "USD"}, records[1])
+			},
+		},
+		{
+			name:  "PDF",
+			query: fmt.Sprintf("format=pdf&from=%s&to=%s", from.Format(time.RFC3339), to.Format(time.RFC3339)),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetEntriesBalance(gomock.Any(), gomock.Any()).Times(1).Return(balance, nil)
+				store.EXPECT().ListEntriesWithBalance(gomock.Any(), gomock.Eq(listArg)).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/pdf", recorder.Header().Get("Content-Type"))
+				require.True(t, bytes.HasPrefix(recorder.Body.Bytes(), []byte("%PDF-")))
+			},
+		},
+		{
+			name:  "SeveralBatches",
+			query: fmt.Sprintf("format=ofx&from=%s&to=%s", from.Format(time.RFC3339), to.Format(time.RFC3339)),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetEntriesBalance(gomock.Any(), gomock.Any()).Times(1).Return(balance, nil)
+
+				nextArg := listArg
+				nextArg.AfterID = batch[len(batch)-1].ID
+				gomock.InOrder(
+					store.EXPECT().ListEntriesWithBalance(gomock.Any(), gomock.Eq(listArg)).Times(1).Return(batch, nil),
+					store.EXPECT().ListEntriesWithBalance(gomock.Any(), gomock.Eq(nextArg)).Times(1).Return(entries, nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/x-ofx", recorder.Header().Get("Content-Type"))
+				require.Equal(t, statementBatchSize+1, bytes.Count(recorder.Body.Bytes(), []byte("<STMTTRN>")))
+			},
+		},
+		{
+			name:  "UnauthorizedUser",
+			query: "format=csv",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntriesWithBalance(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "AccountNotFound",
+			query: "format=csv",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Accounts{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "UnsupportedFormat",
+			query: "format=xls",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidRange",
+			query: fmt.Sprintf("format=csv&from=%s&to=%s", to.Format(time.RFC3339), from.Format(time.RFC3339)),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetEntriesBalance(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalServerError",
+			query: "format=csv",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetEntriesBalance(gomock.Any(), gomock.Any()).Times(1).Return(balance, nil)
+				store.EXPECT().
+					ListEntriesWithBalance(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListEntriesWithBalanceRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/accounts/%d/statement?%s", account.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
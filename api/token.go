@@ -0,0 +1,206 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+)
+
+const (
+	authCookieName    = "auth"
+	refreshCookieName = "refresh"
+	// refreshCookiePath keeps the refresh token from being sent anywhere but the auth routes
+	refreshCookiePath = "/api/v1/auth"
+)
+
+func (server *Server) setAccessCookie(ctx *gin.Context, accessToken string) {
+	ctx.SetCookie(authCookieName, accessToken, int(server.config.AccessTokenDuration.Seconds()), "/", server.config.Domain, false, true)
+}
+
+func (server *Server) setRefreshCookie(ctx *gin.Context, refreshToken string) {
+	ctx.SetCookie(refreshCookieName, refreshToken, int(server.config.RefreshTokenDuration.Seconds()), refreshCookiePath, server.config.Domain, false, true)
+}
+
+func (server *Server) clearAuthCookies(ctx *gin.Context) {
+	ctx.SetCookie(authCookieName, "", -1, "/", server.config.Domain, false, true)
+	ctx.SetCookie(refreshCookieName, "", -1, refreshCookiePath, server.config.Domain, false, true)
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// bindRefreshToken reads the refresh token from the request body, falling back to the refresh cookie
+func bindRefreshToken(ctx *gin.Context) (string, error) {
+	var req refreshTokenRequest
+
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			return "", err
+		}
+	}
+
+	if req.RefreshToken != "" {
+		return req.RefreshToken, nil
+	}
+
+	refreshToken, err := ctx.Cookie(refreshCookieName)
+	if err != nil || refreshToken == "" {
+		return "", errors.New("refresh token is not provided")
+	}
+	return refreshToken, nil
+}
+
+// validSession checks that refreshToken belongs to a session that can still be used
+func (server *Server) validSession(ctx *gin.Context, refreshToken string) (*token.Payload, db.Sessions, bool) {
+	refreshPayload, err := server.tokenMaker.VerifyToken(refreshToken, token.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, db.Sessions{}, false
+	}
+
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Session %v not found", refreshPayload.ID),
+			})
+			return nil, db.Sessions{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, db.Sessions{}, false
+	}
+
+	if session.Username != refreshPayload.Username {
+		err := errors.New("session doesn't belong to the token user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, db.Sessions{}, false
+	}
+
+	if session.RefreshToken != refreshToken {
+		err := errors.New("mismatched session token")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, db.Sessions{}, false
+	}
+
+	revoked, err := server.revocations.IsRevoked(ctx, refreshPayload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, db.Sessions{}, false
+	}
+
+	if revoked {
+		err := errors.New("refresh token has been revoked")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, db.Sessions{}, false
+	}
+
+	return refreshPayload, session, true
+}
+
+type refreshAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+func (server *Server) refreshAccessToken(ctx *gin.Context) {
+	refreshToken, err := bindRefreshToken(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	refreshPayload, session, valid := server.validSession(ctx, refreshToken)
+	if !valid {
+		return
+	}
+
+	if session.IsBlocked {
+		err := errors.New("session is blocked")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		err := errors.New("session has expired")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(refreshPayload.Username, refreshPayload.Role, token.AccessToken, server.config.AccessTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.setAccessCookie(ctx, accessToken)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": refreshAccessTokenResponse{
+			AccessToken:          accessToken,
+			AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		},
+	})
+}
+
+func (server *Server) logout(ctx *gin.Context) {
+	refreshToken, err := bindRefreshToken(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	refreshPayload, session, valid := server.validSession(ctx, refreshToken)
+	if !valid {
+		return
+	}
+
+	_, err = server.store.BlockSessionTx(ctx, db.BlockSessionTxRequest{
+		ID:    session.ID,
+		Audit: auditContext(ctx, session.Username),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := server.revocations.Revoke(ctx, refreshPayload); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the access token sent along, if any, dies with the session
+	if accessToken, err := extractAuthToken(ctx); err == nil {
+		accessPayload, err := server.tokenMaker.VerifyToken(accessToken, token.AccessToken)
+		if err == nil && accessPayload.Username == session.Username {
+			if err := server.revocations.Revoke(ctx, accessPayload); err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+		}
+	}
+
+	server.clearAuthCookies(ctx)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// logoutAll revokes every access and refresh token issued to the authenticated user so far
+func (server *Server) logoutAll(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+
+	err := server.revocations.RevokeAll(ctx, authPayload.Username, time.Now(), auditContext(ctx, authPayload.Username))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.clearAuthCookies(ctx)
+
+	ctx.Status(http.StatusNoContent)
+}
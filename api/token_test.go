@@ -0,0 +1,458 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/rouclec/simplebank/db/mock"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// randomSession creates a refresh token and the session it would have been stored in
+func randomSession(t *testing.T, tokenMaker token.Maker, username string) (string, db.Sessions) {
+	refreshToken, payload, err := tokenMaker.CreateToken(username, util.DepositorRole, token.RefreshToken, time.Hour)
+	require.NoError(t, err)
+
+	session := db.Sessions{
+		ID:           payload.ID,
+		Username:     username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    payload.ExpiredAt,
+	}
+	return refreshToken, session
+}
+
+func TestRefreshAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          func(refreshToken string) gin.H
+		useCookie     bool
+		buildStubs    func(store *mockdb.MockStore, session db.Sessions)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: func(refreshToken string) gin.H {
+				return gin.H{"refresh_token": refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Data refreshAccessTokenResponse `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.NotEmpty(t, response.Data.AccessToken)
+			},
+		},
+		{
+			name:      "FromCookie",
+			useCookie: true,
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NoRefreshToken",
+			body: func(refreshToken string) gin.H {
+				return gin.H{}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidToken",
+			body: func(refreshToken string) gin.H {
+				return gin.H{"refresh_token": "invalid"}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			body: func(refreshToken string) gin.H {
+				return gin.H{"refresh_token": refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Sessions{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			body: func(refreshToken string) gin.H {
+				return gin.H{"refresh_token": refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				session.IsBlocked = true
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(session, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MismatchedToken",
+			body: func(refreshToken string) gin.H {
+				return gin.H{"refresh_token": refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				session.RefreshToken = "another token"
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(session, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: func(refreshToken string) gin.H {
+				return gin.H{"refresh_token": refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Sessions{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			refreshToken, session := randomSession(t, server.tokenMaker, user.Username)
+			tc.buildStubs(store, session)
+
+			var body []byte
+			if tc.body != nil {
+				data, err := json.Marshal(tc.body(refreshToken))
+				require.NoError(t, err)
+				body = data
+			}
+
+			url := "/api/v1/auth/refresh"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			if tc.useCookie {
+				request.AddCookie(&http.Cookie{Name: refreshCookieName, Value: refreshToken})
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRefreshWithAccessToken(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, token.AccessToken, time.Hour)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(gin.H{"refresh_token": accessToken})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestLogoutAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		sendAccess    bool
+		buildStubs    func(store *mockdb.MockStore, session db.Sessions)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				arg := db.BlockSessionTxRequest{
+					ID:    session.ID,
+					Audit: &db.AuditContext{Actor: session.Username},
+				}
+				store.EXPECT().BlockSessionTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RevokeTokenParams) error {
+						require.Equal(t, session.ID, arg.ID)
+						require.Equal(t, session.Username, arg.Username)
+						return nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+
+				for _, cookie := range recorder.Result().Cookies() {
+					require.Empty(t, cookie.Value)
+					require.Negative(t, cookie.MaxAge)
+				}
+			},
+		},
+		{
+			name:       "RevokesAccessToken",
+			sendAccess: true,
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().BlockSessionTx(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(2).
+					DoAndReturn(func(_ interface{}, arg db.RevokeTokenParams) error {
+						require.Equal(t, session.Username, arg.Username)
+						return nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyBlocked",
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				session.IsBlocked = true
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().BlockSessionTx(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "OtherUsersSession",
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				session.Username = "someone-else"
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().BlockSessionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "BlockSessionError",
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().BlockSessionTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Sessions{}, sql.ErrConnDone)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "RevokeTokenError",
+			buildStubs: func(store *mockdb.MockStore, session db.Sessions) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				arg := db.BlockSessionTxRequest{
+					ID:    session.ID,
+					Audit: &db.AuditContext{Actor: session.Username},
+				}
+				store.EXPECT().BlockSessionTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			refreshToken, session := randomSession(t, server.tokenMaker, user.Username)
+			tc.buildStubs(store, session)
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			url := "/api/v1/auth/logout"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			if tc.sendAccess {
+				addAuthorization(t, request, server.tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRefreshTokenAfterLogout(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	refreshToken, session := randomSession(t, server.tokenMaker, user.Username)
+	store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+	store.EXPECT().BlockSessionTx(gomock.Any(), gomock.Any()).Times(1)
+	store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1)
+
+	data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/logout", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	// the session row is still found, the revoked refresh token is what keeps it from being renewed
+	session.IsBlocked = true
+	store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/api/v1/accounts", nil)
+	require.NoError(t, err)
+	request.Header.Set(authHeaderKey, authTypeBearer+refreshToken)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestLogoutAllAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserTokensTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RevokeUserTokensTxRequest) (db.Users, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now(), arg.TokensValidAfter, time.Second)
+						require.Equal(t, &db.AuditContext{Actor: user.Username}, arg.Audit)
+						return user, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserTokensTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserTokensTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Users{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/api/v1/auth/logout-all"
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -4,17 +4,25 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/fx"
 	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
 )
 
 type transferRequest struct {
-	FromAccountID int64   `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64   `json:"to_account_id" binding:"required,min=1"`
-	Amount        float64 `json:"amount" binding:"required,gt=0"`
-	Currency      string  `json:"currency" binding:"required,currency"`
+	FromAccountID int64           `json:"from_account_id" binding:"required_without=QuoteID,omitempty,min=1"`
+	ToAccountID   int64           `json:"to_account_id" binding:"required_without=QuoteID,omitempty,min=1"`
+	Amount        decimal.Decimal `json:"amount" binding:"required_without=QuoteID,omitempty,gt=0"`
+	Currency      string          `json:"currency" binding:"required_without=QuoteID,omitempty,currency"`
+	// QuoteID executes a quote at its price, the fields left out are taken from it
+	QuoteID string `json:"quote_id" binding:"omitempty,uuid"`
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
@@ -25,6 +33,19 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
+	var quoteID uuid.NullUUID
+	if req.QuoteID != "" {
+		id, valid := server.applyQuote(ctx, &req)
+		if !valid {
+			return
+		}
+		quoteID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	if !validAmount(ctx, req.Amount, req.Currency) {
+		return
+	}
+
 	fromAccount, valid := server.validAccount(ctx, req.FromAccountID)
 	if !valid {
 		return
@@ -47,16 +68,49 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
 		Currency:      req.Currency,
+		QuoteID:       quoteID,
+		Audit:         auditContext(ctx, authPayload.Username),
 	}
 
 	result, err := server.store.TransferTx(ctx, arg)
 
 	if err != nil {
+		reportRolledBack(ctx)
+		if errors.Is(err, fx.ErrStaleRates) {
+			ctx.JSON(http.StatusServiceUnavailable, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrTransferLimitExceeded) || errors.Is(err, db.ErrTransferBlocked) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrTransferVelocityExceeded) {
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrQuoteExpired) {
+			ctx.JSON(http.StatusGone, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrQuoteUsed) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{
+	// transfers held for review move no money until a banker approves them
+	status := http.StatusCreated
+	if result.Transfer.Status == util.TransferPending {
+		status = http.StatusAccepted
+	}
+
+	ctx.JSON(status, gin.H{
 		"data": result,
 	})
 }
@@ -86,48 +140,139 @@ func (server *Server) getTransfer(ctx *gin.Context) {
 		return
 	}
 
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+
+	if !canReadAllAccounts(authPayload) {
+		owned, err := server.ownsTransfer(ctx, authPayload.Username, transfer)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if !owned {
+			err := errors.New("unauthorized access to transfer")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"data": transfer,
 	})
 }
 
-type ListTransfersParams struct {
-	FromAccountID int64  `form:"from_account_id"`
-	ToAccountID   int64  `form:"to_account_id"`
-	PageId        uint16 `form:"page_id" binding:"required,min=1"`
-	PageSize      uint16 `form:"page_size" binding:"required,min=1,max=10"`
+// ownsTransfer reports whether username owns either side of the transfer
+func (server *Server) ownsTransfer(ctx *gin.Context, username string, transfer db.Transfers) (bool, error) {
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			return false, err
+		}
+
+		if account.Owner == username {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+type listTransfersRequest struct {
+	AccountID int64     `form:"account_id" binding:"omitempty,min=1"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=sent received"`
+	Currency  string    `form:"currency" binding:"omitempty,currency"`
+	FromDate  time.Time `form:"from_date" time_format:"2006-01-02T15:04:05Z07:00"`
+	ToDate    time.Time `form:"to_date" time_format:"2006-01-02T15:04:05Z07:00"`
+	pageRequest
 }
 
+// listTransfers lists the transfers in or out of the authenticated user's accounts.
+// Bankers and auditors see every user's transfers.
 func (server *Server) listTransfers(ctx *gin.Context) {
-	var req ListTransfersParams
+	var req listTransfersRequest
 
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
+	if !req.FromDate.IsZero() && !req.ToDate.IsZero() && !req.FromDate.Before(req.ToDate) {
+		err := errors.New("from_date must be before to_date")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := req.afterID()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+
 	arg := db.ListTransfersParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Limit:         int32(req.PageSize),
-		Offset:        int32(req.PageId-1) * int32(req.PageSize),
+		Direction: req.Direction,
+		Currency:  pgtype.Text{String: req.Currency, Valid: req.Currency != ""},
+		FromDate:  pgtype.Timestamptz{Time: req.FromDate, Valid: !req.FromDate.IsZero()},
+		ToDate:    pgtype.Timestamptz{Time: req.ToDate, Valid: !req.ToDate.IsZero()},
+		AfterID:   afterID,
+		Limit:     req.limit(),
 	}
 
-	accounts, err := server.store.ListTransfers(ctx, arg)
+	if !canReadAllAccounts(authPayload) {
+		arg.Owner = pgtype.Text{String: authPayload.Username, Valid: true}
+	}
+
+	if req.AccountID != 0 {
+		account, valid := server.validAccount(ctx, req.AccountID)
+		if !valid {
+			return
+		}
+
+		if arg.Owner.Valid && account.Owner != authPayload.Username {
+			err := errors.New("unauthorized access to account")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		arg.AccountID = pgtype.Int8{Int64: req.AccountID, Valid: true}
+	}
+
+	transfers, err := server.store.ListTransfers(ctx, arg)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"data": accounts,
+	var totalCount *int64
+	if req.IncludeTotal {
+		count, err := server.store.CountTransfers(ctx, db.CountTransfersParams{
+			Direction: arg.Direction,
+			Owner:     arg.Owner,
+			AccountID: arg.AccountID,
+			Currency:  arg.Currency,
+			FromDate:  arg.FromDate,
+			ToDate:    arg.ToDate,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		totalCount = &count
+	}
+
+	transfers, nextCursor := trimPage(transfers, req.PageSize, func(transfer db.Transfers) int64 {
+		return transfer.ID
 	})
+
+	ctx.JSON(http.StatusOK, pageEnvelope(transfers, nextCursor, totalCount))
 }
 
 func (server *Server) validAccount(ctx *gin.Context, accountID int64) (db.Accounts, bool) {
 	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
+		reportRolledBack(ctx)
 		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return account, false
@@ -137,5 +282,22 @@ func (server *Server) validAccount(ctx *gin.Context, accountID int64) (db.Accoun
 		return account, false
 	}
 
+	// the bank's system accounts are only posted to by the ledger, never by a request
+	if account.Kind != util.CustomerAccount {
+		ctx.JSON(http.StatusForbidden, errorResponse(db.ErrSystemAccount))
+		return account, false
+	}
+
 	return account, true
 }
+
+// validAmount rejects amounts that are more precise than the currency's minor unit
+func validAmount(ctx *gin.Context, amount decimal.Decimal, currency string) bool {
+	if !util.FitsCurrency(amount, currency) {
+		err := fmt.Errorf("amount %s has more decimal places than %s allows", amount, currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/util"
+)
+
+// validTransfer loads the transfer with the given id, writing the error response
+// when it does not exist or the caller owns neither side of it
+func (server *Server) validTransfer(ctx *gin.Context, id int64) (db.Transfers, bool) {
+	transfer, err := server.store.GetTransfer(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Transaction with id %v not found", id),
+			})
+			return transfer, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return transfer, false
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if canReadAllAccounts(authPayload) {
+		return transfer, true
+	}
+
+	owned, err := server.ownsTransfer(ctx, authPayload.Username, transfer)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return transfer, false
+	}
+
+	if !owned {
+		err := errors.New("unauthorized access to transfer")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return transfer, false
+	}
+
+	return transfer, true
+}
+
+// listTransferStatusChanges lists every status the transfer went through, oldest first
+func (server *Server) listTransferStatusChanges(ctx *gin.Context) {
+	var req getTransferRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, valid := server.validTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	changes, err := server.store.ListTransferStatusChanges(ctx, transfer.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": changes,
+	})
+}
+
+type cancelTransferRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// cancelTransfer withdraws a pending transfer before any money moved. Only
+// the sender can cancel.
+func (server *Server) cancelTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	var req cancelTransferRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the body can be left out when there is no reason to give
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, valid := server.validTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	sender, valid := server.validAccount(ctx, transfer.FromAccountID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if sender.Owner != authPayload.Username {
+		err := errors.New("only the sender can cancel a transfer")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.UpdateTransferStatusTx(ctx, db.UpdateTransferStatusTxRequest{
+		TransferID: transfer.ID,
+		Status:     util.TransferCancelled,
+		Reason:     req.Reason,
+		ChangedBy:  authPayload.Username,
+		Audit:      auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidTransferStatus) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
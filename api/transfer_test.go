@@ -13,10 +13,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	mockdb "github.com/rouclec/simplebank/db/mock"
 	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/fx"
 	"github.com/rouclec/simplebank/token"
 	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,6 +31,16 @@ func TestTransferTxApi(t *testing.T) {
 	account1 := generateRandomAccount(user1.Username)
 	account2 := generateRandomAccount(user2.Username)
 
+	quote := db.TransferQuotes{
+		ID:            uuid.New(),
+		Username:      user1.Username,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      "USD",
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+
 	testCases := []struct {
 		name          string
 		body          gin.H
@@ -43,7 +57,7 @@ func TestTransferTxApi(t *testing.T) {
 				"currency":        "USD",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
@@ -53,7 +67,8 @@ func TestTransferTxApi(t *testing.T) {
 					FromAccountID: account1.ID,
 					ToAccountID:   account2.ID,
 					Currency:      "USD",
-					Amount:        10,
+					Amount:        decimal.NewFromInt(10),
+					Audit:         &db.AuditContext{Actor: user1.Username},
 				}
 				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
 			},
@@ -70,7 +85,7 @@ func TestTransferTxApi(t *testing.T) {
 				"currency":        "USD",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user2.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user2.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
@@ -107,7 +122,7 @@ func TestTransferTxApi(t *testing.T) {
 				"currency":        "USD",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.Accounts{}, db.ErrRecordNotFound)
@@ -127,7 +142,7 @@ func TestTransferTxApi(t *testing.T) {
 				"currency":        "USD",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
@@ -138,6 +153,30 @@ func TestTransferTxApi(t *testing.T) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
+		{
+			name: "ToSystemAccount",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        "USD",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				feesIncome := account2
+				feesIncome.Owner = util.SystemOwner
+				feesIncome.Kind = util.FeesIncomeAccount
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(feesIncome, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			name: "InvalidCurrency",
 			body: gin.H{
@@ -147,7 +186,7 @@ func TestTransferTxApi(t *testing.T) {
 				"currency":        "XYZ",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -166,7 +205,26 @@ func TestTransferTxApi(t *testing.T) {
 				"currency":        "USD",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TooPreciseAmount",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "10.001",
+				"currency":        "USD",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -185,7 +243,7 @@ func TestTransferTxApi(t *testing.T) {
 				"currency":        "USD",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Accounts{}, sql.ErrConnDone)
@@ -204,7 +262,7 @@ func TestTransferTxApi(t *testing.T) {
 				"currency":        "USD",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
@@ -216,6 +274,231 @@ func TestTransferTxApi(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "StaleRates",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        "USD",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransfersTxResponse{}, fmt.Errorf("%w: last refreshed an hour ago", fx.ErrStaleRates))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        "USD",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransfersTxResponse{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "TransferLimitExceeded",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        "USD",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransfersTxResponse{}, db.ErrTransferLimitExceeded)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "TransferVelocityExceeded",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        "USD",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransfersTxResponse{}, db.ErrTransferVelocityExceeded)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "Quote",
+			body: gin.H{
+				"quote_id": quote.ID,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.TransferTxRequest{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Currency:      quote.Currency,
+					Amount:        quote.Amount,
+					QuoteID:       uuid.NullUUID{UUID: quote.ID, Valid: true},
+					Audit:         &db.AuditContext{Actor: user1.Username},
+				}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteNotFound",
+			body: gin.H{
+				"quote_id": quote.ID,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(db.TransferQuotes{}, db.ErrRecordNotFound)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "OtherUsersQuote",
+			body: gin.H{
+				"quote_id": quote.ID,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteMismatch",
+			body: gin.H{
+				"quote_id": quote.ID,
+				"amount":   20,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteExpired",
+			body: gin.H{
+				"quote_id": quote.ID,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransfersTxResponse{}, db.ErrQuoteExpired)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteUsed",
+			body: gin.H{
+				"quote_id": quote.ID,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransfersTxResponse{}, db.ErrQuoteUsed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidQuoteID",
+			body: gin.H{
+				"quote_id": "not-a-quote",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferQuote(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoQuoteNorFields",
+			body: gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -262,12 +545,21 @@ func TestListTransferApi(t *testing.T) {
 
 	transfers := make([]db.Transfers, n)
 	for i := 0; i < n; i++ {
-		transfers[i] = testTransaction(account, account2, 5, "USD").Transfer
+		transfers[i] = testTransaction(account, account2, decimal.NewFromInt(5), "USD").Transfer
 	}
+
+	fromDate := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
 	type Query struct {
-		fromAccountId int64
-		pageID        int
-		pageSize      int
+		accountID int64
+		direction string
+		currency  string
+		fromDate  string
+		toDate    string
+		cursor    string
+		pageSize  int
+		total     bool
 	}
 
 	testCases := []struct {
@@ -280,18 +572,15 @@ func TestListTransferApi(t *testing.T) {
 		{
 			name: "OK",
 			query: Query{
-				pageID:        1,
-				pageSize:      n,
-				fromAccountId: account.ID,
+				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				arg := db.ListTransfersParams{
-					FromAccountID: account.ID,
-					Limit:         int32(n),
-					Offset:        0,
+					Owner: pgtype.Text{String: user.Username, Valid: true},
+					Limit: int32(n + 1),
 				}
 				store.EXPECT().
 					ListTransfers(gomock.Any(), gomock.Eq(arg)).
@@ -300,18 +589,170 @@ func TestListTransferApi(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+				requirePage(t, recorder.Body, "", nil)
 				requireBodyMatchTransfers(t, recorder.Body, transfers)
 			},
 		},
+		{
+			name: "Filters",
+			query: Query{
+				accountID: account.ID,
+				direction: "sent",
+				currency:  "USD",
+				fromDate:  fromDate.Format(time.RFC3339),
+				toDate:    toDate.Format(time.RFC3339),
+				cursor:    encodeCursor(transfers[4].ID),
+				pageSize:  5,
+				total:     true,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.ListTransfersParams{
+					Direction: "sent",
+					Owner:     pgtype.Text{String: user.Username, Valid: true},
+					AccountID: pgtype.Int8{Int64: account.ID, Valid: true},
+					Currency:  pgtype.Text{String: "USD", Valid: true},
+					FromDate:  pgtype.Timestamptz{Time: fromDate, Valid: true},
+					ToDate:    pgtype.Timestamptz{Time: toDate, Valid: true},
+					AfterID:   transfers[4].ID,
+					Limit:     6,
+				}
+				store.EXPECT().
+					ListTransfers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(transfers[5:], nil)
+
+				countArg := db.CountTransfersParams{
+					Direction: arg.Direction,
+					Owner:     arg.Owner,
+					AccountID: arg.AccountID,
+					Currency:  arg.Currency,
+					FromDate:  arg.FromDate,
+					ToDate:    arg.ToDate,
+				}
+				store.EXPECT().
+					CountTransfers(gomock.Any(), gomock.Eq(countArg)).
+					Times(1).
+					Return(int64(n), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				total := int64(n)
+				requirePage(t, recorder.Body, "", &total)
+				requireBodyMatchTransfers(t, recorder.Body, transfers[5:])
+			},
+		},
+		{
+			name: "OtherUsersAccount",
+			query: Query{
+				accountID: account2.ID,
+				pageSize:  n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					ListTransfers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			query: Query{
+				accountID: account.ID,
+				pageSize:  n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Accounts{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					ListTransfers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BankerSeesOtherUsersAccount",
+			query: Query{
+				accountID: account2.ID,
+				pageSize:  n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.ListTransfersParams{
+					AccountID: pgtype.Int8{Int64: account2.ID, Valid: true},
+					Limit:     int32(n + 1),
+				}
+				store.EXPECT().
+					ListTransfers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(transfers, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidDirection",
+			query: Query{
+				direction: "sideways",
+				pageSize:  n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTransfers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidDateRange",
+			query: Query{
+				fromDate: toDate.Format(time.RFC3339),
+				toDate:   fromDate.Format(time.RFC3339),
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTransfers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "InternalServerError",
 			query: Query{
-				pageID:        1,
-				pageSize:      n,
-				fromAccountId: account.ID,
+				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -324,14 +765,13 @@ func TestListTransferApi(t *testing.T) {
 			},
 		},
 		{
-			name: "InvalidPageID",
+			name: "InvalidCursor",
 			query: Query{
-				pageID:        -1,
-				pageSize:      n,
-				fromAccountId: account.ID,
+				cursor:   "bad",
+				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -345,12 +785,10 @@ func TestListTransferApi(t *testing.T) {
 		{
 			name: "InvalidPageSize",
 			query: Query{
-				pageID:        1,
-				pageSize:      10000,
-				fromAccountId: account.ID,
+				pageSize: 10000,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -384,8 +822,27 @@ func TestListTransferApi(t *testing.T) {
 
 			// Add query parameters to request URL
 			q := request.URL.Query()
-			q.Add("from_account_id", fmt.Sprintf("%d", tc.query.fromAccountId))
-			q.Add("page_id", fmt.Sprintf("%d", tc.query.pageID))
+			if tc.query.accountID != 0 {
+				q.Add("account_id", fmt.Sprintf("%d", tc.query.accountID))
+			}
+			if tc.query.direction != "" {
+				q.Add("direction", tc.query.direction)
+			}
+			if tc.query.currency != "" {
+				q.Add("currency", tc.query.currency)
+			}
+			if tc.query.fromDate != "" {
+				q.Add("from_date", tc.query.fromDate)
+			}
+			if tc.query.toDate != "" {
+				q.Add("to_date", tc.query.toDate)
+			}
+			if tc.query.cursor != "" {
+				q.Add("cursor", tc.query.cursor)
+			}
+			if tc.query.total {
+				q.Add("include_total", "true")
+			}
 			q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
 			request.URL.RawQuery = q.Encode()
 
@@ -404,8 +861,9 @@ func TestGetTransferApi(t *testing.T) {
 	account := generateRandomAccount(user.Username)
 	user2, _ := randomUser(t)
 	account2 := generateRandomAccount(user2.Username)
+	user3, _ := randomUser(t)
 
-	transaction := testTransaction(account, account2, 50.00, "USD")
+	transaction := testTransaction(account, account2, decimal.NewFromInt(50), "USD")
 
 	testCases := []struct {
 		name          string
@@ -418,24 +876,79 @@ func TestGetTransferApi(t *testing.T) {
 			name: "OK",
 			ID:   transaction.Transfer.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransfer(gomock.Any(), gomock.Eq(transaction.Transfer.ID)).
+					Times(1).
+					Return(transaction.Transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchTransfer(t, recorder.Body, transaction.Transfer)
+			},
+		},
+		{
+			name: "Recipient",
+			ID:   transaction.Transfer.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user2.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetTransfer(gomock.Any(), gomock.Eq(transaction.Transfer.ID)).
 					Times(1).
 					Return(transaction.Transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchTransfer(t, recorder.Body, transaction.Transfer)
 			},
 		},
+		{
+			name: "OtherUser",
+			ID:   transaction.Transfer.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user3.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransfer(gomock.Any(), gomock.Eq(transaction.Transfer.ID)).
+					Times(1).
+					Return(transaction.Transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Auditor",
+			ID:   transaction.Transfer.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user3.Username, util.AuditorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransfer(gomock.Any(), gomock.Eq(transaction.Transfer.ID)).
+					Times(1).
+					Return(transaction.Transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
 		{
 			name: "NotFound",
 			ID:   transaction.Transfer.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -451,7 +964,7 @@ func TestGetTransferApi(t *testing.T) {
 			name: "InternalServerError",
 			ID:   transaction.Transfer.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -467,7 +980,7 @@ func TestGetTransferApi(t *testing.T) {
 			name: "BadRequest",
 			ID:   0,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -510,15 +1023,25 @@ func TestGetTransferApi(t *testing.T) {
 
 }
 
-func testTransaction(fromAccount db.Accounts, toAccount db.Accounts, amount float64, currency string) db.TransfersTxResponse {
-	fromAmount, _ := util.Converter(currency, fromAccount.Currency, amount)
-	toAmount, _ := util.Converter(currency, toAccount.Currency, amount)
+// testRates are the units of each currency per USD used by fake transfers
+var testRates = map[string]decimal.Decimal{
+	"USD": decimal.RequireFromString("1"),
+	"EUR": decimal.RequireFromString("1.1"),
+	"XAF": decimal.RequireFromString("607.29"),
+	"CAD": decimal.RequireFromString("1.35"),
+}
+
+func testTransaction(fromAccount db.Accounts, toAccount db.Accounts, amount decimal.Decimal, currency string) db.TransfersTxResponse {
+	fromRate := util.CrossRate(testRates[currency], testRates[fromAccount.Currency])
+	toRate := util.CrossRate(testRates[currency], testRates[toAccount.Currency])
+	fromAmount := util.ConvertAmount(amount, fromRate, fromAccount.Currency)
+	toAmount := util.ConvertAmount(amount, toRate, toAccount.Currency)
 
 	updatedFromAccount := fromAccount
-	updatedFromAccount.Balance = fromAmount - fromAmount
+	updatedFromAccount.Balance = fromAccount.Balance.Sub(fromAmount)
 
 	updatedToAccount := toAccount
-	updatedToAccount.Balance = toAccount.Balance + toAmount
+	updatedToAccount.Balance = toAccount.Balance.Add(toAmount)
 
 	return db.TransfersTxResponse{
 		Transfer: db.Transfers{
@@ -527,13 +1050,17 @@ func testTransaction(fromAccount db.Accounts, toAccount db.Accounts, amount floa
 			ToAccountID:   toAccount.ID,
 			Amount:        amount,
 			Currency:      currency,
+			FromRate:      fromRate,
+			FromAmount:    fromAmount,
+			ToRate:        toRate,
+			ToAmount:      toAmount,
 		},
 		FromAccount: updatedFromAccount,
 		ToAccount:   updatedToAccount,
 		FromEntry: db.Entries{
 			ID:        util.RandomInt(1, 1000),
 			AccountID: fromAccount.ID,
-			Amount:    -fromAmount,
+			Amount:    fromAmount.Neg(),
 		},
 		ToEntry: db.Entries{
 			ID:        util.RandomInt(1, 1000),
@@ -547,27 +1074,27 @@ type getTransferResponse struct {
 	Data db.Transfers `json:"data"` // Field name might be different based on your API design
 }
 
-type getTransfersResponse struct {
-	Data []db.Transfers `json:"data"`
-}
-
 func requireBodyMatchTransfer(t *testing.T, body *bytes.Buffer, transfer db.Transfers) {
 	data, err := io.ReadAll(body)
 	require.NoError(t, err)
 
-	var response getTransferResponse
-	err = json.Unmarshal(data, &response)
+	// decimals keep their exponent in memory, so compare the encoded forms
+	expected, err := json.Marshal(getTransferResponse{Data: transfer})
 	require.NoError(t, err)
-	require.Equal(t, response.Data, transfer)
+	require.JSONEq(t, string(expected), string(data))
 }
 
 func requireBodyMatchTransfers(t *testing.T, body *bytes.Buffer, transfers []db.Transfers) {
 	data, err := io.ReadAll(body)
 	require.NoError(t, err)
 
-	var responses getTransfersResponse
-	err = json.Unmarshal(data, &responses)
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	err = json.Unmarshal(data, &response)
+	require.NoError(t, err)
 
+	expected, err := json.Marshal(transfers)
 	require.NoError(t, err)
-	require.Equal(t, responses.Data, transfers)
+	require.JSONEq(t, string(expected), string(response.Data))
 }
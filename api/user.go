@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
 	"github.com/rouclec/simplebank/util"
 )
 
@@ -20,6 +22,8 @@ type createUserRequest struct {
 
 type userResponse struct {
 	Username          string    `json:"username"`
+	Role              string    `json:"role"`
+	Tier              string    `json:"tier"`
 	Email             string    `json:"email"`
 	FullName          string    `json:"full_name"`
 	CreatedAt         time.Time `json:"created_at"`
@@ -29,6 +33,8 @@ type userResponse struct {
 func bindUserResponse(user db.Users) userResponse {
 	return userResponse{
 		Username:          user.Username,
+		Role:              user.Role,
+		Tier:              user.Tier,
 		Email:             user.Email,
 		FullName:          user.FullName,
 		CreatedAt:         user.CreatedAt,
@@ -58,7 +64,10 @@ func (server *Server) createUser(ctx *gin.Context) {
 		Password: hashedPassword,
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	user, err := server.store.CreateUserTx(ctx, db.CreateUserTxRequest{
+		CreateUserParams: arg,
+		Audit:            auditContext(ctx, req.Username),
+	})
 
 	if err != nil {
 		errCode := db.ErrorCode(err)
@@ -111,8 +120,12 @@ type loginUserRequest struct {
 }
 
 type loginUserResponse struct {
-	AccessToken string       `json:"access_token"`
-	User        userResponse `json:"user"`
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  userResponse `json:"user"`
 }
 
 func (server *Server) login(ctx *gin.Context) {
@@ -143,19 +156,143 @@ func (server *Server) login(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, _, err := server.tokenMaker.CreateToken(req.Username, server.config.AccessTokenDuration)
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, server.config.AccessTokenDuration)
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.RefreshToken, server.config.RefreshTokenDuration)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.SetCookie("auth", accessToken, 3600, "/", server.config.Domain, false, true)
+	session, err := server.store.CreateSessionTx(ctx, db.CreateSessionTxRequest{
+		CreateSessionParams: db.CreateSessionParams{
+			ID:           refreshPayload.ID,
+			Username:     user.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			IsBlocked:    false,
+			ExpiresAt:    refreshPayload.ExpiredAt,
+		},
+		Audit: auditContext(ctx, user.Username),
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.setAccessCookie(ctx, accessToken)
+	server.setRefreshCookie(ctx, refreshToken)
 
 	response := loginUserResponse{
-		AccessToken: accessToken,
-		User:        bindUserResponse(user),
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  bindUserResponse(user),
 	}
 
 	ctx.JSON(http.StatusOK, response)
 }
+
+type updateUserRoleURI struct {
+	Username string `uri:"username" binding:"required,min=4"`
+}
+
+type updateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,role"`
+}
+
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var uri updateUserRoleURI
+	var req updateUserRoleRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	user, err := server.store.UpdateUserRoleTx(ctx, db.UpdateUserRoleTxRequest{
+		UpdateUserRoleParams: db.UpdateUserRoleParams{
+			Role:     req.Role,
+			Username: uri.Username,
+		},
+		Audit: auditContext(ctx, authPayload.Username),
+	})
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("User with username %v not found", uri.Username),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the tokens issued with the old role were revoked along with the change
+	server.revocations.RevokedBefore(user.Username, user.TokensValidAfter)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": bindUserResponse(user),
+	})
+}
+
+type updateUserTierRequest struct {
+	Tier string `json:"tier" binding:"required,tier"`
+}
+
+// updateUserTier moves the user to another tier, and so under its transfer limits
+func (server *Server) updateUserTier(ctx *gin.Context) {
+	var uri updateUserRoleURI
+	var req updateUserTierRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	user, err := server.store.UpdateUserTierTx(ctx, db.UpdateUserTierTxRequest{
+		UpdateUserTierParams: db.UpdateUserTierParams{
+			Tier:     req.Tier,
+			Username: uri.Username,
+		},
+		Audit: auditContext(ctx, authPayload.Username),
+	})
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("User with username %v not found", uri.Username),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": bindUserResponse(user),
+	})
+}
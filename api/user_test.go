@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -27,11 +28,18 @@ type eqCreateUserParamsMatcher struct {
 }
 
 func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateUserParams)
+	req, ok := x.(db.CreateUserTxRequest)
 	if !ok {
 		return false
 	}
 
+	// the signup is audited as made by the new user
+	if req.Audit == nil || req.Audit.Actor != e.arg.Username {
+		return false
+	}
+
+	arg := req.CreateUserParams
+
 	err := util.CheckPassword(e.password, arg.Password)
 	if err != nil {
 		return false
@@ -73,7 +81,7 @@ func TestCreateUserAPI(t *testing.T) {
 					Email:    user.Email,
 				}
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
+					CreateUserTx(gomock.Any(), EqCreateUserParams(arg, password)).
 					Times(1).
 					Return(user, nil)
 			},
@@ -92,7 +100,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.Users{}, sql.ErrConnDone)
 			},
@@ -110,7 +118,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.Users{}, db.ErrUniqueViolation)
 			},
@@ -128,7 +136,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -145,7 +153,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -162,7 +170,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -185,7 +193,7 @@ func TestCreateUserAPI(t *testing.T) {
 				}
 
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
+					CreateUserTx(gomock.Any(), EqCreateUserParams(arg, password)).
 					Times(1).
 					Return(db.Users{}, sql.ErrConnDone)
 			},
@@ -236,7 +244,7 @@ func TestGetUserApi(t *testing.T) {
 			name:     "OK",
 			username: user.Username,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -253,7 +261,7 @@ func TestGetUserApi(t *testing.T) {
 			name:     "NotFound",
 			username: user.Username,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -269,7 +277,7 @@ func TestGetUserApi(t *testing.T) {
 			name:     "InternalServerError",
 			username: user.Username,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -285,7 +293,7 @@ func TestGetUserApi(t *testing.T) {
 			name:     "BadRequest",
 			username: "123",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -345,9 +353,55 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					CreateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateSessionTxRequest) (db.Sessions, error) {
+						require.Equal(t, user.Username, arg.Audit.Actor)
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.RefreshToken)
+						require.False(t, arg.IsBlocked)
+						return db.Sessions{ID: arg.ID, Username: arg.Username, RefreshToken: arg.RefreshToken, ExpiresAt: arg.ExpiresAt}, nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.NotEmpty(t, response.AccessToken)
+				require.NotEmpty(t, response.RefreshToken)
+				require.True(t, response.RefreshTokenExpiresAt.After(response.AccessTokenExpiresAt))
+
+				cookies := map[string]*http.Cookie{}
+				for _, cookie := range recorder.Result().Cookies() {
+					cookies[cookie.Name] = cookie
+				}
+				require.Equal(t, response.AccessToken, cookies[authCookieName].Value)
+				require.Equal(t, int(time.Minute.Seconds()), cookies[authCookieName].MaxAge)
+				require.Equal(t, response.RefreshToken, cookies[refreshCookieName].Value)
+				require.Equal(t, int(time.Hour.Seconds()), cookies[refreshCookieName].MaxAge)
+			},
+		},
+		{
+			name: "CreateSessionError",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Sessions{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
 		{
@@ -452,6 +506,7 @@ func randomUser(t *testing.T) (user db.Users, password string) {
 		Password: hashedPassword,
 		FullName: util.RandomOwner(),
 		Email:    util.RandomEmail(),
+		Role:     util.DepositorRole,
 	}
 	return
 }
@@ -475,3 +530,251 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.Users) {
 	require.Equal(t, user.Email, gotUser.Email)
 	require.Empty(t, gotUser.Password)
 }
+
+func TestUpdateUserRoleAPI(t *testing.T) {
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			body:     gin.H{"role": util.AuditorRole},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, banker.Username, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				updatedUser := user
+				updatedUser.Role = util.AuditorRole
+
+				arg := db.UpdateUserRoleTxRequest{
+					UpdateUserRoleParams: db.UpdateUserRoleParams{Role: util.AuditorRole, Username: user.Username},
+					Audit:                &db.AuditContext{Actor: banker.Username},
+				}
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(updatedUser, nil)
+				store.EXPECT().RevokeUserTokensTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), util.AuditorRole)
+			},
+		},
+		{
+			name:     "NotABanker",
+			username: user.Username,
+			body:     gin.H{"role": util.BankerRole},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRoleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NoAuthorization",
+			username: user.Username,
+			body:     gin.H{"role": util.BankerRole},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRoleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidRole",
+			username: user.Username,
+			body:     gin.H{"role": "superuser"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, banker.Username, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRoleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			username: user.Username,
+			body:     gin.H{"role": util.AuditorRole},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authTypeBearer, banker.Username, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Users{}, db.ErrRecordNotFound)
+				store.EXPECT().RevokeUserTokensTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/admin/users/%s/role", tc.username)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateUserRoleRevokesCachedTokens(t *testing.T) {
+	banker, _ := randomUser(t)
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	// a token the user was issued before the change
+	oldPayload, err := token.NewPayload(user.Username, util.DepositorRole, token.AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	updatedUser := user
+	updatedUser.Role = util.AuditorRole
+	updatedUser.TokensValidAfter = time.Now()
+	store.EXPECT().UpdateUserRoleTx(gomock.Any(), gomock.Any()).Times(1).Return(updatedUser, nil)
+
+	data, err := json.Marshal(gin.H{"role": util.AuditorRole})
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("/api/v1/admin/users/%s/role", user.Username)
+	request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authTypeBearer, banker.Username, util.BankerRole, time.Minute)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// refused from the cache once the change committed, without asking the database
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+	revoked, err := server.revocations.IsRevoked(context.Background(), oldPayload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestUpdateUserTierAPI(t *testing.T) {
+	banker, _ := randomUser(t)
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"tier": util.PremiumTier},
+			buildStubs: func(store *mockdb.MockStore) {
+				updatedUser := user
+				updatedUser.Tier = util.PremiumTier
+
+				arg := db.UpdateUserTierTxRequest{
+					UpdateUserTierParams: db.UpdateUserTierParams{Tier: util.PremiumTier, Username: user.Username},
+					Audit:                &db.AuditContext{Actor: banker.Username},
+				}
+				store.EXPECT().
+					UpdateUserTierTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(updatedUser, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), util.PremiumTier)
+			},
+		},
+		{
+			name: "InvalidTier",
+			body: gin.H{"tier": "gold"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTierTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: gin.H{"tier": util.PremiumTier},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTierTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Users{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/admin/users/%s/tier", user.Username)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authTypeBearer, banker.Username, util.BankerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
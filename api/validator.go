@@ -1,10 +1,12 @@
 package api
 
 import (
+	"reflect"
 	"regexp"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
 )
 
 var validateCurrency validator.Func = func(fl validator.FieldLevel) bool {
@@ -14,6 +16,27 @@ var validateCurrency validator.Func = func(fl validator.FieldLevel) bool {
 	return false
 }
 
+var validateRole validator.Func = func(fl validator.FieldLevel) bool {
+	if role, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedRole(role)
+	}
+	return false
+}
+
+var validateTier validator.Func = func(fl validator.FieldLevel) bool {
+	if tier, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedTier(tier)
+	}
+	return false
+}
+
+var validateEventType validator.Func = func(fl validator.FieldLevel) bool {
+	if eventType, ok := fl.Field().Interface().(string); ok {
+		return util.IsSubscribableEvent(eventType)
+	}
+	return false
+}
+
 var validateEmail validator.Func = func(fl validator.FieldLevel) bool {
 	if email, ok := fl.Field().Interface().(string); ok {
 		// Regular expression to match valid email format
@@ -41,3 +64,13 @@ var validatePassword validator.Func = func(fl validator.FieldLevel) bool {
 	}
 	return false
 }
+
+// decimalValue exposes decimal amounts to the validator as float64 so that
+// numeric tags such as gt=0 keep working on money fields
+func decimalValue(field reflect.Value) interface{} {
+	if amount, ok := field.Interface().(decimal.Decimal); ok {
+		value, _ := amount.Float64()
+		return value
+	}
+	return nil
+}
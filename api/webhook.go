@@ -0,0 +1,336 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/token"
+	"github.com/rouclec/simplebank/webhook"
+)
+
+// webhookResponse is a webhook endpoint without its secret, which is only
+// shown once, when the endpoint is registered
+type webhookResponse struct {
+	ID         int64     `json:"id"`
+	Owner      string    `json:"owner"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func bindWebhookResponse(endpoint db.WebhookEndpoints) webhookResponse {
+	return webhookResponse{
+		ID:         endpoint.ID,
+		Owner:      endpoint.Owner,
+		URL:        endpoint.Url,
+		EventTypes: endpoint.EventTypes,
+		Active:     endpoint.Active,
+		CreatedAt:  endpoint.CreatedAt,
+	}
+}
+
+type createWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,http_url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,unique,dive,event_type"`
+}
+
+type createWebhookResponse struct {
+	webhookResponse
+	// Secret the endpoint's deliveries are signed with
+	Secret string `json:"secret"`
+}
+
+// createWebhook registers an endpoint the authenticated user's events are posted to
+func (server *Server) createWebhook(ctx *gin.Context) {
+	var req createWebhookRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// deliveries are posted from inside the bank, so they must not be aimed back at it
+	if err := webhook.CheckURL(ctx, server.resolver, req.URL); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+
+	endpoint, err := server.store.CreateWebhookEndpointTx(ctx, db.CreateWebhookEndpointTxRequest{
+		CreateWebhookEndpointParams: db.CreateWebhookEndpointParams{
+			Owner:      authPayload.Username,
+			Url:        req.URL,
+			Secret:     secret,
+			EventTypes: req.EventTypes,
+		},
+		Audit: auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": createWebhookResponse{
+			webhookResponse: bindWebhookResponse(endpoint),
+			Secret:          endpoint.Secret,
+		},
+	})
+}
+
+type getWebhookRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// validWebhook loads the webhook endpoint with the given id, writing the error
+// response when it does not exist or the caller may not read it
+func (server *Server) validWebhook(ctx *gin.Context, id int64) (db.WebhookEndpoints, bool) {
+	endpoint, err := server.store.GetWebhookEndpoint(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"message": fmt.Sprintf("Webhook with id %v not found", id),
+			})
+			return endpoint, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return endpoint, false
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if !canReadAllAccounts(authPayload) && endpoint.Owner != authPayload.Username {
+		err := errors.New("unauthorized access to webhook")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return endpoint, false
+	}
+
+	return endpoint, true
+}
+
+func (server *Server) getWebhook(ctx *gin.Context) {
+	var req getWebhookRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, valid := server.validWebhook(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": bindWebhookResponse(endpoint),
+	})
+}
+
+type listWebhooksRequest struct {
+	pageRequest
+}
+
+func (server *Server) listWebhooks(ctx *gin.Context) {
+	var req listWebhooksRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := req.afterID()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+
+	endpoints, err := server.store.ListWebhookEndpoints(ctx, db.ListWebhookEndpointsParams{
+		Owner:   authPayload.Username,
+		AfterID: afterID,
+		Limit:   req.limit(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var totalCount *int64
+	if req.IncludeTotal {
+		count, err := server.store.CountWebhookEndpoints(ctx, authPayload.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		totalCount = &count
+	}
+
+	endpoints, nextCursor := trimPage(endpoints, req.PageSize, func(endpoint db.WebhookEndpoints) int64 {
+		return endpoint.ID
+	})
+
+	data := make([]webhookResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		data[i] = bindWebhookResponse(endpoint)
+	}
+
+	ctx.JSON(http.StatusOK, pageEnvelope(data, nextCursor, totalCount))
+}
+
+// deleteWebhook disables an endpoint: nothing more is posted to it, and its
+// delivery log is kept
+func (server *Server) deleteWebhook(ctx *gin.Context) {
+	var req getWebhookRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, valid := server.validWebhook(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	if endpoint.Owner != authPayload.Username {
+		err := errors.New("webhook doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	endpoint, err := server.store.DisableWebhookEndpointTx(ctx, db.DisableWebhookEndpointTxRequest{
+		ID:    req.ID,
+		Audit: auditContext(ctx, authPayload.Username),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("webhook is already disabled")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": bindWebhookResponse(endpoint),
+	})
+}
+
+type webhookDeliveryResponse struct {
+	ID             int64              `json:"id"`
+	EndpointID     int64              `json:"endpoint_id"`
+	EventID        int64              `json:"event_id"`
+	EventType      string             `json:"event_type"`
+	Payload        json.RawMessage    `json:"payload"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	ResponseStatus int32              `json:"response_status"`
+	LastError      string             `json:"last_error"`
+	NextAttemptAt  time.Time          `json:"next_attempt_at"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// bindWebhookDeliveryResponse embeds the payload, JSON already, as it was posted
+func bindWebhookDeliveryResponse(delivery db.WebhookDeliveries) webhookDeliveryResponse {
+	return webhookDeliveryResponse{
+		ID:             delivery.ID,
+		EndpointID:     delivery.EndpointID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+type listWebhookDeliveriesRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	pageRequest
+}
+
+// listWebhookDeliveries lists the events queued for the endpoint, oldest first,
+// with the outcome of the latest attempt at posting each
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var uri getWebhookRequest
+	var req listWebhookDeliveriesRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := req.afterID()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, valid := server.validWebhook(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	status := pgtype.Text{String: req.Status, Valid: req.Status != ""}
+
+	deliveries, err := server.store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		EndpointID: endpoint.ID,
+		Status:     status,
+		AfterID:    afterID,
+		Limit:      req.limit(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var totalCount *int64
+	if req.IncludeTotal {
+		count, err := server.store.CountWebhookDeliveries(ctx, db.CountWebhookDeliveriesParams{
+			EndpointID: endpoint.ID,
+			Status:     status,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		totalCount = &count
+	}
+
+	deliveries, nextCursor := trimPage(deliveries, req.PageSize, func(delivery db.WebhookDeliveries) int64 {
+		return delivery.ID
+	})
+
+	data := make([]webhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		data[i] = bindWebhookDeliveryResponse(delivery)
+	}
+
+	ctx.JSON(http.StatusOK, pageEnvelope(data, nextCursor, totalCount))
+}
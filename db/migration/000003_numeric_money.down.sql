@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS "transfers" ALTER COLUMN "amount" TYPE float USING "amount"::float;
+
+ALTER TABLE IF EXISTS "entries" ALTER COLUMN "amount" TYPE float USING "amount"::float;
+
+ALTER TABLE IF EXISTS "accounts" ALTER COLUMN "balance" TYPE float USING "balance"::float;
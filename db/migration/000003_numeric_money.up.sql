@@ -0,0 +1,12 @@
+-- amounts are rounded to the minor unit of their currency: none for XAF, cents otherwise
+ALTER TABLE "accounts" ALTER COLUMN "balance" TYPE numeric USING round("balance"::numeric, CASE "currency" WHEN 'XAF' THEN 0 ELSE 2 END);
+
+ALTER TABLE "entries" ALTER COLUMN "amount" TYPE numeric USING "amount"::numeric;
+
+-- an entry is in the currency of its account, which a column conversion cannot look up
+UPDATE "entries"
+SET "amount" = round("entries"."amount", CASE "accounts"."currency" WHEN 'XAF' THEN 0 ELSE 2 END)
+FROM "accounts"
+WHERE "accounts"."id" = "entries"."account_id";
+
+ALTER TABLE "transfers" ALTER COLUMN "amount" TYPE numeric USING round("amount"::numeric, CASE "currency" WHEN 'XAF' THEN 0 ELSE 2 END);
@@ -0,0 +1,9 @@
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "to_amount";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "to_rate";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "from_amount";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "from_rate";
+
+DROP TABLE IF EXISTS "exchange_rates";
@@ -0,0 +1,50 @@
+CREATE TABLE "exchange_rates" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "rate" numeric NOT NULL,
+  "effective_from" timestamptz NOT NULL DEFAULT (now()),
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "exchange_rates" ("currency", "effective_from");
+
+COMMENT ON COLUMN "exchange_rates"."rate" IS 'units of currency per USD';
+
+-- Seed the history with the rates previously hard-coded in util.Converter
+INSERT INTO "exchange_rates" ("currency", "rate", "effective_from") VALUES
+  ('USD', 1, '0001-01-01 00:00:00Z'),
+  ('EUR', 1.1, '0001-01-01 00:00:00Z'),
+  ('XAF', 607.29, '0001-01-01 00:00:00Z'),
+  ('CAD', 1.35, '0001-01-01 00:00:00Z');
+
+ALTER TABLE "transfers" ADD COLUMN "from_rate" numeric;
+ALTER TABLE "transfers" ADD COLUMN "from_amount" numeric;
+ALTER TABLE "transfers" ADD COLUMN "to_rate" numeric;
+ALTER TABLE "transfers" ADD COLUMN "to_amount" numeric;
+
+-- Existing transfers were converted with the seeded rates and rounded to two decimals
+UPDATE "transfers" AS t
+SET
+  "from_rate" = round(fr."rate" / tr."rate", 8),
+  "from_amount" = round(t."amount" * fr."rate" / tr."rate", 2),
+  "to_rate" = round(tor."rate" / tr."rate", 8),
+  "to_amount" = round(t."amount" * tor."rate" / tr."rate", 2)
+FROM "accounts" AS fa, "accounts" AS ta, "exchange_rates" AS tr, "exchange_rates" AS fr, "exchange_rates" AS tor
+WHERE fa."id" = t."from_account_id"
+  AND ta."id" = t."to_account_id"
+  AND tr."currency" = t."currency"
+  AND fr."currency" = fa."currency"
+  AND tor."currency" = ta."currency";
+
+ALTER TABLE "transfers" ALTER COLUMN "from_rate" SET NOT NULL;
+ALTER TABLE "transfers" ALTER COLUMN "from_amount" SET NOT NULL;
+ALTER TABLE "transfers" ALTER COLUMN "to_rate" SET NOT NULL;
+ALTER TABLE "transfers" ALTER COLUMN "to_amount" SET NOT NULL;
+
+COMMENT ON COLUMN "transfers"."from_rate" IS 'units of the source account currency per unit of the transfer currency';
+
+COMMENT ON COLUMN "transfers"."from_amount" IS 'amount debited from the source account, in its currency';
+
+COMMENT ON COLUMN "transfers"."to_rate" IS 'units of the destination account currency per unit of the transfer currency';
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited to the destination account, in its currency';
@@ -0,0 +1,17 @@
+CREATE TABLE "idempotency_keys" (
+  "username" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "request_hash" varchar NOT NULL,
+  "response_status" integer NOT NULL DEFAULT 0,
+  "response_body" jsonb,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "key")
+);
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "idempotency_keys" ("created_at");
+
+COMMENT ON COLUMN "idempotency_keys"."request_hash" IS 'sha256 of the method, path and body of the first request';
+
+COMMENT ON COLUMN "idempotency_keys"."response_status" IS '0 while the first request is still being processed';
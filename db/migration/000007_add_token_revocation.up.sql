@@ -0,0 +1,18 @@
+CREATE TABLE "revoked_tokens" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "revoked_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "revoked_tokens" ("expires_at");
+
+COMMENT ON COLUMN "revoked_tokens"."id" IS 'id of the token payload';
+
+COMMENT ON COLUMN "revoked_tokens"."expires_at" IS 'the row can be deleted once the token has expired';
+
+ALTER TABLE "users" ADD COLUMN "tokens_valid_after" timestamptz NOT NULL DEFAULT('0001-01-01 00:00:00Z');
+
+COMMENT ON COLUMN "users"."tokens_valid_after" IS 'tokens issued before this time are revoked';
@@ -0,0 +1,19 @@
+ALTER TABLE "entries" ADD COLUMN "type" varchar NOT NULL DEFAULT 'transfer';
+
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD CONSTRAINT "entries_type_check" CHECK ("type" IN ('transfer', 'deposit', 'withdrawal'));
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'the transfer that produced the entry, if any';
+
+-- entries written by a transfer share its transaction, hence its created_at
+UPDATE "entries" e SET "transfer_id" = t."id"
+FROM "transfers" t
+WHERE e."created_at" = t."created_at"
+AND e."account_id" IN (t."from_account_id", t."to_account_id");
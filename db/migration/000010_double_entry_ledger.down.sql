@@ -0,0 +1,14 @@
+-- opening deposits booked on customer accounts are kept, they match the balances
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "kind" <> 'customer');
+
+DELETE FROM "accounts" WHERE "kind" <> 'customer';
+
+DELETE FROM "users" WHERE "username" = 'system';
+
+DROP INDEX IF EXISTS "system_kind_currency_key";
+
+DROP INDEX IF EXISTS "owner_currency_key";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "kind";
@@ -0,0 +1,62 @@
+ALTER TABLE "accounts" ADD COLUMN "kind" varchar NOT NULL DEFAULT 'customer';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_kind_check" CHECK ("kind" IN ('customer', 'cash', 'fx_clearing', 'fees_income'));
+
+COMMENT ON COLUMN "accounts"."kind" IS 'customer, or the system account the bank books the other side of movements to';
+
+-- customers keep one account per currency, the bank one system account per kind and currency
+ALTER TABLE "accounts" DROP CONSTRAINT "owner_currency_key";
+
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "kind" = 'customer';
+
+CREATE UNIQUE INDEX "system_kind_currency_key" ON "accounts" ("kind", "currency") WHERE "kind" <> 'customer';
+
+-- the bank owns the system accounts. The password is not a bcrypt hash, so it can never log in.
+INSERT INTO "users" ("username", "password", "full_name", "email")
+VALUES ('system', '!', 'SimpleBank', 'system@simplebank.invalid');
+
+INSERT INTO "accounts" ("owner", "balance", "currency", "kind")
+SELECT 'system', 0, c."currency", k."kind"
+FROM (SELECT DISTINCT "currency" FROM "accounts") c
+CROSS JOIN (VALUES ('cash'), ('fx_clearing'), ('fees_income')) AS k ("kind");
+
+-- cross-currency transfers left each currency unbalanced, book the conversion through FX clearing
+INSERT INTO "entries" ("account_id", "amount", "type", "transfer_id", "created_at")
+SELECT fx."id", t."from_amount", 'transfer', t."id", t."created_at"
+FROM "transfers" t
+JOIN "accounts" fa ON fa."id" = t."from_account_id"
+JOIN "accounts" ta ON ta."id" = t."to_account_id"
+JOIN "accounts" fx ON fx."kind" = 'fx_clearing' AND fx."currency" = fa."currency"
+WHERE fa."currency" <> ta."currency";
+
+INSERT INTO "entries" ("account_id", "amount", "type", "transfer_id", "created_at")
+SELECT fx."id", -t."to_amount", 'transfer', t."id", t."created_at"
+FROM "transfers" t
+JOIN "accounts" fa ON fa."id" = t."from_account_id"
+JOIN "accounts" ta ON ta."id" = t."to_account_id"
+JOIN "accounts" fx ON fx."kind" = 'fx_clearing' AND fx."currency" = ta."currency"
+WHERE fa."currency" <> ta."currency";
+
+-- balances credited without an entry become an opening deposit against cash
+CREATE TEMPORARY TABLE "opening_balances" AS
+SELECT a."id", a."currency", a."balance" - COALESCE(SUM(e."amount"), 0) AS "amount"
+FROM "accounts" a
+LEFT JOIN "entries" e ON e."account_id" = a."id"
+WHERE a."kind" = 'customer'
+GROUP BY a."id"
+HAVING a."balance" - COALESCE(SUM(e."amount"), 0) <> 0;
+
+INSERT INTO "entries" ("account_id", "amount", "type")
+SELECT "id", "amount", 'deposit' FROM "opening_balances";
+
+INSERT INTO "entries" ("account_id", "amount", "type")
+SELECT cash."id", -o."amount", 'deposit'
+FROM "opening_balances" o
+JOIN "accounts" cash ON cash."kind" = 'cash' AND cash."currency" = o."currency";
+
+DROP TABLE "opening_balances";
+
+UPDATE "accounts" a SET "balance" = (
+  SELECT COALESCE(SUM(e."amount"), 0) FROM "entries" e WHERE e."account_id" = a."id"
+)
+WHERE a."kind" <> 'customer';
@@ -0,0 +1,10 @@
+-- fee entries stay on the books as part of their transfer
+UPDATE "entries" SET "type" = 'transfer' WHERE "type" = 'fee';
+
+ALTER TABLE IF EXISTS "entries" DROP CONSTRAINT IF EXISTS "entries_type_check";
+
+ALTER TABLE IF EXISTS "entries" ADD CONSTRAINT "entries_type_check" CHECK ("type" IN ('transfer', 'deposit', 'withdrawal'));
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee";
+
+DROP TABLE IF EXISTS "fee_schedules";
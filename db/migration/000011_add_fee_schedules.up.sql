@@ -0,0 +1,37 @@
+CREATE TABLE "fee_schedules" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "flat_amount" numeric NOT NULL DEFAULT 0,
+  "rate" numeric NOT NULL DEFAULT 0,
+  "min_amount" numeric NOT NULL DEFAULT 0,
+  "max_amount" numeric,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "fee_schedules" ADD CONSTRAINT "fee_schedules_kind_check" CHECK ("kind" IN ('flat', 'percentage', 'tiered', 'fx_markup'));
+
+ALTER TABLE "fee_schedules" ADD CONSTRAINT "fee_schedules_amounts_check" CHECK (
+  "flat_amount" >= 0 AND "rate" >= 0 AND "min_amount" >= 0
+  AND ("max_amount" IS NULL OR "max_amount" > "min_amount")
+);
+
+CREATE INDEX ON "fee_schedules" ("currency");
+
+COMMENT ON COLUMN "fee_schedules"."currency" IS 'currency of the source accounts the fee is charged to';
+
+COMMENT ON COLUMN "fee_schedules"."flat_amount" IS 'fixed fee, for flat and tiered schedules';
+
+COMMENT ON COLUMN "fee_schedules"."rate" IS 'fraction of the debited amount charged, for percentage, tiered and fx_markup schedules';
+
+COMMENT ON COLUMN "fee_schedules"."min_amount" IS 'tiered schedules apply from this debited amount';
+
+COMMENT ON COLUMN "fee_schedules"."max_amount" IS 'tiered schedules apply below this debited amount, without limit when null';
+
+ALTER TABLE "transfers" ADD COLUMN "fee" numeric NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "transfers"."fee" IS 'charged to the source account on top of from_amount, in its currency';
+
+ALTER TABLE "entries" DROP CONSTRAINT "entries_type_check";
+
+ALTER TABLE "entries" ADD CONSTRAINT "entries_type_check" CHECK ("type" IN ('transfer', 'deposit', 'withdrawal', 'fee'));
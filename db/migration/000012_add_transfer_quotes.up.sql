@@ -0,0 +1,30 @@
+CREATE TABLE "transfer_quotes" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" numeric NOT NULL,
+  "currency" varchar NOT NULL,
+  "from_rate" numeric NOT NULL,
+  "from_amount" numeric NOT NULL,
+  "to_rate" numeric NOT NULL,
+  "to_amount" numeric NOT NULL,
+  "fee" numeric NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_quotes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_quotes" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_quotes" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_quotes" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE UNIQUE INDEX ON "transfer_quotes" ("transfer_id");
+
+COMMENT ON COLUMN "transfer_quotes"."username" IS 'user the quote was given to, the only one who can execute it';
+
+COMMENT ON COLUMN "transfer_quotes"."transfer_id" IS 'the transfer that executed the quote, a quote can only be executed once';
@@ -0,0 +1,64 @@
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" numeric NOT NULL,
+  "currency" varchar NOT NULL,
+  "frequency" varchar NOT NULL,
+  "day_of_month" integer NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "due_at" timestamptz NOT NULL,
+  "next_run_at" timestamptz NOT NULL,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "locked_until" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_transfers_frequency_check" CHECK ("frequency" IN ('once', 'weekly', 'monthly'));
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_transfers_day_of_month_check" CHECK ("day_of_month" BETWEEN 0 AND 31);
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_transfers_status_check" CHECK ("status" IN ('active', 'completed', 'cancelled', 'failed'));
+
+CREATE INDEX ON "scheduled_transfers" ("owner", "id");
+
+CREATE INDEX ON "scheduled_transfers" ("next_run_at") WHERE "status" = 'active';
+
+COMMENT ON COLUMN "scheduled_transfers"."day_of_month" IS 'day monthly transfers run on, the last day of shorter months; 0 for other frequencies';
+
+COMMENT ON COLUMN "scheduled_transfers"."due_at" IS 'occurrence currently due';
+
+COMMENT ON COLUMN "scheduled_transfers"."next_run_at" IS 'when the executor next attempts the due occurrence, later than due_at while retrying';
+
+COMMENT ON COLUMN "scheduled_transfers"."attempts" IS 'failed attempts at the due occurrence';
+
+COMMENT ON COLUMN "scheduled_transfers"."locked_until" IS 'lease of the executor replica running the due occurrence';
+
+CREATE TABLE "scheduled_transfer_runs" (
+  "id" bigserial PRIMARY KEY,
+  "scheduled_transfer_id" bigint NOT NULL,
+  "due_at" timestamptz NOT NULL,
+  "attempt" integer NOT NULL,
+  "status" varchar NOT NULL,
+  "transfer_id" bigint,
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD CONSTRAINT "scheduled_transfer_runs_status_check" CHECK ("status" IN ('succeeded', 'retrying', 'failed'));
+
+CREATE INDEX ON "scheduled_transfer_runs" ("scheduled_transfer_id", "id");
+
+-- an occurrence moves money at most once
+CREATE UNIQUE INDEX ON "scheduled_transfer_runs" ("scheduled_transfer_id", "due_at") WHERE "status" = 'succeeded';
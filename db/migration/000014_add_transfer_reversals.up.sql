@@ -0,0 +1,22 @@
+CREATE TABLE "transfer_reversals" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint NOT NULL,
+  "reversal_transfer_id" bigint UNIQUE NOT NULL,
+  "initiated_by" varchar NOT NULL,
+  "reason" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_reversals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_reversals" ADD FOREIGN KEY ("reversal_transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_reversals" ADD FOREIGN KEY ("initiated_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_reversals" ADD CONSTRAINT "transfer_reversals_distinct_check" CHECK ("transfer_id" <> "reversal_transfer_id");
+
+CREATE INDEX ON "transfer_reversals" ("transfer_id");
+
+COMMENT ON COLUMN "transfer_reversals"."transfer_id" IS 'the transfer being reversed, in full or in part';
+
+COMMENT ON COLUMN "transfer_reversals"."reversal_transfer_id" IS 'the compensating transfer, from the original recipient back to the sender at the original rates';
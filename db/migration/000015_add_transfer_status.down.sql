@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS "transfer_status_changes";
+
+-- transfers that never completed moved no money
+DELETE FROM "transfer_reversals" WHERE "transfer_id" IN (SELECT "id" FROM "transfers" WHERE "status" <> 'completed');
+
+UPDATE "transfer_quotes" SET "transfer_id" = NULL WHERE "transfer_id" IN (SELECT "id" FROM "transfers" WHERE "status" <> 'completed');
+
+UPDATE "scheduled_transfer_runs" SET "transfer_id" = NULL WHERE "transfer_id" IN (SELECT "id" FROM "transfers" WHERE "status" <> 'completed');
+
+DELETE FROM "transfers" WHERE "status" <> 'completed';
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "failure_reason";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "status";
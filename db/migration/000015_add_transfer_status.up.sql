@@ -0,0 +1,33 @@
+ALTER TABLE "transfers" ADD COLUMN "status" varchar NOT NULL DEFAULT 'completed';
+
+ALTER TABLE "transfers" ADD COLUMN "failure_reason" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_status_check" CHECK ("status" IN ('pending', 'completed', 'failed', 'cancelled'));
+
+CREATE INDEX ON "transfers" ("status") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "transfers"."status" IS 'money only moves when a transfer is completed, pending ones await approval or a hold';
+
+COMMENT ON COLUMN "transfers"."failure_reason" IS 'why a failed or cancelled transfer did not complete';
+
+CREATE TABLE "transfer_status_changes" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint NOT NULL,
+  "from_status" varchar NOT NULL,
+  "to_status" varchar NOT NULL,
+  "reason" varchar NOT NULL DEFAULT '',
+  "changed_by" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_status_changes" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfer_status_changes" ("transfer_id", "id");
+
+COMMENT ON COLUMN "transfer_status_changes"."from_status" IS 'empty when the transfer was created';
+
+COMMENT ON COLUMN "transfer_status_changes"."changed_by" IS 'user who made the change, empty for changes made by the bank itself';
+
+-- every transfer so far was written once it had completed
+INSERT INTO "transfer_status_changes" ("transfer_id", "from_status", "to_status", "created_at")
+SELECT "id", '', 'completed', "created_at" FROM "transfers";
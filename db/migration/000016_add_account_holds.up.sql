@@ -0,0 +1,31 @@
+CREATE TABLE "account_holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "amount" numeric NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "reason" varchar NOT NULL DEFAULT '',
+  "created_by" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "account_holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_holds" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+
+ALTER TABLE "account_holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "account_holds" ADD CONSTRAINT "account_holds_amount_check" CHECK ("amount" > 0);
+
+ALTER TABLE "account_holds" ADD CONSTRAINT "account_holds_status_check" CHECK ("status" IN ('active', 'captured', 'released'));
+
+CREATE INDEX ON "account_holds" ("account_id", "id");
+
+CREATE INDEX ON "account_holds" ("account_id", "expires_at") WHERE "status" = 'active';
+
+COMMENT ON COLUMN "account_holds"."amount" IS 'reserved in the account currency';
+
+COMMENT ON COLUMN "account_holds"."status" IS 'active holds reserve their amount until they expire';
+
+COMMENT ON COLUMN "account_holds"."transfer_id" IS 'the transfer that captured the hold';
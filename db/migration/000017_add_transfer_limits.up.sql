@@ -0,0 +1,42 @@
+ALTER TABLE "users" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+ALTER TABLE "users" ADD CONSTRAINT "users_tier_check" CHECK ("tier" IN ('standard', 'premium'));
+
+COMMENT ON COLUMN "users"."tier" IS 'picks the transfer limits that apply to the user';
+
+CREATE TABLE "transfer_limits" (
+  "id" bigserial PRIMARY KEY,
+  "tier" varchar NOT NULL DEFAULT '',
+  "account_id" bigint,
+  "kind" varchar NOT NULL,
+  "currency" varchar NOT NULL DEFAULT '',
+  "amount" numeric NOT NULL DEFAULT 0,
+  "max_count" int NOT NULL DEFAULT 0,
+  "window_minutes" int NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limits_scope_check" CHECK (("tier" <> '') <> ("account_id" IS NOT NULL));
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limits_kind_check" CHECK (
+  ("kind" IN ('daily', 'monthly', 'single') AND "currency" <> '' AND "amount" > 0)
+  OR ("kind" = 'velocity' AND "max_count" > 0 AND "window_minutes" > 0)
+);
+
+CREATE INDEX ON "transfer_limits" ("tier");
+
+CREATE INDEX ON "transfer_limits" ("account_id");
+
+COMMENT ON COLUMN "transfer_limits"."tier" IS 'users of this tier are limited across all their accounts, empty for account limits';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'the account limited, null for tier limits';
+
+COMMENT ON COLUMN "transfer_limits"."currency" IS 'currency of the source accounts amount limits apply to, empty for velocity limits';
+
+COMMENT ON COLUMN "transfer_limits"."amount" IS 'most that can be debited in a single transfer, a UTC day or a UTC month';
+
+COMMENT ON COLUMN "transfer_limits"."max_count" IS 'velocity limits allow at most this many transfers every window_minutes';
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
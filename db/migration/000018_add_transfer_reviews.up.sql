@@ -0,0 +1,20 @@
+CREATE TABLE "transfer_reviews" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint UNIQUE NOT NULL,
+  "reasons" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "reviewed_by" varchar NOT NULL DEFAULT '',
+  "note" varchar NOT NULL DEFAULT '',
+  "reviewed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_reviews" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_reviews" ADD CONSTRAINT "transfer_reviews_status_check" CHECK ("status" IN ('pending', 'approved', 'rejected'));
+
+CREATE INDEX ON "transfer_reviews" ("id") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "transfer_reviews"."reasons" IS 'what the screening rules flagged, for the reviewer';
+
+COMMENT ON COLUMN "transfer_reviews"."reviewed_by" IS 'banker who approved or rejected the transfer, empty while pending';
@@ -0,0 +1,38 @@
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "actor" varchar NOT NULL DEFAULT '',
+  "ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "action" varchar NOT NULL,
+  "resource" varchar NOT NULL,
+  "before" json,
+  "after" json,
+  "prev_hash" bytea NOT NULL,
+  "hash" bytea UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "audit_events" ("actor", "id");
+
+CREATE INDEX ON "audit_events" ("resource", "id");
+
+COMMENT ON COLUMN "audit_events"."actor" IS 'user who made the change';
+
+COMMENT ON COLUMN "audit_events"."resource" IS 'what was changed, as kind/id';
+
+COMMENT ON COLUMN "audit_events"."before" IS 'state before the change, kept as written so the hash can be checked again; null for creations';
+
+COMMENT ON COLUMN "audit_events"."prev_hash" IS 'hash of the event before, empty for the first one';
+
+COMMENT ON COLUMN "audit_events"."hash" IS 'sha256 of prev_hash and the event, so changing or removing any event breaks the chain after it';
+
+-- the log is append-only
+CREATE FUNCTION "audit_events_append_only"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit events cannot be changed or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_events_append_only"
+BEFORE UPDATE OR DELETE OR TRUNCATE ON "audit_events"
+FOR EACH STATEMENT EXECUTE FUNCTION "audit_events_append_only"();
@@ -0,0 +1,25 @@
+CREATE TABLE "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "aggregate_type" varchar NOT NULL,
+  "aggregate_id" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "delivered_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "outbox_events" ("aggregate_type", "aggregate_id", "id") WHERE "delivered_at" IS NULL;
+
+CREATE INDEX ON "outbox_events" ("next_attempt_at") WHERE "delivered_at" IS NULL;
+
+COMMENT ON COLUMN "outbox_events"."aggregate_id" IS 'events of the same aggregate are delivered one at a time, in the order they were written';
+
+COMMENT ON COLUMN "outbox_events"."attempts" IS 'failed deliveries of the event';
+
+COMMENT ON COLUMN "outbox_events"."next_attempt_at" IS 'when the relay next delivers the event, later than created_at while retrying';
+
+COMMENT ON COLUMN "outbox_events"."locked_until" IS 'lease of the relay replica delivering the event';
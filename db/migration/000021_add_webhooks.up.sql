@@ -0,0 +1,54 @@
+CREATE TABLE "webhook_endpoints" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+CREATE INDEX ON "webhook_endpoints" ("owner", "id");
+
+COMMENT ON COLUMN "webhook_endpoints"."secret" IS 'key the deliveries to the endpoint are signed with';
+
+COMMENT ON COLUMN "webhook_endpoints"."event_types" IS 'events the endpoint is subscribed to';
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "endpoint_id" bigint NOT NULL,
+  "event_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" json NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "response_status" integer NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "delivered_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("endpoint_id") REFERENCES "webhook_endpoints" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "outbox_events" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD CONSTRAINT "webhook_deliveries_status_check" CHECK ("status" IN ('pending', 'succeeded', 'failed'));
+
+-- an event is delivered to each endpoint once, however often the outbox relays it
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("endpoint_id", "event_id");
+
+CREATE INDEX ON "webhook_deliveries" ("endpoint_id", "id");
+
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "webhook_deliveries"."payload" IS 'body posted to the endpoint, kept as written so every attempt signs the same bytes';
+
+COMMENT ON COLUMN "webhook_deliveries"."response_status" IS 'HTTP status the endpoint last answered with, 0 when it could not be reached';
+
+COMMENT ON COLUMN "webhook_deliveries"."next_attempt_at" IS 'when the dispatcher next posts the delivery, later than created_at while retrying';
+
+COMMENT ON COLUMN "webhook_deliveries"."locked_until" IS 'lease of the dispatcher replica posting the delivery';
@@ -0,0 +1,3 @@
+ALTER TABLE "idempotency_keys" ADD COLUMN "locked_until" timestamptz NOT NULL DEFAULT (now());
+
+COMMENT ON COLUMN "idempotency_keys"."locked_until" IS 'a request still in progress past this time is presumed lost, and a retry may take the key over';
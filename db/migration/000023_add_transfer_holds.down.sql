@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "account_holds_transfer_id_idx";
+
+COMMENT ON COLUMN "account_holds"."transfer_id" IS 'the transfer that captured the hold';
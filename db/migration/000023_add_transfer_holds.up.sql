@@ -0,0 +1,3 @@
+CREATE INDEX "account_holds_transfer_id_idx" ON "account_holds" ("transfer_id") WHERE "status" = 'active';
+
+COMMENT ON COLUMN "account_holds"."transfer_id" IS 'the transfer that captured the hold, or the pending transfer it reserves the money of while active';
@@ -11,6 +11,19 @@ INSERT INTO accounts (
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: EnsureSystemAccount :one
+INSERT INTO accounts (
+  owner,
+  balance,
+  currency,
+  kind
+) VALUES (
+  $1, 0, $2, $3
+)
+ON CONFLICT (kind, currency) WHERE kind <> 'customer'
+DO UPDATE SET kind = EXCLUDED.kind
+RETURNING *;
+
 -- name: GetAccountForUpdate :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
@@ -18,10 +31,14 @@ FOR NO KEY UPDATE;
 
 -- name: ListAccounts :many
 SELECT * FROM accounts
-WHERE owner = $1
+WHERE owner = sqlc.arg(owner)
+AND id > sqlc.arg(after_id)
 ORDER BY id
-LIMIT $2
-OFFSET $3;
+LIMIT sqlc.arg('limit');
+
+-- name: CountAccounts :one
+SELECT count(*) FROM accounts
+WHERE owner = $1;
 
 -- name: UpdateAccount :one
 UPDATE accounts
@@ -37,4 +54,12 @@ RETURNING *;
 
 -- name: DeleteAccount :exec
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ListBalanceMismatches :many
+SELECT a.id, a.currency, a.balance, COALESCE(SUM(e.amount), 0)::numeric AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+GROUP BY a.id
+HAVING a.balance <> COALESCE(SUM(e.amount), 0)
+ORDER BY a.id;
\ No newline at end of file
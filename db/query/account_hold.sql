@@ -0,0 +1,53 @@
+-- name: CreateAccountHold :one
+INSERT INTO account_holds (
+  account_id,
+  amount,
+  reason,
+  created_by,
+  expires_at,
+  transfer_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetAccountHold :one
+SELECT * FROM account_holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetAccountHoldForUpdate :one
+SELECT * FROM account_holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetTransferHoldForUpdate :one
+-- GetTransferHoldForUpdate locks the active hold reserving the money of a pending transfer
+SELECT * FROM account_holds
+WHERE transfer_id = $1
+AND status = 'active'
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListAccountHolds :many
+SELECT * FROM account_holds
+WHERE account_id = sqlc.arg(account_id)
+AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: CountAccountHolds :one
+SELECT count(*) FROM account_holds
+WHERE account_id = $1;
+
+-- name: GetHeldAmount :one
+-- GetHeldAmount sums the holds reserving money on the account at the given time
+SELECT COALESCE(SUM(amount), 0)::numeric AS amount
+FROM account_holds
+WHERE account_id = sqlc.arg(account_id)
+AND status = 'active'
+AND expires_at > sqlc.arg(now);
+
+-- name: UpdateAccountHoldStatus :one
+UPDATE account_holds
+SET status = $1, transfer_id = $2
+WHERE id = $3
+RETURNING *;
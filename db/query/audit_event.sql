@@ -0,0 +1,44 @@
+-- name: LockAuditChain :exec
+-- LockAuditChain makes other transactions wait to append to the audit log
+-- until this one ends, so each event chains onto the one committed before it
+SELECT pg_advisory_xact_lock(hashtext('audit_events'));
+
+-- name: GetLastAuditEvent :one
+SELECT * FROM audit_events
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  actor,
+  ip,
+  user_agent,
+  action,
+  resource,
+  before,
+  after,
+  prev_hash,
+  hash,
+  created_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg(actor)::varchar IS NULL OR actor = sqlc.narg(actor))
+AND (sqlc.narg(action)::varchar IS NULL OR action = sqlc.narg(action))
+AND (sqlc.narg(resource)::varchar IS NULL OR resource = sqlc.narg(resource))
+AND (sqlc.narg(from_date)::timestamptz IS NULL OR created_at >= sqlc.narg(from_date))
+AND (sqlc.narg(to_date)::timestamptz IS NULL OR created_at < sqlc.narg(to_date))
+AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: CountAuditEvents :one
+SELECT count(*) FROM audit_events
+WHERE (sqlc.narg(actor)::varchar IS NULL OR actor = sqlc.narg(actor))
+AND (sqlc.narg(action)::varchar IS NULL OR action = sqlc.narg(action))
+AND (sqlc.narg(resource)::varchar IS NULL OR resource = sqlc.narg(resource))
+AND (sqlc.narg(from_date)::timestamptz IS NULL OR created_at >= sqlc.narg(from_date))
+AND (sqlc.narg(to_date)::timestamptz IS NULL OR created_at < sqlc.narg(to_date));
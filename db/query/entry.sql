@@ -1,9 +1,11 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  type,
+  transfer_id
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4
 ) RETURNING *;
 
 -- name: GetEntry :one
@@ -12,7 +14,55 @@ WHERE id = $1 LIMIT 1;
 
 -- name: ListEntries :many
 SELECT * FROM entries
-WHERE account_id = $1
+WHERE account_id = sqlc.arg(account_id)
+AND id > sqlc.arg(after_id)
 ORDER BY id
-LIMIT $2
-OFFSET $3;
+LIMIT sqlc.arg('limit');
+
+-- name: ListEntriesWithBalance :many
+SELECT id, account_id, amount, created_at, type, transfer_id, balance
+FROM (
+  SELECT *, SUM(amount) OVER (ORDER BY id)::numeric AS balance
+  FROM entries
+  WHERE account_id = sqlc.arg(account_id)
+) AS e
+WHERE (sqlc.narg(from_date)::timestamptz IS NULL OR created_at >= sqlc.narg(from_date))
+AND (sqlc.narg(to_date)::timestamptz IS NULL OR created_at < sqlc.narg(to_date))
+AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: CountEntries :one
+SELECT count(*) FROM entries
+WHERE account_id = sqlc.arg(account_id)
+AND (sqlc.narg(from_date)::timestamptz IS NULL OR created_at >= sqlc.narg(from_date))
+AND (sqlc.narg(to_date)::timestamptz IS NULL OR created_at < sqlc.narg(to_date));
+
+-- name: GetEntriesBalance :one
+SELECT
+  COALESCE(SUM(amount) FILTER (WHERE created_at < sqlc.narg(from_date)::timestamptz), 0)::numeric AS opening_balance,
+  COALESCE(SUM(amount) FILTER (WHERE sqlc.narg(to_date)::timestamptz IS NULL OR created_at < sqlc.narg(to_date)), 0)::numeric AS closing_balance
+FROM entries
+WHERE account_id = sqlc.arg(account_id);
+
+-- name: ListLedgerImbalances :many
+SELECT a.currency, SUM(e.amount)::numeric AS total
+FROM entries e
+JOIN accounts a ON a.id = e.account_id
+GROUP BY a.currency
+HAVING SUM(e.amount) <> 0
+ORDER BY a.currency;
+
+-- name: ListOrphanedEntries :many
+SELECT e.* FROM entries e
+JOIN accounts a ON a.id = e.account_id
+LEFT JOIN transfers t ON t.id = e.transfer_id
+WHERE (
+  e.type IN ('transfer', 'fee') AND (
+    t.id IS NULL
+    OR t.status <> 'completed'
+    OR (a.kind = 'customer' AND e.account_id NOT IN (t.from_account_id, t.to_account_id))
+  )
+)
+OR (e.type NOT IN ('transfer', 'fee') AND e.transfer_id IS NOT NULL)
+ORDER BY e.id;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- name: CreateExchangeRate :one
+INSERT INTO exchange_rates (
+  currency,
+  rate,
+  effective_from
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetExchangeRate :one
+SELECT * FROM exchange_rates
+WHERE currency = sqlc.arg(currency)
+  AND effective_from <= sqlc.arg(at)
+ORDER BY effective_from DESC, id DESC
+LIMIT 1;
+
+-- name: ListExchangeRates :many
+SELECT * FROM exchange_rates
+WHERE currency = $1
+ORDER BY effective_from DESC, id DESC
+LIMIT $2
+OFFSET $3;
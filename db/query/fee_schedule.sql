@@ -0,0 +1,21 @@
+-- name: CreateFeeSchedule :one
+INSERT INTO fee_schedules (
+  currency,
+  kind,
+  flat_amount,
+  rate,
+  min_amount,
+  max_amount
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListFeeSchedules :many
+SELECT * FROM fee_schedules
+WHERE sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency)
+ORDER BY id;
+
+-- name: DeleteFeeSchedule :one
+DELETE FROM fee_schedules
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,33 @@
+-- name: CreateIdempotencyKey :one
+-- CreateIdempotencyKey claims the key for a request. A key already in use is
+-- only taken over when its first request never completed and its lease ran out.
+INSERT INTO idempotency_keys (
+  username,
+  key,
+  request_hash,
+  locked_until
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (username, key) DO UPDATE
+SET locked_until = EXCLUDED.locked_until
+WHERE idempotency_keys.response_status = 0
+  AND idempotency_keys.request_hash = EXCLUDED.request_hash
+  AND idempotency_keys.locked_until < now()
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE username = $1 AND key = $2 LIMIT 1;
+
+-- name: CompleteIdempotencyKey :one
+UPDATE idempotency_keys
+SET
+  response_status = sqlc.arg(response_status),
+  response_body = sqlc.arg(response_body)
+WHERE username = sqlc.arg(username) AND key = sqlc.arg(key)
+RETURNING *;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE username = $1 AND key = $2;
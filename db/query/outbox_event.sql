@@ -0,0 +1,54 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+  aggregate_type,
+  aggregate_id,
+  event_type,
+  payload
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ClaimOutboxEvents :many
+-- Leases the oldest undelivered event of each aggregate that is due; later
+-- events of an aggregate wait until the ones before them are delivered, and
+-- rows another replica is claiming are skipped rather than waited for
+UPDATE outbox_events
+SET locked_until = sqlc.arg(locked_until)
+WHERE id IN (
+  SELECT e.id FROM outbox_events e
+  WHERE e.delivered_at IS NULL
+  AND e.next_attempt_at <= sqlc.arg(now)
+  AND (e.locked_until IS NULL OR e.locked_until <= sqlc.arg(now))
+  AND NOT EXISTS (
+    SELECT 1 FROM outbox_events earlier
+    WHERE earlier.aggregate_type = e.aggregate_type
+    AND earlier.aggregate_id = e.aggregate_id
+    AND earlier.delivered_at IS NULL
+    AND earlier.id < e.id
+  )
+  ORDER BY e.id
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventDelivered :one
+UPDATE outbox_events
+SET delivered_at = now(), locked_until = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: RetryOutboxEvent :one
+UPDATE outbox_events
+SET
+  attempts = attempts + 1,
+  last_error = sqlc.arg(last_error),
+  next_attempt_at = sqlc.arg(next_attempt_at),
+  locked_until = NULL
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ListOutboxEventsByAggregate :many
+SELECT * FROM outbox_events
+WHERE aggregate_type = $1 AND aggregate_id = $2
+ORDER BY id;
@@ -0,0 +1,23 @@
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (
+  id,
+  username,
+  expires_at
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: IsTokenRevoked :one
+SELECT EXISTS (
+  SELECT 1 FROM revoked_tokens
+  WHERE id = sqlc.arg(id)
+) OR EXISTS (
+  SELECT 1 FROM users
+  WHERE username = sqlc.arg(username)
+    AND GREATEST(tokens_valid_after, password_changed_at) > sqlc.arg(issued_at)
+) AS revoked;
+
+-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM revoked_tokens
+WHERE expires_at < now();
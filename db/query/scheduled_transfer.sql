@@ -0,0 +1,68 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+  owner,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  frequency,
+  day_of_month,
+  due_at,
+  next_run_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $8
+) RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetScheduledTransferForUpdate :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE owner = sqlc.arg(owner)
+AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: CountScheduledTransfers :one
+SELECT count(*) FROM scheduled_transfers
+WHERE owner = $1;
+
+-- name: ClaimDueScheduledTransfers :many
+-- Leases due schedules to the caller; rows another replica is claiming are
+-- skipped rather than waited for, and leased rows are not claimed again until
+-- the lease runs out
+UPDATE scheduled_transfers
+SET locked_until = sqlc.arg(locked_until)
+WHERE id IN (
+  SELECT id FROM scheduled_transfers
+  WHERE status = 'active'
+  AND next_run_at <= sqlc.arg(now)
+  AND (locked_until IS NULL OR locked_until <= sqlc.arg(now))
+  ORDER BY next_run_at
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateScheduledTransferState :one
+UPDATE scheduled_transfers
+SET
+  status = sqlc.arg(status),
+  due_at = sqlc.arg(due_at),
+  next_run_at = sqlc.arg(next_run_at),
+  attempts = sqlc.arg(attempts),
+  locked_until = NULL
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CancelScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'cancelled', locked_until = NULL
+WHERE id = $1 AND status = 'active'
+RETURNING *;
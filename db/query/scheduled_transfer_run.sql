@@ -0,0 +1,22 @@
+-- name: CreateScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (
+  scheduled_transfer_id,
+  due_at,
+  attempt,
+  status,
+  transfer_id,
+  error
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListScheduledTransferRuns :many
+SELECT * FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = sqlc.arg(scheduled_transfer_id)
+AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: CountScheduledTransferRuns :one
+SELECT count(*) FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1;
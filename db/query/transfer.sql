@@ -3,20 +3,133 @@ INSERT INTO transfers (
   from_account_id,
   to_account_id,
   amount,
-  currency
+  currency,
+  from_rate,
+  from_amount,
+  to_rate,
+  to_amount,
+  fee,
+  status
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 ) RETURNING *;
 
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: ListTransfers :many
 SELECT * FROM transfers
-WHERE 
-    from_account_id = $1 OR
-    to_account_id = $2
+WHERE
+  (
+    (sqlc.arg(direction)::varchar IN ('', 'sent') AND from_account_id IN (
+      SELECT id FROM accounts
+      WHERE (sqlc.narg(owner)::varchar IS NULL OR owner = sqlc.narg(owner))
+        AND (sqlc.narg(account_id)::bigint IS NULL OR id = sqlc.narg(account_id))
+    ))
+    OR
+    (sqlc.arg(direction) IN ('', 'received') AND to_account_id IN (
+      SELECT id FROM accounts
+      WHERE (sqlc.narg(owner)::varchar IS NULL OR owner = sqlc.narg(owner))
+        AND (sqlc.narg(account_id)::bigint IS NULL OR id = sqlc.narg(account_id))
+    ))
+  )
+  AND (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency))
+  AND (sqlc.narg(from_date)::timestamptz IS NULL OR created_at >= sqlc.narg(from_date))
+  AND (sqlc.narg(to_date)::timestamptz IS NULL OR created_at < sqlc.narg(to_date))
+  AND id > sqlc.arg(after_id)
 ORDER BY id
-LIMIT $3
-OFFSET $4;
+LIMIT sqlc.arg('limit');
+
+-- name: CountTransfers :one
+SELECT count(*) FROM transfers
+WHERE
+  (
+    (sqlc.arg(direction)::varchar IN ('', 'sent') AND from_account_id IN (
+      SELECT id FROM accounts
+      WHERE (sqlc.narg(owner)::varchar IS NULL OR owner = sqlc.narg(owner))
+        AND (sqlc.narg(account_id)::bigint IS NULL OR id = sqlc.narg(account_id))
+    ))
+    OR
+    (sqlc.arg(direction) IN ('', 'received') AND to_account_id IN (
+      SELECT id FROM accounts
+      WHERE (sqlc.narg(owner)::varchar IS NULL OR owner = sqlc.narg(owner))
+        AND (sqlc.narg(account_id)::bigint IS NULL OR id = sqlc.narg(account_id))
+    ))
+  )
+  AND (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency))
+  AND (sqlc.narg(from_date)::timestamptz IS NULL OR created_at >= sqlc.narg(from_date))
+  AND (sqlc.narg(to_date)::timestamptz IS NULL OR created_at < sqlc.narg(to_date));
+
+-- name: ListUnpairedTransfers :many
+SELECT * FROM transfers t
+WHERE t.status = 'completed' AND (
+  NOT EXISTS (
+    SELECT 1 FROM entries e
+    WHERE e.transfer_id = t.id AND e.account_id = t.from_account_id AND e.amount = -t.from_amount
+  )
+  OR NOT EXISTS (
+    SELECT 1 FROM entries e
+    WHERE e.transfer_id = t.id AND e.account_id = t.to_account_id AND e.amount = t.to_amount
+  )
+)
+ORDER BY t.id;
+
+-- name: UpdateTransferStatus :one
+UPDATE transfers
+SET status = sqlc.arg(status), failure_reason = sqlc.arg(failure_reason)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+-- name: GetOutgoingTransferTotals :one
+-- GetOutgoingTransferTotals adds up what the owner's transfers debited since
+-- the given time, from one account or currency when set. Failed, cancelled and
+-- reversing transfers do not count.
+SELECT
+  COALESCE(SUM(t.from_amount), 0)::numeric AS amount,
+  count(*) AS count
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = sqlc.arg(owner)
+AND (sqlc.narg(account_id)::bigint IS NULL OR t.from_account_id = sqlc.narg(account_id))
+AND (sqlc.narg(currency)::varchar IS NULL OR a.currency = sqlc.narg(currency))
+AND t.created_at >= sqlc.arg(since)
+AND t.status IN ('pending', 'completed')
+AND NOT EXISTS (
+  SELECT 1 FROM transfer_reversals r
+  WHERE r.reversal_transfer_id = t.id
+);
+
+-- name: CountPayeeTransfers :one
+-- CountPayeeTransfers counts the completed transfers the owner has made to the account
+SELECT count(*) FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = sqlc.arg(owner)
+AND t.to_account_id = sqlc.arg(to_account_id)
+AND t.status = 'completed';
+
+-- name: GetTransferAmountStats :one
+-- GetTransferAmountStats sums up the completed transfers made from the account
+-- in the currency, for telling usual amounts from unusual ones
+SELECT
+  count(*) AS count,
+  COALESCE(AVG(amount), 0)::numeric AS average
+FROM transfers
+WHERE from_account_id = sqlc.arg(from_account_id)
+AND currency = sqlc.arg(currency)
+AND status = 'completed';
+
+-- name: CountCrossCurrencyTransfers :one
+-- CountCrossCurrencyTransfers counts the owner's transfers since the given time
+-- that moved money between accounts of different currencies
+SELECT count(*) FROM transfers t
+JOIN accounts f ON f.id = t.from_account_id
+JOIN accounts r ON r.id = t.to_account_id
+WHERE f.owner = sqlc.arg(owner)
+AND f.currency <> r.currency
+AND t.created_at >= sqlc.arg(since)
+AND t.status IN ('pending', 'completed');
@@ -0,0 +1,28 @@
+-- name: CreateTransferLimit :one
+INSERT INTO transfer_limits (
+  tier,
+  account_id,
+  kind,
+  currency,
+  amount,
+  max_count,
+  window_minutes
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: ListTransferLimits :many
+SELECT * FROM transfer_limits
+WHERE (sqlc.narg(tier)::varchar IS NULL OR tier = sqlc.narg(tier))
+AND (sqlc.narg(account_id)::bigint IS NULL OR account_id = sqlc.narg(account_id))
+ORDER BY id;
+
+-- name: ListApplicableTransferLimits :many
+SELECT * FROM transfer_limits
+WHERE tier = sqlc.arg(tier) OR account_id = sqlc.arg(account_id)
+ORDER BY id;
+
+-- name: DeleteTransferLimit :one
+DELETE FROM transfer_limits
+WHERE id = $1
+RETURNING *;
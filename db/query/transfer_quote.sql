@@ -0,0 +1,32 @@
+-- name: CreateTransferQuote :one
+INSERT INTO transfer_quotes (
+  id,
+  username,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  from_rate,
+  from_amount,
+  to_rate,
+  to_amount,
+  fee,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING *;
+
+-- name: GetTransferQuote :one
+SELECT * FROM transfer_quotes
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferQuoteForUpdate :one
+SELECT * FROM transfer_quotes
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: UseTransferQuote :one
+UPDATE transfer_quotes
+SET transfer_id = sqlc.arg(transfer_id)
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,29 @@
+-- name: CreateTransferReversal :one
+INSERT INTO transfer_reversals (
+  transfer_id,
+  reversal_transfer_id,
+  initiated_by,
+  reason
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListTransferReversals :many
+SELECT * FROM transfer_reversals
+WHERE transfer_id = $1
+ORDER BY id;
+
+-- name: GetTransferReversalTotals :one
+SELECT
+  COALESCE(SUM(t.amount), 0)::numeric AS amount,
+  COALESCE(SUM(t.from_amount), 0)::numeric AS from_amount,
+  COALESCE(SUM(t.to_amount), 0)::numeric AS to_amount
+FROM transfer_reversals r
+JOIN transfers t ON t.id = r.reversal_transfer_id
+WHERE r.transfer_id = $1;
+
+-- name: IsTransferReversal :one
+SELECT EXISTS (
+  SELECT 1 FROM transfer_reversals
+  WHERE reversal_transfer_id = $1
+);
@@ -0,0 +1,35 @@
+-- name: CreateTransferReview :one
+INSERT INTO transfer_reviews (
+  transfer_id,
+  reasons
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetTransferReviewForUpdate :one
+SELECT * FROM transfer_reviews
+WHERE transfer_id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListPendingTransferReviews :many
+-- ListPendingTransferReviews lists the reviews still awaiting a banker, leaving
+-- out those whose transfer was cancelled meanwhile
+SELECT r.* FROM transfer_reviews r
+JOIN transfers t ON t.id = r.transfer_id
+WHERE r.status = 'pending'
+AND t.status = 'pending'
+AND r.id > sqlc.arg(after_id)
+ORDER BY r.id
+LIMIT sqlc.arg('limit');
+
+-- name: CountPendingTransferReviews :one
+SELECT count(*) FROM transfer_reviews r
+JOIN transfers t ON t.id = r.transfer_id
+WHERE r.status = 'pending'
+AND t.status = 'pending';
+
+-- name: CloseTransferReview :one
+UPDATE transfer_reviews
+SET status = $1, reviewed_by = $2, note = $3, reviewed_at = now()
+WHERE id = $4
+RETURNING *;
@@ -0,0 +1,15 @@
+-- name: CreateTransferStatusChange :one
+INSERT INTO transfer_status_changes (
+  transfer_id,
+  from_status,
+  to_status,
+  reason,
+  changed_by
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListTransferStatusChanges :many
+SELECT * FROM transfer_status_changes
+WHERE transfer_id = $1
+ORDER BY id;
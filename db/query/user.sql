@@ -12,6 +12,11 @@ INSERT INTO users (
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- -- name: UpdateUser :one
 -- UPDATE users
 -- SET
@@ -22,4 +27,21 @@ WHERE username = $1 LIMIT 1;
 --   is_email_verified = COALESCE(sqlc.narg(is_email_verified), is_email_verified)
 -- WHERE
 --   username = sqlc.arg(username)
--- RETURNING *;
\ No newline at end of file
+-- RETURNING *;
+-- name: RevokeUserTokens :one
+UPDATE users
+SET tokens_valid_after = sqlc.arg(tokens_valid_after)
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = sqlc.arg(role)
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: UpdateUserTier :one
+UPDATE users
+SET tier = sqlc.arg(tier)
+WHERE username = sqlc.arg(username)
+RETURNING *;
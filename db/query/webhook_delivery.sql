@@ -0,0 +1,59 @@
+-- name: CreateWebhookDeliveries :many
+-- Queues the event for every active endpoint of owner subscribed to it;
+-- endpoints the event was already queued for are skipped
+INSERT INTO webhook_deliveries (
+  endpoint_id,
+  event_id,
+  event_type,
+  payload
+)
+SELECT id, sqlc.arg(event_id)::bigint, sqlc.arg(event_type)::varchar, sqlc.arg(payload)::json
+FROM webhook_endpoints
+WHERE owner = sqlc.arg(owner)
+AND active
+AND sqlc.arg(event_type)::varchar = ANY(event_types)
+ON CONFLICT (endpoint_id, event_id) DO NOTHING
+RETURNING *;
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Leases due deliveries to the caller; rows another replica is claiming are
+-- skipped rather than waited for, and leased rows are not claimed again until
+-- the lease runs out
+UPDATE webhook_deliveries
+SET locked_until = sqlc.arg(locked_until)
+WHERE id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE status = 'pending'
+  AND next_attempt_at <= sqlc.arg(now)
+  AND (locked_until IS NULL OR locked_until <= sqlc.arg(now))
+  ORDER BY next_attempt_at
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: RecordWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET
+  status = sqlc.arg(status),
+  attempts = attempts + 1,
+  response_status = sqlc.arg(response_status),
+  last_error = sqlc.arg(last_error),
+  next_attempt_at = sqlc.arg(next_attempt_at),
+  delivered_at = sqlc.narg(delivered_at),
+  locked_until = NULL
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = sqlc.arg(endpoint_id)
+AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: CountWebhookDeliveries :one
+SELECT count(*) FROM webhook_deliveries
+WHERE endpoint_id = sqlc.arg(endpoint_id)
+AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status));
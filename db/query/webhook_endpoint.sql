@@ -0,0 +1,30 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+  owner,
+  url,
+  secret,
+  event_types
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE owner = sqlc.arg(owner)
+AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: CountWebhookEndpoints :one
+SELECT count(*) FROM webhook_endpoints
+WHERE owner = $1;
+
+-- name: DisableWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET active = false
+WHERE id = $1 AND active
+RETURNING *;
@@ -7,18 +7,20 @@ package db
 
 import (
 	"context"
+
+	"github.com/shopspring/decimal"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, kind
 `
 
 type AddAccountBalanceParams struct {
-	Amount float64 `json:"amount"`
-	ID     int64   `json:"id"`
+	Amount decimal.Decimal `json:"amount"`
+	ID     int64           `json:"id"`
 }
 
 func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Accounts, error) {
@@ -30,10 +32,23 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Kind,
 	)
 	return i, err
 }
 
+const countAccounts = `-- name: CountAccounts :one
+SELECT count(*) FROM accounts
+WHERE owner = $1
+`
+
+func (q *Queries) CountAccounts(ctx context.Context, owner string) (int64, error) {
+	row := q.db.QueryRow(ctx, countAccounts, owner)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
   owner,
@@ -41,13 +56,13 @@ INSERT INTO accounts (
   currency
 ) VALUES (
   $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+) RETURNING id, owner, balance, currency, created_at, kind
 `
 
 type CreateAccountParams struct {
-	Owner    string  `json:"owner"`
-	Balance  float64 `json:"balance"`
-	Currency string  `json:"currency"`
+	Owner    string          `json:"owner"`
+	Balance  decimal.Decimal `json:"balance"`
+	Currency string          `json:"currency"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Accounts, error) {
@@ -59,6 +74,7 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Kind,
 	)
 	return i, err
 }
@@ -73,8 +89,42 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 	return err
 }
 
+const ensureSystemAccount = `-- name: EnsureSystemAccount :one
+INSERT INTO accounts (
+  owner,
+  balance,
+  currency,
+  kind
+) VALUES (
+  $1, 0, $2, $3
+)
+ON CONFLICT (kind, currency) WHERE kind <> 'customer'
+DO UPDATE SET kind = EXCLUDED.kind
+RETURNING id, owner, balance, currency, created_at, kind
+`
+
+type EnsureSystemAccountParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+	Kind     string `json:"kind"`
+}
+
+func (q *Queries) EnsureSystemAccount(ctx context.Context, arg EnsureSystemAccountParams) (Accounts, error) {
+	row := q.db.QueryRow(ctx, ensureSystemAccount, arg.Owner, arg.Currency, arg.Kind)
+	var i Accounts
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Kind,
+	)
+	return i, err
+}
+
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, kind FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -87,12 +137,13 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Accounts, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Kind,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, kind FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -106,26 +157,27 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Accounts,
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Kind,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, kind FROM accounts
 WHERE owner = $1
+AND id > $2
 ORDER BY id
-LIMIT $2
-OFFSET $3
+LIMIT $3
 `
 
 type ListAccountsParams struct {
-	Owner  string `json:"owner"`
-	Limit  int32  `json:"limit"`
-	Offset int32  `json:"offset"`
+	Owner   string `json:"owner"`
+	AfterID int64  `json:"after_id"`
+	Limit   int32  `json:"limit"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Accounts, error) {
-	rows, err := q.db.Query(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listAccounts, arg.Owner, arg.AfterID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +191,48 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Kind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBalanceMismatches = `-- name: ListBalanceMismatches :many
+SELECT a.id, a.currency, a.balance, COALESCE(SUM(e.amount), 0)::numeric AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+GROUP BY a.id
+HAVING a.balance <> COALESCE(SUM(e.amount), 0)
+ORDER BY a.id
+`
+
+type ListBalanceMismatchesRow struct {
+	ID           int64           `json:"id"`
+	Currency     string          `json:"currency"`
+	Balance      decimal.Decimal `json:"balance"`
+	EntriesTotal decimal.Decimal `json:"entries_total"`
+}
+
+func (q *Queries) ListBalanceMismatches(ctx context.Context) ([]ListBalanceMismatchesRow, error) {
+	rows, err := q.db.Query(ctx, listBalanceMismatches)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBalanceMismatchesRow{}
+	for rows.Next() {
+		var i ListBalanceMismatchesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Currency,
+			&i.Balance,
+			&i.EntriesTotal,
 		); err != nil {
 			return nil, err
 		}
@@ -154,12 +248,12 @@ const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, kind
 `
 
 type UpdateAccountParams struct {
-	ID      int64   `json:"id"`
-	Balance float64 `json:"balance"`
+	ID      int64           `json:"id"`
+	Balance decimal.Decimal `json:"balance"`
 }
 
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Accounts, error) {
@@ -171,6 +265,7 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Kind,
 	)
 	return i, err
 }
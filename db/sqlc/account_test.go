@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,7 +27,7 @@ func createRandomAccount(t *testing.T) Accounts {
 	require.NotEmpty(t, account)
 
 	require.Equal(t, account.Owner, arg.Owner)
-	require.Equal(t, account.Balance, arg.Balance)
+	require.True(t, account.Balance.Equal(arg.Balance))
 	require.Equal(t, account.Currency, arg.Currency)
 
 	require.NotZero(t, account.ID)
@@ -44,7 +45,7 @@ func TestGetAccount(t *testing.T) {
 
 	require.NoError(t, err)
 
-	require.Equal(t, accountFound.Balance, account.Balance)
+	require.True(t, accountFound.Balance.Equal(account.Balance))
 	require.Equal(t, accountFound.Currency, account.Currency)
 	require.Equal(t, accountFound.Owner, account.Owner)
 
@@ -56,7 +57,7 @@ func TestUpdateAccount(t *testing.T) {
 
 	arg := UpdateAccountParams{
 		ID:      account.ID,
-		Balance: account.Balance + util.RandomBalance(account.Currency),
+		Balance: account.Balance.Add(util.RandomBalance(account.Currency)),
 	}
 
 	updatedAccount, err := testQueries.UpdateAccount(context.Background(), arg)
@@ -66,7 +67,7 @@ func TestUpdateAccount(t *testing.T) {
 	require.NotEmpty(t, updatedAccount)
 
 	require.Equal(t, updatedAccount.ID, account.ID)
-	require.Equal(t, updatedAccount.Balance, arg.Balance)
+	require.True(t, updatedAccount.Balance.Equal(arg.Balance))
 	require.Equal(t, updatedAccount.Currency, account.Currency)
 	require.Equal(t, updatedAccount.Owner, account.Owner)
 }
@@ -92,9 +93,8 @@ func TestListAccounts(t *testing.T) {
 	}
 
 	arg := ListAccountsParams{
-		Owner:  lastAccount.Owner,
-		Limit:  5,
-		Offset: 0,
+		Owner: lastAccount.Owner,
+		Limit: 5,
 	}
 
 	accounts, err := testQueries.ListAccounts(context.Background(), arg)
@@ -106,6 +106,19 @@ func TestListAccounts(t *testing.T) {
 		require.NotEmpty(t, account)
 		require.Equal(t, lastAccount.Owner, account.Owner)
 	}
+
+	// the next page starts after the last account of this one
+	arg.AfterID = accounts[len(accounts)-1].ID
+	next, err := testQueries.ListAccounts(context.Background(), arg)
+	require.NoError(t, err)
+
+	for _, account := range next {
+		require.Greater(t, account.ID, arg.AfterID)
+	}
+
+	count, err := testQueries.CountAccounts(context.Background(), lastAccount.Owner)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(accounts)+len(next)), count)
 }
 
 func TestUpdateAccountEdgeCases(t *testing.T) {
@@ -114,11 +127,11 @@ func TestUpdateAccountEdgeCases(t *testing.T) {
 	// Test updating with zero balance
 	arg := UpdateAccountParams{
 		ID:      account.ID,
-		Balance: 0.00,
+		Balance: decimal.Zero,
 	}
 
 	updatedAccount, err := testQueries.UpdateAccount(context.Background(), arg)
 
 	require.NoError(t, err)
-	require.Equal(t, updatedAccount.Balance, 0.00)
+	require.True(t, updatedAccount.Balance.IsZero())
 }
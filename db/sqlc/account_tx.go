@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+type AccountTxRequest struct {
+	AccountID int64           `json:"account_id"`
+	Amount    decimal.Decimal `json:"amount"`
+	// Audit, when set, records the movement in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+type AccountTxResponse struct {
+	Account Accounts `json:"account"`
+	Entry   Entries  `json:"entry"`
+}
+
+// DepositTx credits amount to the account against the bank's cash account and
+// records it as a deposit
+func (store *SQLStore) DepositTx(ctx context.Context, arg AccountTxRequest) (AccountTxResponse, error) {
+	return store.postEntryTx(ctx, arg, arg.Amount, util.DepositEntry, util.AuditDeposit)
+}
+
+// WithdrawTx debits amount from the account against the bank's cash account and
+// records it as a withdrawal, failing with ErrInsufficientFunds if the available
+// balance, net of active holds, does not cover it
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg AccountTxRequest) (AccountTxResponse, error) {
+	return store.postEntryTx(ctx, arg, arg.Amount.Neg(), util.WithdrawalEntry, util.AuditWithdrawal)
+}
+
+// postEntryTx locks the account and posts amount to it, with the opposite side
+// on the cash account of its currency, within a single transaction
+func (store *SQLStore) postEntryTx(ctx context.Context, arg AccountTxRequest, amount decimal.Decimal, entryType string, action string) (AccountTxResponse, error) {
+	var response AccountTxResponse
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkPrecision(amount, account.Currency); err != nil {
+			return err
+		}
+
+		if amount.IsNegative() {
+			available, err := availableBalance(ctx, q, account, time.Now())
+			if err != nil {
+				return err
+			}
+
+			if available.Add(amount).IsNegative() {
+				return ErrInsufficientFunds
+			}
+		}
+
+		cash := systemAccountKey{Kind: util.CashAccount, Currency: account.Currency}
+		system, err := lockSystemAccounts(ctx, q, cash)
+		if err != nil {
+			return err
+		}
+
+		entries, accounts, err := postJournal(ctx, q, pgtype.Int8{}, []journalLine{
+			{Account: account, Amount: amount, Type: entryType},
+			{Account: system[cash], Amount: amount.Neg(), Type: entryType},
+		})
+		if err != nil {
+			return err
+		}
+
+		response.Entry = entries[0]
+		response.Account = accounts[account.ID]
+
+		resource := fmt.Sprintf("account/%d", account.ID)
+		return recordAudit(ctx, q, arg.Audit, action, resource, account, response)
+	})
+
+	return response, err
+}
+
+type CreateAccountTxRequest struct {
+	CreateAccountParams
+	// Audit, when set, records the account being opened in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// CreateAccountTx opens an account
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxRequest) (Accounts, error) {
+	var account Accounts
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		account, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		if err != nil {
+			return err
+		}
+
+		if err := publishAccountEvent(ctx, q, account, util.EventAccountCreated, AccountEvent{Account: account}); err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("account/%d", account.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditAccountCreate, resource, nil, account)
+	})
+
+	return account, err
+}
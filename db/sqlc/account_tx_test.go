@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepositTx(t *testing.T) {
+	store := NewStore(pool)
+
+	account := createRandomAccount(t)
+	amount := decimal.NewFromInt(10)
+
+	// run concurrent deposits
+	n := 5
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.DepositTx(context.Background(), AccountTxRequest{
+				AccountID: account.ID,
+				Amount:    amount,
+			})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	updatedAccount, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.True(t, account.Balance.Add(amount.Mul(decimal.NewFromInt(int64(n)))).Equal(updatedAccount.Balance))
+
+	entries, err := store.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: account.ID,
+		Limit:     int32(n + 1),
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, n)
+
+	for _, entry := range entries {
+		require.Equal(t, util.DepositEntry, entry.Type)
+		require.True(t, amount.Equal(entry.Amount))
+		require.False(t, entry.TransferID.Valid)
+	}
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := NewStore(pool)
+
+	account := createRandomAccount(t)
+	amount := account.Balance.Div(decimal.NewFromInt(2)).Floor()
+
+	response, err := store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.ID, response.Account.ID)
+	require.True(t, account.Balance.Sub(amount).Equal(response.Account.Balance))
+	require.Equal(t, util.WithdrawalEntry, response.Entry.Type)
+	require.True(t, amount.Neg().Equal(response.Entry.Amount))
+
+	// withdrawing more than what is left fails and changes nothing
+	_, err = store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    response.Account.Balance.Add(decimal.NewFromInt(1)),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.True(t, response.Account.Balance.Equal(updatedAccount.Balance))
+
+	entries, err := store.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: account.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAuditChainBroken is returned when an audit event no longer matches its hash,
+// or no longer follows the event it was chained to
+var ErrAuditChainBroken = errors.New("audit chain is broken")
+
+// AuditContext is who makes a change through the API, recorded with it in the audit log
+type AuditContext struct {
+	Actor     string `json:"actor"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+// recordAudit appends an event to the audit log within the caller's transaction,
+// so it is only kept if the change is. Nothing is recorded without an audit
+// context, as for the changes the bank makes on its own.
+func recordAudit(ctx context.Context, q *Queries, audit *AuditContext, action string, resource string, before any, after any) error {
+	if audit == nil {
+		return nil
+	}
+
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	if err := q.LockAuditChain(ctx); err != nil {
+		return err
+	}
+
+	prevHash := []byte{}
+	last, err := q.GetLastAuditEvent(ctx)
+	if err == nil {
+		prevHash = last.Hash
+	} else if !errors.Is(err, ErrRecordNotFound) {
+		return err
+	}
+
+	event := AuditEvents{
+		Actor:     audit.Actor,
+		Ip:        audit.IP,
+		UserAgent: audit.UserAgent,
+		Action:    action,
+		Resource:  resource,
+		Before:    beforeJSON,
+		After:     afterJSON,
+		PrevHash:  prevHash,
+		// stored to the microsecond, so the hash is taken of what is read back
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	_, err = q.CreateAuditEvent(ctx, CreateAuditEventParams{
+		Actor:     event.Actor,
+		Ip:        event.Ip,
+		UserAgent: event.UserAgent,
+		Action:    event.Action,
+		Resource:  event.Resource,
+		Before:    event.Before,
+		After:     event.After,
+		PrevHash:  event.PrevHash,
+		Hash:      auditHash(event),
+		CreatedAt: event.CreatedAt,
+	})
+	return err
+}
+
+// auditJSON encodes the state kept in an audit event, nil when there is none
+func auditJSON(state any) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding audit state: %w", err)
+	}
+	return data, nil
+}
+
+// auditHash chains the event onto its previous one by hashing them together.
+// Each field is prefixed with its length so no two events hash the same input.
+func auditHash(event AuditEvents) []byte {
+	fields := [][]byte{
+		event.PrevHash,
+		[]byte(event.Actor),
+		[]byte(event.Ip),
+		[]byte(event.UserAgent),
+		[]byte(event.Action),
+		[]byte(event.Resource),
+		event.Before,
+		event.After,
+		[]byte(event.CreatedAt.UTC().Format(time.RFC3339Nano)),
+	}
+
+	hash := sha256.New()
+	for _, field := range fields {
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(field)))
+		hash.Write(size[:])
+		hash.Write(field)
+	}
+	return hash.Sum(nil)
+}
+
+// auditBatchSize is how many events VerifyAuditChain reads at once
+const auditBatchSize = 500
+
+type AuditChainReport struct {
+	// Events verified, from the first one
+	Events int64 `json:"events"`
+	// LastHash is the hash of the latest event. Kept somewhere else, it shows
+	// whether events were removed from the end of the log since.
+	LastHash []byte `json:"last_hash"`
+}
+
+// VerifyAuditChain hashes the audit log again from its first event, failing
+// with ErrAuditChainBroken at the first event that was changed or removed
+func (store *SQLStore) VerifyAuditChain(ctx context.Context) (AuditChainReport, error) {
+	report := AuditChainReport{LastHash: []byte{}}
+	var afterID int64
+
+	for {
+		events, err := store.ListAuditEvents(ctx, ListAuditEventsParams{
+			AfterID: afterID,
+			Limit:   auditBatchSize,
+		})
+		if err != nil {
+			return report, err
+		}
+
+		for _, event := range events {
+			if !bytes.Equal(event.PrevHash, report.LastHash) || !bytes.Equal(event.Hash, auditHash(event)) {
+				return report, fmt.Errorf("%w at event %d", ErrAuditChainBroken, event.ID)
+			}
+
+			report.Events++
+			report.LastHash = event.Hash
+			afterID = event.ID
+		}
+
+		if len(events) < auditBatchSize {
+			return report, nil
+		}
+	}
+}
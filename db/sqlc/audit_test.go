@@ -0,0 +1,335 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// auditEventsOf lists the audit events recorded against resource
+func auditEventsOf(t *testing.T, resource string) []AuditEvents {
+	events, err := testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		Resource: pgtype.Text{String: resource, Valid: true},
+		Limit:    100,
+	})
+	require.NoError(t, err)
+	return events
+}
+
+func TestCreateUserTxAudited(t *testing.T) {
+	store := NewStore(pool)
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	audit := &AuditContext{Actor: util.RandomOwner(), IP: "10.0.0.1", UserAgent: "test"}
+
+	user, err := store.CreateUserTx(context.Background(), CreateUserTxRequest{
+		CreateUserParams: CreateUserParams{
+			Username: audit.Actor,
+			Password: hashedPassword,
+			FullName: util.RandomOwner(),
+			Email:    util.RandomEmail(),
+		},
+		Audit: audit,
+	})
+	require.NoError(t, err)
+
+	events := auditEventsOf(t, "user/"+user.Username)
+	require.Len(t, events, 1)
+	require.Equal(t, util.AuditSignup, events[0].Action)
+	require.Equal(t, "10.0.0.1", events[0].Ip)
+	require.Equal(t, "test", events[0].UserAgent)
+	require.Nil(t, events[0].Before)
+
+	// the password hash is never written to the log
+	require.NotContains(t, string(events[0].After), hashedPassword)
+	require.Contains(t, string(events[0].After), user.Email)
+}
+
+func TestDepositTxAudited(t *testing.T) {
+	store := NewStore(pool)
+	account := createRandomAccountIn(t, "USD")
+	audit := &AuditContext{Actor: account.Owner}
+
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(25),
+		Audit:     audit,
+	})
+	require.NoError(t, err)
+
+	events := auditEventsOf(t, fmt.Sprintf("account/%d", account.ID))
+	require.Len(t, events, 1)
+	require.Equal(t, account.Owner, events[0].Actor)
+	require.Equal(t, util.AuditDeposit, events[0].Action)
+	require.WithinDuration(t, time.Now(), events[0].CreatedAt, time.Minute)
+
+	var before Accounts
+	require.NoError(t, json.Unmarshal(events[0].Before, &before))
+	require.True(t, before.Balance.IsZero())
+
+	var after AccountTxResponse
+	require.NoError(t, json.Unmarshal(events[0].After, &after))
+	require.True(t, decimal.NewFromInt(25).Equal(after.Account.Balance))
+
+	// movements without an audit context, made by the bank itself, are not logged
+	_, err = store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(5),
+	})
+	require.NoError(t, err)
+	require.Len(t, auditEventsOf(t, fmt.Sprintf("account/%d", account.ID)), 1)
+}
+
+func TestTransferTxAudited(t *testing.T) {
+	store := NewStore(pool)
+	fromAccount := createFundedAccount(t, store)
+	toAccount := createRandomAccountIn(t, "USD")
+
+	result, err := store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      "USD",
+		Audit:         &AuditContext{Actor: fromAccount.Owner},
+	})
+	require.NoError(t, err)
+
+	events := auditEventsOf(t, fmt.Sprintf("transfer/%d", result.Transfer.ID))
+	require.Len(t, events, 1)
+	require.Equal(t, util.AuditTransferCreate, events[0].Action)
+
+	var before transferAccounts
+	require.NoError(t, json.Unmarshal(events[0].Before, &before))
+	require.True(t, decimal.NewFromInt(1000).Equal(before.FromAccount.Balance))
+}
+
+func TestUpdateUserRoleTxAudited(t *testing.T) {
+	store := NewStore(pool)
+	user := createRandomUser(t)
+
+	_, err := store.UpdateUserRoleTx(context.Background(), UpdateUserRoleTxRequest{
+		UpdateUserRoleParams: UpdateUserRoleParams{Role: util.AuditorRole, Username: user.Username},
+		Audit:                &AuditContext{Actor: "banker"},
+	})
+	require.NoError(t, err)
+
+	events := auditEventsOf(t, "user/"+user.Username)
+	require.Len(t, events, 1)
+	require.Equal(t, "banker", events[0].Actor)
+	require.Equal(t, util.AuditRoleChange, events[0].Action)
+	require.Contains(t, string(events[0].Before), user.Role)
+	require.Contains(t, string(events[0].After), util.AuditorRole)
+}
+
+func TestReleaseHoldTxAudited(t *testing.T) {
+	store := NewStore(pool)
+	hold, account := createFundedHold(t, store, time.Now().Add(time.Hour))
+
+	_, err := store.ReleaseHoldTx(context.Background(), ReleaseHoldTxRequest{
+		HoldID: hold.ID,
+		Audit:  &AuditContext{Actor: account.Owner},
+	})
+	require.NoError(t, err)
+
+	events := auditEventsOf(t, fmt.Sprintf("hold/%d", hold.ID))
+	require.Len(t, events, 1)
+	require.Equal(t, util.AuditHoldRelease, events[0].Action)
+
+	var after AccountHolds
+	require.NoError(t, json.Unmarshal(events[0].After, &after))
+	require.Equal(t, util.HoldReleased, after.Status)
+}
+
+func TestUpdateTransferStatusTxAudited(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	transfer, fromAccount, _ := createPendingTransfer(t, store)
+
+	_, err := store.UpdateTransferStatusTx(context.Background(), UpdateTransferStatusTxRequest{
+		TransferID: transfer.ID,
+		Status:     util.TransferCancelled,
+		ChangedBy:  fromAccount.Owner,
+		Audit:      &AuditContext{Actor: fromAccount.Owner},
+	})
+	require.NoError(t, err)
+
+	events := auditEventsOf(t, fmt.Sprintf("transfer/%d", transfer.ID))
+	require.Len(t, events, 1)
+	require.Equal(t, fromAccount.Owner, events[0].Actor)
+	require.Equal(t, util.AuditTransferStatusChange, events[0].Action)
+
+	var before Transfers
+	require.NoError(t, json.Unmarshal(events[0].Before, &before))
+	require.Equal(t, util.TransferPending, before.Status)
+
+	var after TransfersTxResponse
+	require.NoError(t, json.Unmarshal(events[0].After, &after))
+	require.Equal(t, util.TransferCancelled, after.Transfer.Status)
+}
+
+func TestScheduledTransferTxAudited(t *testing.T) {
+	store := NewStore(pool)
+	fromAccount := createRandomAccountIn(t, "USD")
+	toAccount := createRandomAccountIn(t, "USD")
+	audit := &AuditContext{Actor: fromAccount.Owner}
+
+	schedule, err := store.CreateScheduledTransferTx(context.Background(), CreateScheduledTransferTxRequest{
+		CreateScheduledTransferParams: CreateScheduledTransferParams{
+			Owner:         fromAccount.Owner,
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        decimal.NewFromInt(10),
+			Currency:      "USD",
+			Frequency:     util.WeeklyFrequency,
+			DueAt:         time.Now().Add(time.Hour),
+		},
+		Audit: audit,
+	})
+	require.NoError(t, err)
+
+	cancelled, err := store.CancelScheduledTransferTx(context.Background(), CancelScheduledTransferTxRequest{
+		ID:    schedule.ID,
+		Audit: audit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.ScheduleCancelled, cancelled.Status)
+
+	// only active schedules can be cancelled, and a refused cancel records nothing
+	_, err = store.CancelScheduledTransferTx(context.Background(), CancelScheduledTransferTxRequest{
+		ID:    schedule.ID,
+		Audit: audit,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	events := auditEventsOf(t, fmt.Sprintf("scheduled_transfer/%d", schedule.ID))
+	require.Len(t, events, 2)
+	require.Equal(t, util.AuditScheduleCreate, events[0].Action)
+	require.Equal(t, util.AuditScheduleCancel, events[1].Action)
+	require.Contains(t, string(events[1].Before), util.ScheduleActive)
+	require.Contains(t, string(events[1].After), util.ScheduleCancelled)
+}
+
+func TestQuoteTransferAudited(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	fromAccount := createRandomAccountIn(t, "EUR")
+	toAccount := createRandomAccountIn(t, "USD")
+
+	quote, err := store.QuoteTransfer(context.Background(), QuoteTransferRequest{
+		Username:      fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      "EUR",
+		ExpiresAt:     time.Now().Add(time.Minute),
+		Audit:         &AuditContext{Actor: fromAccount.Owner},
+	})
+	require.NoError(t, err)
+
+	events := auditEventsOf(t, "transfer_quote/"+quote.ID.String())
+	require.Len(t, events, 1)
+	require.Equal(t, util.AuditQuoteCreate, events[0].Action)
+	require.Nil(t, events[0].Before)
+}
+
+func TestCreateWebhookEndpointTxAudited(t *testing.T) {
+	store := NewStore(pool)
+	user := createRandomUser(t)
+	secret := "whsec_" + util.RandomString(32)
+
+	endpoint, err := store.CreateWebhookEndpointTx(context.Background(), CreateWebhookEndpointTxRequest{
+		CreateWebhookEndpointParams: CreateWebhookEndpointParams{
+			Owner:      user.Username,
+			Url:        "https://example.com/" + util.RandomString(8),
+			Secret:     secret,
+			EventTypes: []string{util.EventTransferReceived},
+		},
+		Audit: &AuditContext{Actor: user.Username},
+	})
+	require.NoError(t, err)
+
+	events := auditEventsOf(t, fmt.Sprintf("webhook/%d", endpoint.ID))
+	require.Len(t, events, 1)
+	require.Equal(t, util.AuditWebhookCreate, events[0].Action)
+
+	// the signing secret is never written to the log
+	require.NotContains(t, string(events[0].After), secret)
+	require.Contains(t, string(events[0].After), endpoint.Url)
+}
+
+func TestAuditChain(t *testing.T) {
+	store := NewStore(pool)
+	account := createRandomAccountIn(t, "USD")
+
+	for i := 0; i < 3; i++ {
+		_, err := store.DepositTx(context.Background(), AccountTxRequest{
+			AccountID: account.ID,
+			Amount:    decimal.NewFromInt(1),
+			Audit:     &AuditContext{Actor: account.Owner},
+		})
+		require.NoError(t, err)
+	}
+
+	events := auditEventsOf(t, fmt.Sprintf("account/%d", account.ID))
+	require.Len(t, events, 3)
+	for _, event := range events {
+		require.Equal(t, auditHash(event), event.Hash)
+	}
+
+	report, err := store.VerifyAuditChain(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, report.Events, int64(3))
+
+	last, err := testQueries.GetLastAuditEvent(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, last.Hash, report.LastHash)
+}
+
+func TestAuditEventsAppendOnly(t *testing.T) {
+	account := createRandomAccountIn(t, "USD")
+
+	_, err := NewStore(pool).DepositTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(1),
+		Audit:     &AuditContext{Actor: account.Owner},
+	})
+	require.NoError(t, err)
+
+	_, err = pool.Exec(context.Background(), "UPDATE audit_events SET actor = 'someone else' WHERE actor = $1", account.Owner)
+	require.Error(t, err)
+
+	_, err = pool.Exec(context.Background(), "DELETE FROM audit_events WHERE actor = $1", account.Owner)
+	require.Error(t, err)
+}
+
+func TestAuditHash(t *testing.T) {
+	event := AuditEvents{
+		Actor:     "alice",
+		Action:    util.AuditDeposit,
+		Resource:  "account/1",
+		After:     []byte(`{"balance":"10"}`),
+		PrevHash:  []byte{},
+		CreatedAt: time.Now(),
+	}
+	hash := auditHash(event)
+	require.Len(t, hash, 32)
+
+	tampered := event
+	tampered.After = []byte(`{"balance":"1000"}`)
+	require.NotEqual(t, hash, auditHash(tampered))
+
+	// a field's bytes cannot be moved into its neighbour without changing the hash
+	shifted := event
+	shifted.Actor, shifted.Ip = "ali", "ce"
+	require.NotEqual(t, hash, auditHash(shifted))
+
+	rechained := event
+	rechained.PrevHash = hash
+	require.NotEqual(t, hash, auditHash(rechained))
+}
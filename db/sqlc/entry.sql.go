@@ -7,36 +7,97 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
 )
 
+const countEntries = `-- name: CountEntries :one
+SELECT count(*) FROM entries
+WHERE account_id = $1
+AND ($2::timestamptz IS NULL OR created_at >= $2)
+AND ($3::timestamptz IS NULL OR created_at < $3)
+`
+
+type CountEntriesParams struct {
+	AccountID int64              `json:"account_id"`
+	FromDate  pgtype.Timestamptz `json:"from_date"`
+	ToDate    pgtype.Timestamptz `json:"to_date"`
+}
+
+func (q *Queries) CountEntries(ctx context.Context, arg CountEntriesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countEntries, arg.AccountID, arg.FromDate, arg.ToDate)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  type,
+  transfer_id
 ) VALUES (
-  $1, $2
-) RETURNING id, account_id, amount, created_at
+  $1, $2, $3, $4
+) RETURNING id, account_id, amount, created_at, type, transfer_id
 `
 
 type CreateEntryParams struct {
-	AccountID int64   `json:"account_id"`
-	Amount    float64 `json:"amount"`
+	AccountID  int64           `json:"account_id"`
+	Amount     decimal.Decimal `json:"amount"`
+	Type       string          `json:"type"`
+	TransferID pgtype.Int8     `json:"transfer_id"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entries, error) {
-	row := q.db.QueryRow(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.Type,
+		arg.TransferID,
+	)
 	var i Entries
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Type,
+		&i.TransferID,
 	)
 	return i, err
 }
 
+const getEntriesBalance = `-- name: GetEntriesBalance :one
+SELECT
+  COALESCE(SUM(amount) FILTER (WHERE created_at < $1::timestamptz), 0)::numeric AS opening_balance,
+  COALESCE(SUM(amount) FILTER (WHERE $2::timestamptz IS NULL OR created_at < $2), 0)::numeric AS closing_balance
+FROM entries
+WHERE account_id = $3
+`
+
+type GetEntriesBalanceParams struct {
+	FromDate  pgtype.Timestamptz `json:"from_date"`
+	ToDate    pgtype.Timestamptz `json:"to_date"`
+	AccountID int64              `json:"account_id"`
+}
+
+type GetEntriesBalanceRow struct {
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+	ClosingBalance decimal.Decimal `json:"closing_balance"`
+}
+
+func (q *Queries) GetEntriesBalance(ctx context.Context, arg GetEntriesBalanceParams) (GetEntriesBalanceRow, error) {
+	row := q.db.QueryRow(ctx, getEntriesBalance, arg.FromDate, arg.ToDate, arg.AccountID)
+	var i GetEntriesBalanceRow
+	err := row.Scan(&i.OpeningBalance, &i.ClosingBalance)
+	return i, err
+}
+
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, type, transfer_id FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -48,26 +109,170 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entries, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Type,
+		&i.TransferID,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, type, transfer_id FROM entries
 WHERE account_id = $1
+AND id > $2
 ORDER BY id
-LIMIT $2
-OFFSET $3
+LIMIT $3
 `
 
 type ListEntriesParams struct {
 	AccountID int64 `json:"account_id"`
+	AfterID   int64 `json:"after_id"`
 	Limit     int32 `json:"limit"`
-	Offset    int32 `json:"offset"`
 }
 
 func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entries, error) {
-	rows, err := q.db.Query(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listEntries, arg.AccountID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entries{}
+	for rows.Next() {
+		var i Entries
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Type,
+			&i.TransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntriesWithBalance = `-- name: ListEntriesWithBalance :many
+SELECT id, account_id, amount, created_at, type, transfer_id, balance
+FROM (
+  SELECT id, account_id, amount, created_at, type, transfer_id, SUM(amount) OVER (ORDER BY id)::numeric AS balance
+  FROM entries
+  WHERE account_id = $1
+) AS e
+WHERE ($2::timestamptz IS NULL OR created_at >= $2)
+AND ($3::timestamptz IS NULL OR created_at < $3)
+AND id > $4
+ORDER BY id
+LIMIT $5
+`
+
+type ListEntriesWithBalanceParams struct {
+	AccountID int64              `json:"account_id"`
+	FromDate  pgtype.Timestamptz `json:"from_date"`
+	ToDate    pgtype.Timestamptz `json:"to_date"`
+	AfterID   int64              `json:"after_id"`
+	Limit     int32              `json:"limit"`
+}
+
+type ListEntriesWithBalanceRow struct {
+	ID         int64           `json:"id"`
+	AccountID  int64           `json:"account_id"`
+	Amount     decimal.Decimal `json:"amount"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Type       string          `json:"type"`
+	TransferID pgtype.Int8     `json:"transfer_id"`
+	Balance    decimal.Decimal `json:"balance"`
+}
+
+func (q *Queries) ListEntriesWithBalance(ctx context.Context, arg ListEntriesWithBalanceParams) ([]ListEntriesWithBalanceRow, error) {
+	rows, err := q.db.Query(ctx, listEntriesWithBalance,
+		arg.AccountID,
+		arg.FromDate,
+		arg.ToDate,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListEntriesWithBalanceRow{}
+	for rows.Next() {
+		var i ListEntriesWithBalanceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Type,
+			&i.TransferID,
+			&i.Balance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLedgerImbalances = `-- name: ListLedgerImbalances :many
+SELECT a.currency, SUM(e.amount)::numeric AS total
+FROM entries e
+JOIN accounts a ON a.id = e.account_id
+GROUP BY a.currency
+HAVING SUM(e.amount) <> 0
+ORDER BY a.currency
+`
+
+type ListLedgerImbalancesRow struct {
+	Currency string          `json:"currency"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+func (q *Queries) ListLedgerImbalances(ctx context.Context) ([]ListLedgerImbalancesRow, error) {
+	rows, err := q.db.Query(ctx, listLedgerImbalances)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLedgerImbalancesRow{}
+	for rows.Next() {
+		var i ListLedgerImbalancesRow
+		if err := rows.Scan(&i.Currency, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrphanedEntries = `-- name: ListOrphanedEntries :many
+SELECT e.id, e.account_id, e.amount, e.created_at, e.type, e.transfer_id FROM entries e
+JOIN accounts a ON a.id = e.account_id
+LEFT JOIN transfers t ON t.id = e.transfer_id
+WHERE (
+  e.type IN ('transfer', 'fee') AND (
+    t.id IS NULL
+    OR t.status <> 'completed'
+    OR (a.kind = 'customer' AND e.account_id NOT IN (t.from_account_id, t.to_account_id))
+  )
+)
+OR (e.type NOT IN ('transfer', 'fee') AND e.transfer_id IS NOT NULL)
+ORDER BY e.id
+`
+
+func (q *Queries) ListOrphanedEntries(ctx context.Context) ([]Entries, error) {
+	rows, err := q.db.Query(ctx, listOrphanedEntries)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +285,8 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Type,
+			&i.TransferID,
 		); err != nil {
 			return nil, err
 		}
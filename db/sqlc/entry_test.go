@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -13,6 +15,7 @@ func createRandomEntry(t *testing.T, account Accounts) Entries {
 	arg := CreateEntryParams{
 		AccountID: account.ID,
 		Amount:    util.RandomBalance(account.Currency),
+		Type:      util.DepositEntry,
 	}
 
 	entry, err := testQueries.CreateEntry(context.Background(), arg)
@@ -20,7 +23,9 @@ func createRandomEntry(t *testing.T, account Accounts) Entries {
 	require.NotEmpty(t, entry)
 
 	require.Equal(t, arg.AccountID, entry.AccountID)
-	require.Equal(t, arg.Amount, entry.Amount)
+	require.True(t, arg.Amount.Equal(entry.Amount))
+	require.Equal(t, arg.Type, entry.Type)
+	require.False(t, entry.TransferID.Valid)
 
 	require.NotZero(t, entry.ID)
 	require.NotZero(t, entry.CreatedAt)
@@ -43,7 +48,7 @@ func TestGetEntry(t *testing.T) {
 
 	require.Equal(t, entry1.ID, entry2.ID)
 	require.Equal(t, entry1.AccountID, entry2.AccountID)
-	require.Equal(t, entry1.Amount, entry2.Amount)
+	require.True(t, entry1.Amount.Equal(entry2.Amount))
 
 	require.WithinDuration(t, entry1.CreatedAt, entry2.CreatedAt, time.Second)
 }
@@ -57,9 +62,13 @@ func TestListEntries(t *testing.T) {
 	arg := ListEntriesParams{
 		AccountID: account.ID,
 		Limit:     5,
-		Offset:    5,
 	}
 
+	page1, err := testQueries.ListEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page1, 5)
+
+	arg.AfterID = page1[len(page1)-1].ID
 	entries, err := testQueries.ListEntries(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, entries, 5)
@@ -67,5 +76,53 @@ func TestListEntries(t *testing.T) {
 	for _, entry := range entries {
 		require.NotEmpty(t, entry)
 		require.Equal(t, arg.AccountID, entry.AccountID)
+		require.Greater(t, entry.ID, arg.AfterID)
+	}
+
+	count, err := testQueries.CountEntries(context.Background(), CountEntriesParams{AccountID: account.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), count)
+}
+
+func TestListEntriesWithBalance(t *testing.T) {
+	account := createRandomAccount(t)
+
+	balance := decimal.Zero
+	for i := 0; i < 10; i++ {
+		entry := createRandomEntry(t, account)
+		balance = balance.Add(entry.Amount)
+	}
+
+	first, err := testQueries.ListEntriesWithBalance(context.Background(), ListEntriesWithBalanceParams{
+		AccountID: account.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, first, 5)
+
+	arg := ListEntriesWithBalanceParams{
+		AccountID: account.ID,
+		AfterID:   first[len(first)-1].ID,
+		Limit:     5,
+	}
+
+	entries, err := testQueries.ListEntriesWithBalance(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+
+	running := entries[0].Balance.Sub(entries[0].Amount)
+	for _, entry := range entries {
+		require.Equal(t, arg.AccountID, entry.AccountID)
+		running = running.Add(entry.Amount)
+		require.True(t, running.Equal(entry.Balance))
 	}
+	require.True(t, balance.Equal(running))
+
+	summary, err := testQueries.GetEntriesBalance(context.Background(), GetEntriesBalanceParams{
+		FromDate:  pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+		AccountID: account.ID,
+	})
+	require.NoError(t, err)
+	require.True(t, balance.Equal(summary.OpeningBalance))
+	require.True(t, balance.Equal(summary.ClosingBalance))
 }
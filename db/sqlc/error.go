@@ -2,9 +2,12 @@ package db
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
 )
 
 const (
@@ -14,6 +17,22 @@ const (
 
 var ErrRecordNotFound = pgx.ErrNoRows
 
+// ErrInsufficientFunds is returned when a debit would take an account below zero
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrAmountPrecision is returned when an amount has more decimal places than
+// the minor unit of its currency
+var ErrAmountPrecision = errors.New("amount is more precise than its currency allows")
+
+// checkPrecision fails with ErrAmountPrecision unless amount fits the minor
+// unit of currency
+func checkPrecision(amount decimal.Decimal, currency string) error {
+	if !util.FitsCurrency(amount, currency) {
+		return fmt.Errorf("%w: %s %s", ErrAmountPrecision, amount, currency)
+	}
+	return nil
+}
+
 var ErrUniqueViolation = &pgconn.PgError{
 	Code: UniqueViolation,
 }
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomExchangeRate(t *testing.T, currency string, effectiveFrom time.Time) ExchangeRates {
+	arg := CreateExchangeRateParams{
+		Currency:      currency,
+		Rate:          decimal.NewFromInt(util.RandomInt(1, 1000)).Div(decimal.NewFromInt(100)),
+		EffectiveFrom: effectiveFrom,
+	}
+
+	rate, err := testQueries.CreateExchangeRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, rate)
+
+	require.Equal(t, arg.Currency, rate.Currency)
+	require.True(t, arg.Rate.Equal(rate.Rate))
+	require.WithinDuration(t, arg.EffectiveFrom, rate.EffectiveFrom, time.Second)
+
+	require.NotZero(t, rate.ID)
+	require.NotZero(t, rate.CreatedAt)
+
+	return rate
+}
+
+// randomRateCurrency returns a currency code that no other test writes rates for
+func randomRateCurrency() string {
+	return strings.ToUpper(util.RandomString(3))
+}
+
+func TestCreateExchangeRate(t *testing.T) {
+	createRandomExchangeRate(t, randomRateCurrency(), time.Now())
+}
+
+func TestGetExchangeRate(t *testing.T) {
+	currency := randomRateCurrency()
+	now := time.Now()
+
+	old := createRandomExchangeRate(t, currency, now.Add(-48*time.Hour))
+	current := createRandomExchangeRate(t, currency, now.Add(-time.Hour))
+	createRandomExchangeRate(t, currency, now.Add(time.Hour))
+
+	rate, err := testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		Currency: currency,
+		At:       now,
+	})
+	require.NoError(t, err)
+	require.Equal(t, current.ID, rate.ID)
+
+	rate, err = testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		Currency: currency,
+		At:       now.Add(-24 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, old.ID, rate.ID)
+
+	_, err = testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		Currency: currency,
+		At:       now.Add(-72 * time.Hour),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListExchangeRates(t *testing.T) {
+	currency := randomRateCurrency()
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		createRandomExchangeRate(t, currency, now.Add(-time.Duration(i)*time.Hour))
+	}
+
+	rates, err := testQueries.ListExchangeRates(context.Background(), ListExchangeRatesParams{
+		Currency: currency,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Len(t, rates, 5)
+
+	for i := 1; i < len(rates); i++ {
+		require.False(t, rates[i].EffectiveFrom.After(rates[i-1].EffectiveFrom))
+	}
+}
+
+func TestExchangeRateAt(t *testing.T) {
+	store := NewStore(pool)
+	from := randomRateCurrency()
+	to := randomRateCurrency()
+	at := time.Now().Add(-time.Minute)
+
+	fromRate := createRandomExchangeRate(t, from, at)
+	toRate := createRandomExchangeRate(t, to, at)
+
+	rate, err := store.ExchangeRateAt(context.Background(), from, to, time.Now())
+	require.NoError(t, err)
+	require.True(t, rate.Equal(util.CrossRate(fromRate.Rate, toRate.Rate)))
+
+	rate, err = store.ExchangeRateAt(context.Background(), from, from, time.Now())
+	require.NoError(t, err)
+	require.True(t, rate.Equal(decimal.NewFromInt(1)))
+
+	_, err = store.ExchangeRateAt(context.Background(), from, to, at.Add(-time.Hour))
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+type CreateFeeScheduleTxRequest struct {
+	CreateFeeScheduleParams
+	// Audit, when set, records the new schedule in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// CreateFeeScheduleTx adds a fee schedule, charged on the transfers made from then on
+func (store *SQLStore) CreateFeeScheduleTx(ctx context.Context, arg CreateFeeScheduleTxRequest) (FeeSchedules, error) {
+	var schedule FeeSchedules
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		schedule, err = q.CreateFeeSchedule(ctx, arg.CreateFeeScheduleParams)
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("fee_schedule/%d", schedule.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditFeeScheduleCreate, resource, nil, schedule)
+	})
+
+	return schedule, err
+}
+
+type DeleteFeeScheduleTxRequest struct {
+	ID int64 `json:"id"`
+	// Audit, when set, records the removal in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// DeleteFeeScheduleTx removes a fee schedule
+func (store *SQLStore) DeleteFeeScheduleTx(ctx context.Context, arg DeleteFeeScheduleTxRequest) (FeeSchedules, error) {
+	var schedule FeeSchedules
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		schedule, err = q.DeleteFeeSchedule(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("fee_schedule/%d", schedule.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditFeeScheduleDelete, resource, schedule, nil)
+	})
+
+	return schedule, err
+}
+
+// transferFee adds up what every schedule charges for debiting amount from an
+// account in currency, rounded to the currency's minor unit
+func transferFee(schedules []FeeSchedules, amount decimal.Decimal, currency string, crossCurrency bool) decimal.Decimal {
+	fee := decimal.Zero
+
+	for _, schedule := range schedules {
+		switch schedule.Kind {
+		case util.FlatFee:
+			fee = fee.Add(schedule.FlatAmount)
+		case util.PercentageFee:
+			fee = fee.Add(amount.Mul(schedule.Rate))
+		case util.TieredFee:
+			if amount.LessThan(schedule.MinAmount) {
+				continue
+			}
+			if schedule.MaxAmount.Valid && !amount.LessThan(schedule.MaxAmount.Decimal) {
+				continue
+			}
+			fee = fee.Add(schedule.FlatAmount).Add(amount.Mul(schedule.Rate))
+		case util.FXMarkupFee:
+			if crossCurrency {
+				fee = fee.Add(amount.Mul(schedule.Rate))
+			}
+		}
+	}
+
+	return util.RoundToCurrency(fee, currency)
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomFeeSchedule(t *testing.T, arg CreateFeeScheduleParams) FeeSchedules {
+	schedule, err := testQueries.CreateFeeSchedule(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, schedule.ID)
+	require.NotZero(t, schedule.CreatedAt)
+
+	require.Equal(t, arg.Currency, schedule.Currency)
+	require.Equal(t, arg.Kind, schedule.Kind)
+	require.True(t, arg.FlatAmount.Equal(schedule.FlatAmount))
+	require.True(t, arg.Rate.Equal(schedule.Rate))
+
+	t.Cleanup(func() {
+		testQueries.DeleteFeeSchedule(context.Background(), schedule.ID)
+	})
+
+	return schedule
+}
+
+func TestListFeeSchedules(t *testing.T) {
+	currency := randomRateCurrency()
+
+	flat := createRandomFeeSchedule(t, CreateFeeScheduleParams{
+		Currency:   currency,
+		Kind:       util.FlatFee,
+		FlatAmount: decimal.NewFromInt(1),
+	})
+	markup := createRandomFeeSchedule(t, CreateFeeScheduleParams{
+		Currency: currency,
+		Kind:     util.FXMarkupFee,
+		Rate:     decimal.RequireFromString("0.005"),
+	})
+
+	schedules, err := testQueries.ListFeeSchedules(context.Background(), pgtype.Text{String: currency, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+	require.Equal(t, flat.ID, schedules[0].ID)
+	require.Equal(t, markup.ID, schedules[1].ID)
+	require.False(t, schedules[0].MaxAmount.Valid)
+
+	_, err = testQueries.DeleteFeeSchedule(context.Background(), flat.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.DeleteFeeSchedule(context.Background(), flat.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestTransferFee(t *testing.T) {
+	flat := FeeSchedules{Kind: util.FlatFee, FlatAmount: decimal.NewFromInt(2)}
+	percentage := FeeSchedules{Kind: util.PercentageFee, Rate: decimal.RequireFromString("0.01")}
+	markup := FeeSchedules{Kind: util.FXMarkupFee, Rate: decimal.RequireFromString("0.02")}
+	lowTier := FeeSchedules{
+		Kind:       util.TieredFee,
+		FlatAmount: decimal.NewFromInt(1),
+		MinAmount:  decimal.Zero,
+		MaxAmount:  decimal.NewNullDecimal(decimal.NewFromInt(100)),
+	}
+	highTier := FeeSchedules{
+		Kind:      util.TieredFee,
+		Rate:      decimal.RequireFromString("0.005"),
+		MinAmount: decimal.NewFromInt(100),
+	}
+
+	testCases := []struct {
+		name          string
+		schedules     []FeeSchedules
+		amount        string
+		currency      string
+		crossCurrency bool
+		fee           string
+	}{
+		{name: "NoSchedules", amount: "50", currency: "USD", fee: "0"},
+		{name: "Flat", schedules: []FeeSchedules{flat}, amount: "50", currency: "USD", fee: "2"},
+		{name: "Percentage", schedules: []FeeSchedules{percentage}, amount: "12.34", currency: "USD", fee: "0.12"},
+		{name: "PercentageRoundsToCurrency", schedules: []FeeSchedules{percentage}, amount: "1250", currency: "XAF", fee: "13"},
+		{name: "MarkupSameCurrency", schedules: []FeeSchedules{markup}, amount: "50", currency: "USD", fee: "0"},
+		{name: "MarkupCrossCurrency", schedules: []FeeSchedules{markup}, amount: "50", currency: "USD", crossCurrency: true, fee: "1"},
+		{name: "LowTier", schedules: []FeeSchedules{lowTier, highTier}, amount: "99.99", currency: "USD", fee: "1"},
+		{name: "HighTierFromItsMinimum", schedules: []FeeSchedules{lowTier, highTier}, amount: "100", currency: "USD", fee: "0.5"},
+		{name: "Combined", schedules: []FeeSchedules{flat, percentage, markup}, amount: "200", currency: "EUR", crossCurrency: true, fee: "8"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fee := transferFee(tc.schedules, decimal.RequireFromString(tc.amount), tc.currency, tc.crossCurrency)
+			require.True(t, decimal.RequireFromString(tc.fee).Equal(fee), fee.String())
+		})
+	}
+}
+
+func TestTransferTxChargesFee(t *testing.T) {
+	store := NewStore(pool)
+	currency := randomRateCurrency()
+
+	createRandomFeeSchedule(t, CreateFeeScheduleParams{
+		Currency:   currency,
+		Kind:       util.FlatFee,
+		FlatAmount: decimal.NewFromInt(1),
+	})
+	createRandomFeeSchedule(t, CreateFeeScheduleParams{
+		Currency: currency,
+		Kind:     util.PercentageFee,
+		Rate:     decimal.RequireFromString("0.1"),
+	})
+
+	fromAccount := createRandomAccountIn(t, currency)
+	toAccount := createRandomAccountIn(t, currency)
+
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	response, err := store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(50),
+		Currency:      currency,
+	})
+	require.NoError(t, err)
+
+	fee := decimal.NewFromInt(6)
+	require.True(t, fee.Equal(response.Transfer.Fee))
+	require.NotNil(t, response.FeeEntry)
+	require.Equal(t, util.FeeEntry, response.FeeEntry.Type)
+	require.Equal(t, fromAccount.ID, response.FeeEntry.AccountID)
+	require.True(t, fee.Neg().Equal(response.FeeEntry.Amount))
+	require.Equal(t, response.Transfer.ID, response.FeeEntry.TransferID.Int64)
+
+	require.True(t, decimal.NewFromInt(44).Equal(response.FromAccount.Balance))
+	require.True(t, decimal.NewFromInt(50).Equal(response.ToAccount.Balance))
+
+	feesIncome := getSystemAccount(t, util.FeesIncomeAccount, currency)
+	require.True(t, fee.Equal(feesIncome.Balance))
+
+	// the fee counts towards the funds the transfer needs
+	_, err = store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(40),
+		Currency:      currency,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
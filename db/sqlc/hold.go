@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrHoldNotActive is returned when capturing or releasing a hold that was already captured or released
+	ErrHoldNotActive = errors.New("hold is no longer active")
+	// ErrHoldExpired is returned when capturing a hold past its expiry
+	ErrHoldExpired = errors.New("hold has expired")
+	// ErrHoldMismatch is returned when a capture debits another account, in
+	// another currency or more than the hold reserved
+	ErrHoldMismatch = errors.New("transfer does not match the hold")
+	// ErrTransferHold is returned when capturing or releasing the hold of a
+	// pending transfer, which is settled with the transfer instead
+	ErrTransferHold = errors.New("hold reserves the money of a pending transfer")
+)
+
+// transferHoldDuration is how long a pending transfer reserves its money. One
+// still pending after that holds nothing back, and completing it checks the
+// balance again.
+const transferHoldDuration = 90 * 24 * time.Hour
+
+type PlaceHoldTxRequest struct {
+	AccountID int64 `json:"account_id"`
+	// Amount to reserve, in the account currency
+	Amount    decimal.Decimal `json:"amount"`
+	Reason    string          `json:"reason"`
+	CreatedBy string          `json:"created_by"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	// Audit, when set, records the hold in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// PlaceHoldTx reserves money on an account without moving it, failing with
+// ErrInsufficientFunds if the available balance does not cover it
+func (store *SQLStore) PlaceHoldTx(ctx context.Context, arg PlaceHoldTxRequest) (AccountHolds, error) {
+	var hold AccountHolds
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkPrecision(arg.Amount, account.Currency); err != nil {
+			return err
+		}
+
+		available, err := availableBalance(ctx, q, account, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if available.LessThan(arg.Amount) {
+			return ErrInsufficientFunds
+		}
+
+		hold, err = q.CreateAccountHold(ctx, CreateAccountHoldParams{
+			AccountID: arg.AccountID,
+			Amount:    arg.Amount,
+			Reason:    arg.Reason,
+			CreatedBy: arg.CreatedBy,
+			ExpiresAt: arg.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("hold/%d", hold.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditHoldPlace, resource, nil, hold)
+	})
+
+	return hold, err
+}
+
+type CaptureHoldTxRequest struct {
+	HoldID      int64 `json:"hold_id"`
+	ToAccountID int64 `json:"to_account_id"`
+	// Amount to transfer in the held account's currency, the whole hold when
+	// zero. Whatever is left of the hold is released.
+	Amount decimal.Decimal `json:"amount"`
+	// Audit, when set, records the capture and its transfer in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// CaptureHoldTx turns a hold into a transfer from the held account, priced as
+// any other transfer
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxRequest) (TransfersTxResponse, error) {
+	hold, err := store.GetAccountHold(ctx, arg.HoldID)
+	if err != nil {
+		return TransfersTxResponse{}, err
+	}
+
+	account, err := store.GetAccount(ctx, hold.AccountID)
+	if err != nil {
+		return TransfersTxResponse{}, err
+	}
+
+	amount := arg.Amount
+	if amount.IsZero() {
+		amount = hold.Amount
+	}
+
+	return store.TransferTx(ctx, TransferTxRequest{
+		FromAccountID: hold.AccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        amount,
+		Currency:      account.Currency,
+		HoldID:        hold.ID,
+		Audit:         arg.Audit,
+	})
+}
+
+type ReleaseHoldTxRequest struct {
+	HoldID int64 `json:"hold_id"`
+	// Audit, when set, records the release in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// ReleaseHoldTx gives up a hold, making its amount available again
+func (store *SQLStore) ReleaseHoldTx(ctx context.Context, arg ReleaseHoldTxRequest) (AccountHolds, error) {
+	var hold AccountHolds
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetAccountHoldForUpdate(ctx, arg.HoldID)
+		if err != nil {
+			return err
+		}
+
+		if before.Status != util.HoldActive {
+			return ErrHoldNotActive
+		}
+
+		if before.TransferID.Valid {
+			return ErrTransferHold
+		}
+
+		hold, err = q.UpdateAccountHoldStatus(ctx, UpdateAccountHoldStatusParams{
+			Status: util.HoldReleased,
+			ID:     before.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("hold/%d", hold.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditHoldRelease, resource, before, hold)
+	})
+
+	return hold, err
+}
+
+// lockCapturableHold locks the hold a transfer captures, checking it is still
+// active and reserves what the transfer debits
+func lockCapturableHold(ctx context.Context, q *Queries, arg TransferTxRequest, now time.Time) (AccountHolds, error) {
+	hold, err := q.GetAccountHoldForUpdate(ctx, arg.HoldID)
+	if err != nil {
+		return hold, err
+	}
+
+	if hold.Status != util.HoldActive {
+		return hold, ErrHoldNotActive
+	}
+
+	if hold.TransferID.Valid {
+		return hold, ErrTransferHold
+	}
+
+	if !hold.ExpiresAt.After(now) {
+		return hold, ErrHoldExpired
+	}
+
+	if hold.AccountID != arg.FromAccountID || arg.Amount.GreaterThan(hold.Amount) {
+		return hold, ErrHoldMismatch
+	}
+
+	return hold, nil
+}
+
+// captureHold marks the hold as captured by the transfer. It is audited by the
+// caller once every row lock is taken, since the audit chain is locked last.
+func captureHold(ctx context.Context, q *Queries, hold AccountHolds, transferID int64) (AccountHolds, error) {
+	return q.UpdateAccountHoldStatus(ctx, UpdateAccountHoldStatusParams{
+		Status:     util.HoldCaptured,
+		TransferID: pgtype.Int8{Int64: transferID, Valid: true},
+		ID:         hold.ID,
+	})
+}
+
+// reserveTransfer places a hold on the source account for what the pending
+// transfer will debit, so the money cannot be spent elsewhere while it waits
+func reserveTransfer(ctx context.Context, q *Queries, transfer Transfers, fromAccount Accounts, now time.Time) (AccountHolds, error) {
+	return q.CreateAccountHold(ctx, CreateAccountHoldParams{
+		AccountID:  fromAccount.ID,
+		Amount:     transfer.FromAmount.Add(transfer.Fee),
+		Reason:     fmt.Sprintf("transfer %d pending", transfer.ID),
+		CreatedBy:  fromAccount.Owner,
+		ExpiresAt:  now.Add(transferHoldDuration),
+		TransferID: pgtype.Int8{Int64: transfer.ID, Valid: true},
+	})
+}
+
+// settleTransferHold captures the hold of a pending transfer that completed,
+// and releases it for one that failed or was cancelled
+func settleTransferHold(ctx context.Context, q *Queries, hold AccountHolds, status string) (AccountHolds, error) {
+	settled := util.HoldReleased
+	if status == util.TransferCompleted {
+		settled = util.HoldCaptured
+	}
+
+	return q.UpdateAccountHoldStatus(ctx, UpdateAccountHoldStatusParams{
+		Status:     settled,
+		TransferID: hold.TransferID,
+		ID:         hold.ID,
+	})
+}
+
+// availableBalance is what can be spent from the account at the given time,
+// its balance less the holds still reserving money on it
+func availableBalance(ctx context.Context, q *Queries, account Accounts, now time.Time) (decimal.Decimal, error) {
+	held, err := q.GetHeldAmount(ctx, GetHeldAmountParams{
+		AccountID: account.ID,
+		Now:       now,
+	})
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return account.Balance.Sub(held), nil
+}
@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// createFundedHold holds 60 of a USD account funded with 100
+func createFundedHold(t *testing.T, store Store, expiresAt time.Time) (AccountHolds, Accounts) {
+	account := createRandomAccountIn(t, "USD")
+
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	hold, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(60),
+		Reason:    "card authorization",
+		CreatedBy: createRandomUser(t).Username,
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.HoldActive, hold.Status)
+	require.False(t, hold.TransferID.Valid)
+
+	return hold, account
+}
+
+func TestPlaceHoldTxReservesAvailableBalance(t *testing.T) {
+	store := NewStore(pool)
+	_, account := createFundedHold(t, store, time.Now().Add(time.Hour))
+
+	// only 40 is left to spend, though the balance is untouched
+	_, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(50),
+		CreatedBy: createRandomUser(t).Username,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(50),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	result, err := store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(40),
+	})
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(60).Equal(result.Account.Balance))
+
+	toAccount := createRandomAccountIn(t, "USD")
+	_, err = store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: account.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(1),
+		Currency:      "USD",
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestPlaceHoldTxExpiredHoldsReserveNothing(t *testing.T) {
+	store := NewStore(pool)
+	hold, account := createFundedHold(t, store, time.Now().Add(time.Second))
+
+	time.Sleep(time.Second)
+
+	result, err := store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+	require.True(t, result.Account.Balance.IsZero())
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxRequest{
+		HoldID:      hold.ID,
+		ToAccountID: createRandomAccountIn(t, "USD").ID,
+	})
+	require.ErrorIs(t, err, ErrHoldExpired)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewStore(pool)
+	hold, account := createFundedHold(t, store, time.Now().Add(time.Hour))
+	toAccount := createRandomAccountIn(t, "USD")
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxRequest{
+		HoldID:      hold.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      decimal.NewFromInt(70),
+	})
+	require.ErrorIs(t, err, ErrHoldMismatch)
+
+	// capturing part of the hold releases the rest
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxRequest{
+		HoldID:      hold.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      decimal.NewFromInt(45),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, result.Transfer.FromAccountID)
+	require.True(t, decimal.NewFromInt(45).Equal(result.Transfer.Amount))
+	require.True(t, decimal.NewFromInt(55).Equal(result.FromAccount.Balance))
+
+	captured, err := testQueries.GetAccountHold(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.HoldCaptured, captured.Status)
+	require.Equal(t, result.Transfer.ID, captured.TransferID.Int64)
+
+	held, err := testQueries.GetHeldAmount(context.Background(), GetHeldAmountParams{
+		AccountID: account.ID,
+		Now:       time.Now(),
+	})
+	require.NoError(t, err)
+	require.True(t, held.IsZero())
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxRequest{
+		HoldID:      hold.ID,
+		ToAccountID: toAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+
+	require.NoError(t, store.VerifyLedger(context.Background()))
+}
+
+func TestReleaseHoldTx(t *testing.T) {
+	store := NewStore(pool)
+	hold, account := createFundedHold(t, store, time.Now().Add(time.Hour))
+
+	released, err := store.ReleaseHoldTx(context.Background(), ReleaseHoldTxRequest{HoldID: hold.ID})
+	require.NoError(t, err)
+	require.Equal(t, util.HoldReleased, released.Status)
+
+	_, err = store.ReleaseHoldTx(context.Background(), ReleaseHoldTxRequest{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+
+	result, err := store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+	require.True(t, result.Account.Balance.IsZero())
+}
+
+func TestCaptureHoldTxConcurrentWithTransfers(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("0.9")))
+	audit := &AuditContext{Actor: createRandomUser(t).Username}
+
+	// captures and ordinary transfers both lock the FX clearing accounts and the
+	// audit chain, so they must take them in the same order
+	n := 5
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		hold, _ := createFundedHold(t, store, time.Now().Add(time.Hour))
+		sender := createFundedAccount(t, store)
+		toAccount := createRandomAccountIn(t, "EUR")
+
+		go func() {
+			_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxRequest{
+				HoldID:      hold.ID,
+				ToAccountID: toAccount.ID,
+				Audit:       audit,
+			})
+			errs <- err
+		}()
+
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxRequest{
+				FromAccountID: sender.ID,
+				ToAccountID:   toAccount.ID,
+				Amount:        decimal.NewFromInt(10),
+				Currency:      "USD",
+				Audit:         audit,
+			})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < 2*n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	require.NoError(t, store.VerifyLedger(context.Background()))
+}
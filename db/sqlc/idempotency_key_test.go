@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomIdempotencyKey(t *testing.T, user Users) IdempotencyKeys {
+	return createLeasedIdempotencyKey(t, user, time.Now().Add(time.Minute))
+}
+
+func createLeasedIdempotencyKey(t *testing.T, user Users, lockedUntil time.Time) IdempotencyKeys {
+	arg := CreateIdempotencyKeyParams{
+		Username:    user.Username,
+		Key:         util.RandomString(32),
+		RequestHash: util.RandomString(64),
+		LockedUntil: lockedUntil,
+	}
+
+	idempotencyKey, err := testQueries.CreateIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.Username, idempotencyKey.Username)
+	require.Equal(t, arg.Key, idempotencyKey.Key)
+	require.Equal(t, arg.RequestHash, idempotencyKey.RequestHash)
+	require.Zero(t, idempotencyKey.ResponseStatus)
+	require.Empty(t, idempotencyKey.ResponseBody)
+	require.NotZero(t, idempotencyKey.CreatedAt)
+	require.WithinDuration(t, lockedUntil, idempotencyKey.LockedUntil, time.Second)
+
+	return idempotencyKey
+}
+
+func TestCreateIdempotencyKey(t *testing.T) {
+	user := createRandomUser(t)
+	idempotencyKey := createRandomIdempotencyKey(t, user)
+
+	// reusing the key inserts nothing, even for the same request while it is in progress
+	for _, requestHash := range []string{util.RandomString(64), idempotencyKey.RequestHash} {
+		_, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+			Username:    user.Username,
+			Key:         idempotencyKey.Key,
+			RequestHash: requestHash,
+			LockedUntil: time.Now().Add(time.Minute),
+		})
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	}
+
+	// the same key belongs to each user separately
+	createRandomIdempotencyKey(t, createRandomUser(t))
+}
+
+func TestCreateIdempotencyKeyAfterLease(t *testing.T) {
+	user := createRandomUser(t)
+	lost := createLeasedIdempotencyKey(t, user, time.Now().Add(-time.Second))
+
+	// another request cannot take over a lost key
+	_, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:    user.Username,
+		Key:         lost.Key,
+		RequestHash: util.RandomString(64),
+		LockedUntil: time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// a retry of the same request can, once its lease has run out
+	lockedUntil := time.Now().Add(time.Minute)
+	retried, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:    user.Username,
+		Key:         lost.Key,
+		RequestHash: lost.RequestHash,
+		LockedUntil: lockedUntil,
+	})
+	require.NoError(t, err)
+	require.Zero(t, retried.ResponseStatus)
+	require.WithinDuration(t, lockedUntil, retried.LockedUntil, time.Second)
+
+	// a completed key is never taken over
+	completed := createLeasedIdempotencyKey(t, user, time.Now().Add(-time.Second))
+	_, err = testQueries.CompleteIdempotencyKey(context.Background(), CompleteIdempotencyKeyParams{
+		ResponseStatus: 500,
+		ResponseBody:   []byte(`{"error": "error building response"}`),
+		Username:       completed.Username,
+		Key:            completed.Key,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:    user.Username,
+		Key:         completed.Key,
+		RequestHash: completed.RequestHash,
+		LockedUntil: time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestCompleteIdempotencyKey(t *testing.T) {
+	idempotencyKey1 := createRandomIdempotencyKey(t, createRandomUser(t))
+
+	idempotencyKey2, err := testQueries.CompleteIdempotencyKey(context.Background(), CompleteIdempotencyKeyParams{
+		ResponseStatus: 201,
+		ResponseBody:   []byte(`{"data": {"id": 1}}`),
+		Username:       idempotencyKey1.Username,
+		Key:            idempotencyKey1.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(201), idempotencyKey2.ResponseStatus)
+	require.JSONEq(t, `{"data": {"id": 1}}`, string(idempotencyKey2.ResponseBody))
+
+	idempotencyKey3, err := testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: idempotencyKey1.Username,
+		Key:      idempotencyKey1.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, idempotencyKey2.ResponseStatus, idempotencyKey3.ResponseStatus)
+	require.Equal(t, idempotencyKey1.RequestHash, idempotencyKey3.RequestHash)
+}
+
+func TestDeleteIdempotencyKey(t *testing.T) {
+	idempotencyKey := createRandomIdempotencyKey(t, createRandomUser(t))
+
+	err := testQueries.DeleteIdempotencyKey(context.Background(), DeleteIdempotencyKeyParams{
+		Username: idempotencyKey.Username,
+		Key:      idempotencyKey.Key,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: idempotencyKey.Username,
+		Key:      idempotencyKey.Key,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+// ErrUnbalancedJournal is returned when the lines of a movement do not sum to zero in every currency
+var ErrUnbalancedJournal = errors.New("journal lines do not balance")
+
+// ErrLedgerImbalanced is returned by VerifyLedger when the entries of a currency do not sum to zero
+var ErrLedgerImbalanced = errors.New("ledger is out of balance")
+
+// ErrSystemAccount is returned when a transfer names one of the bank's system
+// accounts, which only the ledger itself posts to
+var ErrSystemAccount = errors.New("system accounts cannot take part in transfers")
+
+// journalLine is one side of a movement, posted as an entry of Type on the account
+type journalLine struct {
+	Account Accounts
+	Amount  decimal.Decimal
+	Type    string
+}
+
+// systemAccountKey identifies one of the bank's system accounts
+type systemAccountKey struct {
+	Kind     string
+	Currency string
+}
+
+// lockSystemAccounts returns the system accounts for keys, creating any that
+// do not exist yet. The rows are locked in a fixed order, after the customer
+// accounts, so concurrent movements cannot deadlock on them.
+func lockSystemAccounts(ctx context.Context, q *Queries, keys ...systemAccountKey) (map[systemAccountKey]Accounts, error) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Kind != keys[j].Kind {
+			return keys[i].Kind < keys[j].Kind
+		}
+		return keys[i].Currency < keys[j].Currency
+	})
+
+	accounts := make(map[systemAccountKey]Accounts, len(keys))
+	for _, key := range keys {
+		if _, ok := accounts[key]; ok {
+			continue
+		}
+
+		account, err := q.EnsureSystemAccount(ctx, EnsureSystemAccountParams{
+			Owner:    util.SystemOwner,
+			Currency: key.Currency,
+			Kind:     key.Kind,
+		})
+		if err != nil {
+			return nil, err
+		}
+		accounts[key] = account
+	}
+
+	return accounts, nil
+}
+
+// postJournal writes one entry per line and applies the lines to the account
+// balances. The lines must sum to zero in every currency. Entries are returned
+// in the order of lines, and the updated accounts by id.
+func postJournal(ctx context.Context, q *Queries, transferID pgtype.Int8, lines []journalLine) ([]Entries, map[int64]Accounts, error) {
+	totals := make(map[string]decimal.Decimal)
+	for _, line := range lines {
+		totals[line.Account.Currency] = totals[line.Account.Currency].Add(line.Amount)
+	}
+	for currency, total := range totals {
+		if !total.IsZero() {
+			return nil, nil, fmt.Errorf("%w: %s is off by %s", ErrUnbalancedJournal, currency, total)
+		}
+	}
+
+	entries := make([]Entries, len(lines))
+	changes := make(map[int64]decimal.Decimal)
+	for i, line := range lines {
+		var err error
+		entries[i], err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:  line.Account.ID,
+			Amount:     line.Amount,
+			Type:       line.Type,
+			TransferID: transferID,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		changes[line.Account.ID] = changes[line.Account.ID].Add(line.Amount)
+	}
+
+	ids := make([]int64, 0, len(changes))
+	for id := range changes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	accounts := make(map[int64]Accounts, len(ids))
+	for _, id := range ids {
+		account, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     id,
+			Amount: changes[id],
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		accounts[id] = account
+	}
+
+	return entries, accounts, nil
+}
+
+// VerifyLedger checks that the entries of every currency sum to zero across
+// customer and system accounts, returning ErrLedgerImbalanced if any do not
+func (store *SQLStore) VerifyLedger(ctx context.Context) error {
+	imbalances, err := store.ListLedgerImbalances(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(imbalances) == 0 {
+		return nil
+	}
+
+	totals := make([]string, len(imbalances))
+	for i, imbalance := range imbalances {
+		totals[i] = fmt.Sprintf("%s is off by %s", imbalance.Currency, imbalance.Total)
+	}
+	return fmt.Errorf("%w: %s", ErrLedgerImbalanced, strings.Join(totals, ", "))
+}
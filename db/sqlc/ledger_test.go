@@ -0,0 +1,280 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedRate converts between any two different currencies at the same rate
+type fixedRate decimal.Decimal
+
+func (rate fixedRate) ExchangeRateAt(ctx context.Context, fromCurrency string, toCurrency string, at time.Time) (decimal.Decimal, error) {
+	if fromCurrency == toCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+	return decimal.Decimal(rate), nil
+}
+
+func createRandomAccountIn(t *testing.T, currency string) Accounts {
+	user := createRandomUser(t)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  decimal.Zero,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.CustomerAccount, account.Kind)
+
+	return account
+}
+
+func getSystemAccount(t *testing.T, kind string, currency string) Accounts {
+	account, err := testQueries.EnsureSystemAccount(context.Background(), EnsureSystemAccountParams{
+		Owner:    util.SystemOwner,
+		Currency: currency,
+		Kind:     kind,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.SystemOwner, account.Owner)
+	require.Equal(t, kind, account.Kind)
+	require.Equal(t, currency, account.Currency)
+
+	return account
+}
+
+// entriesSince returns the entries posted to the account after the entry afterID
+func entriesSince(t *testing.T, accountID int64, afterID int64) []Entries {
+	entries, err := testQueries.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: accountID,
+		AfterID:   afterID,
+		Limit:     100,
+	})
+	require.NoError(t, err)
+	return entries
+}
+
+func lastEntryID(t *testing.T, accountID int64) int64 {
+	var lastID int64
+	for {
+		entries := entriesSince(t, accountID, lastID)
+		if len(entries) == 0 {
+			return lastID
+		}
+		lastID = entries[len(entries)-1].ID
+	}
+}
+
+func TestEnsureSystemAccount(t *testing.T) {
+	currency := util.RandomCurrency()
+
+	account1 := getSystemAccount(t, util.CashAccount, currency)
+	account2 := getSystemAccount(t, util.CashAccount, currency)
+	require.Equal(t, account1.ID, account2.ID)
+
+	account3 := getSystemAccount(t, util.FXClearingAccount, currency)
+	require.NotEqual(t, account1.ID, account3.ID)
+}
+
+func TestDepositAndWithdrawPostToCash(t *testing.T) {
+	store := NewStore(pool)
+
+	account := createRandomAccountIn(t, "USD")
+	cash := getSystemAccount(t, util.CashAccount, "USD")
+	lastID := lastEntryID(t, cash.ID)
+
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(50),
+	})
+	require.NoError(t, err)
+
+	withdrawal, err := store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(20),
+	})
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(30).Equal(withdrawal.Account.Balance))
+
+	cashEntries := entriesSince(t, cash.ID, lastID)
+	require.Len(t, cashEntries, 2)
+
+	require.Equal(t, util.DepositEntry, cashEntries[0].Type)
+	require.True(t, decimal.NewFromInt(-50).Equal(cashEntries[0].Amount))
+	require.Equal(t, util.WithdrawalEntry, cashEntries[1].Type)
+	require.True(t, decimal.NewFromInt(20).Equal(cashEntries[1].Amount))
+}
+
+func TestTransferTxPostsToFXClearing(t *testing.T) {
+	rate := decimal.RequireFromString("1.1")
+	store := NewStoreWithRates(pool, fixedRate(rate))
+
+	fromAccount := createRandomAccountIn(t, "EUR")
+	toAccount := createRandomAccountIn(t, "USD")
+
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	fromClearing := getSystemAccount(t, util.FXClearingAccount, "EUR")
+	toClearing := getSystemAccount(t, util.FXClearingAccount, "USD")
+	fromLastID := lastEntryID(t, fromClearing.ID)
+	toLastID := lastEntryID(t, toClearing.ID)
+
+	response, err := store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      "EUR",
+	})
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(-10).Equal(response.FromEntry.Amount))
+	require.True(t, decimal.NewFromInt(11).Equal(response.ToEntry.Amount))
+
+	transferID := pgtype.Int8{Int64: response.Transfer.ID, Valid: true}
+
+	clearingEntry := func(accountID int64, afterID int64) Entries {
+		var found []Entries
+		for _, entry := range entriesSince(t, accountID, afterID) {
+			if entry.TransferID == transferID {
+				found = append(found, entry)
+			}
+		}
+		require.Len(t, found, 1)
+		require.Equal(t, util.TransferEntry, found[0].Type)
+		return found[0]
+	}
+
+	// each currency balances: the bank takes the euros and pays out the dollars
+	require.True(t, decimal.NewFromInt(10).Equal(clearingEntry(fromClearing.ID, fromLastID).Amount))
+	require.True(t, decimal.NewFromInt(-11).Equal(clearingEntry(toClearing.ID, toLastID).Amount))
+}
+
+func TestTransferTxSameCurrencySkipsFXClearing(t *testing.T) {
+	store := NewStore(pool)
+
+	fromAccount := createRandomAccountIn(t, "USD")
+	toAccount := createRandomAccountIn(t, "USD")
+
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	clearing := getSystemAccount(t, util.FXClearingAccount, "USD")
+	lastID := lastEntryID(t, clearing.ID)
+
+	_, err = store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      "USD",
+	})
+	require.NoError(t, err)
+	require.Empty(t, entriesSince(t, clearing.ID, lastID))
+}
+
+func TestVerifyLedger(t *testing.T) {
+	store := NewStore(pool)
+
+	// accounts created straight through CreateAccount carry balances without
+	// entries, so only check that movements keep the totals where they were
+	before, err := store.ListLedgerImbalances(context.Background())
+	require.NoError(t, err)
+
+	account := createRandomAccountIn(t, "CAD")
+	_, err = store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(25),
+	})
+	require.NoError(t, err)
+
+	after, err := store.ListLedgerImbalances(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, len(before), len(after))
+	for i := range before {
+		require.Equal(t, before[i].Currency, after[i].Currency)
+		require.True(t, before[i].Total.Equal(after[i].Total))
+	}
+
+	err = store.VerifyLedger(context.Background())
+	if len(after) == 0 {
+		require.NoError(t, err)
+	} else {
+		require.ErrorIs(t, err, ErrLedgerImbalanced)
+	}
+}
+
+func TestPostJournalUnbalanced(t *testing.T) {
+	lines := []journalLine{
+		{Account: Accounts{ID: 1, Currency: "USD"}, Amount: decimal.NewFromInt(10), Type: util.DepositEntry},
+		{Account: Accounts{ID: 2, Currency: "USD"}, Amount: decimal.NewFromInt(-9), Type: util.DepositEntry},
+	}
+
+	// the lines are checked before anything is written
+	_, _, err := postJournal(context.Background(), nil, pgtype.Int8{}, lines)
+	require.ErrorIs(t, err, ErrUnbalancedJournal)
+}
+
+func TestTransferTxRejectsSystemAccounts(t *testing.T) {
+	store := NewStore(pool)
+	account := createFundedAccount(t, store)
+
+	for _, kind := range []string{util.CashAccount, util.FXClearingAccount, util.FeesIncomeAccount} {
+		systemAccount := getSystemAccount(t, kind, "USD")
+
+		require.ErrorIs(t, transferUSD(store, account, systemAccount, 10), ErrSystemAccount)
+		require.ErrorIs(t, transferUSD(store, systemAccount, account, 10), ErrSystemAccount)
+	}
+
+	// nothing moved
+	got, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.True(t, account.Balance.Equal(got.Balance))
+}
+
+func TestMovementsRejectFractionalXAF(t *testing.T) {
+	store := NewStore(pool)
+	fraction := decimal.RequireFromString("1.50")
+
+	account := createRandomAccountIn(t, "XAF")
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{AccountID: account.ID, Amount: decimal.NewFromInt(1000)})
+	require.NoError(t, err)
+	other := createRandomAccountIn(t, "XAF")
+
+	_, err = store.DepositTx(context.Background(), AccountTxRequest{AccountID: account.ID, Amount: fraction})
+	require.ErrorIs(t, err, ErrAmountPrecision)
+
+	_, err = store.WithdrawTx(context.Background(), AccountTxRequest{AccountID: account.ID, Amount: fraction})
+	require.ErrorIs(t, err, ErrAmountPrecision)
+
+	_, err = store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: account.ID,
+		ToAccountID:   other.ID,
+		Amount:        fraction,
+		Currency:      "XAF",
+	})
+	require.ErrorIs(t, err, ErrAmountPrecision)
+
+	_, err = store.PlaceHoldTx(context.Background(), PlaceHoldTxRequest{
+		AccountID: account.ID,
+		Amount:    fraction,
+		CreatedBy: account.Owner,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrAmountPrecision)
+
+	// nothing moved
+	got, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(1000).Equal(got.Balance))
+}
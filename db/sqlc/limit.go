@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrTransferLimitExceeded is returned when a transfer would debit more than
+	// a single, daily or monthly limit allows
+	ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+	// ErrTransferVelocityExceeded is returned when a transfer would be one too
+	// many for a velocity limit
+	ErrTransferVelocityExceeded = errors.New("too many transfers")
+)
+
+// checkTransferLimits checks debiting amount from the account keeps within the
+// limits of its owner's tier and of the account. The owner is locked first, so
+// concurrent transfers from any of their accounts are checked one at a time
+// against totals that include each other.
+func checkTransferLimits(ctx context.Context, q *Queries, account Accounts, amount decimal.Decimal, now time.Time) error {
+	owner, err := q.GetUserForUpdate(ctx, account.Owner)
+	if err != nil {
+		return err
+	}
+
+	limits, err := q.ListApplicableTransferLimits(ctx, ListApplicableTransferLimitsParams{
+		Tier:      owner.Tier,
+		AccountID: pgtype.Int8{Int64: account.ID, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, limit := range limits {
+		if limit.Kind != util.VelocityLimit && limit.Currency != account.Currency {
+			continue
+		}
+
+		arg := GetOutgoingTransferTotalsParams{
+			Owner:     owner.Username,
+			AccountID: limit.AccountID,
+		}
+
+		switch limit.Kind {
+		case util.SingleLimit:
+			if amount.GreaterThan(limit.Amount) {
+				return fmt.Errorf("%w: single transfers are limited to %s %s", ErrTransferLimitExceeded, limit.Amount, limit.Currency)
+			}
+		case util.DailyLimit, util.MonthlyLimit:
+			arg.Currency = pgtype.Text{String: limit.Currency, Valid: true}
+			arg.Since = util.LimitPeriodStart(limit.Kind, now)
+
+			totals, err := q.GetOutgoingTransferTotals(ctx, arg)
+			if err != nil {
+				return err
+			}
+
+			if totals.Amount.Add(amount).GreaterThan(limit.Amount) {
+				return fmt.Errorf("%w: %s limit of %s %s", ErrTransferLimitExceeded, limit.Kind, limit.Amount, limit.Currency)
+			}
+		case util.VelocityLimit:
+			arg.Since = now.Add(-time.Duration(limit.WindowMinutes) * time.Minute)
+
+			totals, err := q.GetOutgoingTransferTotals(ctx, arg)
+			if err != nil {
+				return err
+			}
+
+			if totals.Count >= int64(limit.MaxCount) {
+				return fmt.Errorf("%w: at most %d every %d minutes", ErrTransferVelocityExceeded, limit.MaxCount, limit.WindowMinutes)
+			}
+		}
+	}
+
+	return nil
+}
+
+type CreateTransferLimitTxRequest struct {
+	CreateTransferLimitParams
+	// Audit, when set, records the new limit in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// CreateTransferLimitTx sets a limit on the transfers of a tier or an account
+func (store *SQLStore) CreateTransferLimitTx(ctx context.Context, arg CreateTransferLimitTxRequest) (TransferLimits, error) {
+	var limit TransferLimits
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		limit, err = q.CreateTransferLimit(ctx, arg.CreateTransferLimitParams)
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("transfer_limit/%d", limit.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditTransferLimitCreate, resource, nil, limit)
+	})
+
+	return limit, err
+}
+
+type DeleteTransferLimitTxRequest struct {
+	ID int64 `json:"id"`
+	// Audit, when set, records the removal in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// DeleteTransferLimitTx removes a transfer limit
+func (store *SQLStore) DeleteTransferLimitTx(ctx context.Context, arg DeleteTransferLimitTxRequest) (TransferLimits, error) {
+	var limit TransferLimits
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		limit, err = q.DeleteTransferLimit(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("transfer_limit/%d", limit.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditTransferLimitDelete, resource, limit, nil)
+	})
+
+	return limit, err
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTransferLimit(t *testing.T, arg CreateTransferLimitParams) TransferLimits {
+	limit, err := testQueries.CreateTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Kind, limit.Kind)
+	require.True(t, arg.Amount.Equal(limit.Amount))
+
+	t.Cleanup(func() {
+		_, err := testQueries.DeleteTransferLimit(context.Background(), limit.ID)
+		require.NoError(t, err)
+	})
+
+	return limit
+}
+
+// createFundedAccount opens a USD account funded with 1000
+func createFundedAccount(t *testing.T, store Store) Accounts {
+	account := createRandomAccountIn(t, "USD")
+
+	result, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: account.ID,
+		Amount:    decimal.NewFromInt(1000),
+	})
+	require.NoError(t, err)
+
+	return result.Account
+}
+
+func transferUSD(store Store, fromAccount Accounts, toAccount Accounts, amount int64) error {
+	_, err := store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(amount),
+		Currency:      "USD",
+	})
+	return err
+}
+
+func TestTransferTxSingleLimit(t *testing.T) {
+	store := NewStore(pool)
+	fromAccount := createFundedAccount(t, store)
+	toAccount := createRandomAccountIn(t, "USD")
+
+	createRandomTransferLimit(t, CreateTransferLimitParams{
+		AccountID: pgtype.Int8{Int64: fromAccount.ID, Valid: true},
+		Kind:      util.SingleLimit,
+		Currency:  "USD",
+		Amount:    decimal.NewFromInt(100),
+	})
+
+	require.ErrorIs(t, transferUSD(store, fromAccount, toAccount, 101), ErrTransferLimitExceeded)
+	require.NoError(t, transferUSD(store, fromAccount, toAccount, 100))
+}
+
+func TestTransferTxDailyLimit(t *testing.T) {
+	store := NewStore(pool)
+	fromAccount := createFundedAccount(t, store)
+	toAccount := createRandomAccountIn(t, "USD")
+
+	createRandomTransferLimit(t, CreateTransferLimitParams{
+		AccountID: pgtype.Int8{Int64: fromAccount.ID, Valid: true},
+		Kind:      util.DailyLimit,
+		Currency:  "USD",
+		Amount:    decimal.NewFromInt(250),
+	})
+
+	require.NoError(t, transferUSD(store, fromAccount, toAccount, 100))
+	require.NoError(t, transferUSD(store, fromAccount, toAccount, 100))
+	require.ErrorIs(t, transferUSD(store, fromAccount, toAccount, 100), ErrTransferLimitExceeded)
+	require.NoError(t, transferUSD(store, fromAccount, toAccount, 50))
+}
+
+func TestTransferTxTierLimitSpansAccounts(t *testing.T) {
+	store := NewStore(pool)
+	fromAccount := createFundedAccount(t, store)
+	toAccount := createRandomAccountIn(t, "USD")
+
+	owner, err := testQueries.UpdateUserTier(context.Background(), UpdateUserTierParams{
+		Tier:     util.PremiumTier,
+		Username: fromAccount.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.PremiumTier, owner.Tier)
+
+	// a second account of the same owner
+	otherAccount, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    owner.Username,
+		Balance:  decimal.Zero,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+	_, err = store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: otherAccount.ID,
+		Amount:    decimal.NewFromInt(1000),
+	})
+	require.NoError(t, err)
+
+	createRandomTransferLimit(t, CreateTransferLimitParams{
+		Tier:     util.PremiumTier,
+		Kind:     util.MonthlyLimit,
+		Currency: "USD",
+		Amount:   decimal.NewFromInt(1500),
+	})
+
+	require.NoError(t, transferUSD(store, fromAccount, toAccount, 900))
+	require.ErrorIs(t, transferUSD(store, otherAccount, toAccount, 700), ErrTransferLimitExceeded)
+	require.NoError(t, transferUSD(store, otherAccount, toAccount, 600))
+}
+
+func TestTransferTxVelocityLimit(t *testing.T) {
+	store := NewStore(pool)
+	fromAccount := createFundedAccount(t, store)
+	toAccount := createRandomAccountIn(t, "USD")
+
+	createRandomTransferLimit(t, CreateTransferLimitParams{
+		AccountID:     pgtype.Int8{Int64: fromAccount.ID, Valid: true},
+		Kind:          util.VelocityLimit,
+		MaxCount:      3,
+		WindowMinutes: 10,
+	})
+
+	// concurrent transfers are checked one at a time, so only 3 get through
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			errs <- transferUSD(store, fromAccount, toAccount, 10)
+		}()
+	}
+
+	var succeeded int
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrTransferVelocityExceeded)
+	}
+	require.Equal(t, 3, succeeded)
+}
@@ -6,13 +6,53 @@ package db
 
 import (
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
 )
 
+type AccountHolds struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// reserved in the account currency
+	Amount decimal.Decimal `json:"amount"`
+	// active holds reserve their amount until they expire
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"created_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// the transfer that captured the hold, or the pending transfer it reserves the money of while active
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
 type Accounts struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   float64   `json:"balance"`
-	Currency  string    `json:"currency"`
+	ID        int64           `json:"id"`
+	Owner     string          `json:"owner"`
+	Balance   decimal.Decimal `json:"balance"`
+	Currency  string          `json:"currency"`
+	CreatedAt time.Time       `json:"created_at"`
+	// customer, or the system account the bank books the other side of movements to
+	Kind string `json:"kind"`
+}
+
+type AuditEvents struct {
+	ID int64 `json:"id"`
+	// user who made the change
+	Actor     string `json:"actor"`
+	Ip        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	Action    string `json:"action"`
+	// what was changed, as kind/id
+	Resource string `json:"resource"`
+	// state before the change, kept as written so the hash can be checked again; null for creations
+	Before []byte `json:"before"`
+	After  []byte `json:"after"`
+	// hash of the event before, empty for the first one
+	PrevHash []byte `json:"prev_hash"`
+	// sha256 of prev_hash and the event, so changing or removing any event breaks the chain after it
+	Hash      []byte    `json:"hash"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -20,7 +60,192 @@ type Entries struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
 	// can be negative or positive
-	Amount    float64   `json:"amount"`
+	Amount    decimal.Decimal `json:"amount"`
+	CreatedAt time.Time       `json:"created_at"`
+	Type      string          `json:"type"`
+	// the transfer that produced the entry, if any
+	TransferID pgtype.Int8 `json:"transfer_id"`
+}
+
+type ExchangeRates struct {
+	ID       int64  `json:"id"`
+	Currency string `json:"currency"`
+	// units of currency per USD
+	Rate          decimal.Decimal `json:"rate"`
+	EffectiveFrom time.Time       `json:"effective_from"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type FeeSchedules struct {
+	ID int64 `json:"id"`
+	// currency of the source accounts the fee is charged to
+	Currency string `json:"currency"`
+	Kind     string `json:"kind"`
+	// fixed fee, for flat and tiered schedules
+	FlatAmount decimal.Decimal `json:"flat_amount"`
+	// fraction of the debited amount charged, for percentage, tiered and fx_markup schedules
+	Rate decimal.Decimal `json:"rate"`
+	// tiered schedules apply from this debited amount
+	MinAmount decimal.Decimal `json:"min_amount"`
+	// tiered schedules apply below this debited amount, without limit when null
+	MaxAmount decimal.NullDecimal `json:"max_amount"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+type IdempotencyKeys struct {
+	Username string `json:"username"`
+	Key      string `json:"key"`
+	// sha256 of the method, path and body of the first request
+	RequestHash string `json:"request_hash"`
+	// 0 while the first request is still being processed
+	ResponseStatus int32     `json:"response_status"`
+	ResponseBody   []byte    `json:"response_body"`
+	CreatedAt      time.Time `json:"created_at"`
+	// a request still in progress past this time is presumed lost, and a retry may take the key over
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+type OutboxEvents struct {
+	ID            int64  `json:"id"`
+	AggregateType string `json:"aggregate_type"`
+	// events of the same aggregate are delivered one at a time, in the order they were written
+	AggregateID string `json:"aggregate_id"`
+	EventType   string `json:"event_type"`
+	Payload     []byte `json:"payload"`
+	// failed deliveries of the event
+	Attempts  int32  `json:"attempts"`
+	LastError string `json:"last_error"`
+	// when the relay next delivers the event, later than created_at while retrying
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// lease of the relay replica delivering the event
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	DeliveredAt pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+type RevokedTokens struct {
+	// id of the token payload
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// the row can be deleted once the token has expired
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type ScheduledTransferRuns struct {
+	ID                  int64       `json:"id"`
+	ScheduledTransferID int64       `json:"scheduled_transfer_id"`
+	DueAt               time.Time   `json:"due_at"`
+	Attempt             int32       `json:"attempt"`
+	Status              string      `json:"status"`
+	TransferID          pgtype.Int8 `json:"transfer_id"`
+	Error               string      `json:"error"`
+	CreatedAt           time.Time   `json:"created_at"`
+}
+
+type ScheduledTransfers struct {
+	ID            int64           `json:"id"`
+	Owner         string          `json:"owner"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	Frequency     string          `json:"frequency"`
+	// day monthly transfers run on, the last day of shorter months; 0 for other frequencies
+	DayOfMonth int32  `json:"day_of_month"`
+	Status     string `json:"status"`
+	// occurrence currently due
+	DueAt time.Time `json:"due_at"`
+	// when the executor next attempts the due occurrence, later than due_at while retrying
+	NextRunAt time.Time `json:"next_run_at"`
+	// failed attempts at the due occurrence
+	Attempts int32 `json:"attempts"`
+	// lease of the executor replica running the due occurrence
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+type Sessions struct {
+	// id of the refresh token payload
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type TransferLimits struct {
+	ID int64 `json:"id"`
+	// users of this tier are limited across all their accounts, empty for account limits
+	Tier string `json:"tier"`
+	// the account limited, null for tier limits
+	AccountID pgtype.Int8 `json:"account_id"`
+	Kind      string      `json:"kind"`
+	// currency of the source accounts amount limits apply to, empty for velocity limits
+	Currency string `json:"currency"`
+	// most that can be debited in a single transfer, a UTC day or a UTC month
+	Amount decimal.Decimal `json:"amount"`
+	// velocity limits allow at most this many transfers every window_minutes
+	MaxCount      int32     `json:"max_count"`
+	WindowMinutes int32     `json:"window_minutes"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type TransferQuotes struct {
+	ID uuid.UUID `json:"id"`
+	// user the quote was given to, the only one who can execute it
+	Username      string          `json:"username"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	FromRate      decimal.Decimal `json:"from_rate"`
+	FromAmount    decimal.Decimal `json:"from_amount"`
+	ToRate        decimal.Decimal `json:"to_rate"`
+	ToAmount      decimal.Decimal `json:"to_amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	// the transfer that executed the quote, a quote can only be executed once
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type TransferReversals struct {
+	ID int64 `json:"id"`
+	// the transfer being reversed, in full or in part
+	TransferID int64 `json:"transfer_id"`
+	// the compensating transfer, from the original recipient back to the sender at the original rates
+	ReversalTransferID int64     `json:"reversal_transfer_id"`
+	InitiatedBy        string    `json:"initiated_by"`
+	Reason             string    `json:"reason"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+type TransferReviews struct {
+	ID         int64 `json:"id"`
+	TransferID int64 `json:"transfer_id"`
+	// what the screening rules flagged, for the reviewer
+	Reasons string `json:"reasons"`
+	Status  string `json:"status"`
+	// banker who approved or rejected the transfer, empty while pending
+	ReviewedBy string             `json:"reviewed_by"`
+	Note       string             `json:"note"`
+	ReviewedAt pgtype.Timestamptz `json:"reviewed_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type TransferStatusChanges struct {
+	ID         int64 `json:"id"`
+	TransferID int64 `json:"transfer_id"`
+	// empty when the transfer was created
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Reason     string `json:"reason"`
+	// user who made the change, empty for changes made by the bank itself
+	ChangedBy string    `json:"changed_by"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -29,9 +254,23 @@ type Transfers struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// it must be positive
-	Amount    float64   `json:"amount"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount    decimal.Decimal `json:"amount"`
+	Currency  string          `json:"currency"`
+	CreatedAt time.Time       `json:"created_at"`
+	// units of the source account currency per unit of the transfer currency
+	FromRate decimal.Decimal `json:"from_rate"`
+	// amount debited from the source account, in its currency
+	FromAmount decimal.Decimal `json:"from_amount"`
+	// units of the destination account currency per unit of the transfer currency
+	ToRate decimal.Decimal `json:"to_rate"`
+	// amount credited to the destination account, in its currency
+	ToAmount decimal.Decimal `json:"to_amount"`
+	// charged to the source account on top of from_amount, in its currency
+	Fee decimal.Decimal `json:"fee"`
+	// money only moves when a transfer is completed, pending ones await approval or a hold
+	Status string `json:"status"`
+	// why a failed or cancelled transfer did not complete
+	FailureReason string `json:"failure_reason"`
 }
 
 type Users struct {
@@ -41,4 +280,41 @@ type Users struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	// tokens issued before this time are revoked
+	TokensValidAfter time.Time `json:"tokens_valid_after"`
+	Role             string    `json:"role"`
+	// picks the transfer limits that apply to the user
+	Tier string `json:"tier"`
+}
+
+type WebhookDeliveries struct {
+	ID         int64  `json:"id"`
+	EndpointID int64  `json:"endpoint_id"`
+	EventID    int64  `json:"event_id"`
+	EventType  string `json:"event_type"`
+	// body posted to the endpoint, kept as written so every attempt signs the same bytes
+	Payload  []byte `json:"payload"`
+	Status   string `json:"status"`
+	Attempts int32  `json:"attempts"`
+	// HTTP status the endpoint last answered with, 0 when it could not be reached
+	ResponseStatus int32  `json:"response_status"`
+	LastError      string `json:"last_error"`
+	// when the dispatcher next posts the delivery, later than created_at while retrying
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// lease of the dispatcher replica posting the delivery
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	DeliveredAt pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+type WebhookEndpoints struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	Url   string `json:"url"`
+	// key the deliveries to the endpoint are signed with
+	Secret string `json:"secret"`
+	// events the endpoint is subscribed to
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
 }
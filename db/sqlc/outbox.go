@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/rouclec/simplebank/util"
+)
+
+// UserEvent is the payload of user.created events
+type UserEvent struct {
+	User UserProfile `json:"user"`
+}
+
+// AccountEvent is the payload of account.created events
+type AccountEvent struct {
+	Account Accounts `json:"account"`
+}
+
+// TransferEvent is the payload of transfer.sent and transfer.received events,
+// with the account they are published on as it stands after the transfer
+type TransferEvent struct {
+	Account  Accounts  `json:"account"`
+	Transfer Transfers `json:"transfer"`
+}
+
+// publishEvent writes a domain event to the outbox, in the transaction q belongs
+// to, so the event is published if and only if the change it describes commits
+func publishEvent(ctx context.Context, q *Queries, aggregateType string, aggregateID string, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       data,
+	})
+	return err
+}
+
+// publishAccountEvent writes an event about account to the outbox
+func publishAccountEvent(ctx context.Context, q *Queries, account Accounts, eventType string, payload any) error {
+	return publishEvent(ctx, q, util.AccountAggregate, strconv.FormatInt(account.ID, 10), eventType, payload)
+}
+
+// publishTransferEvents tells both accounts of a transfer that its money moved.
+// The events are written while the accounts are locked, so the events of an
+// account are in the order its balance changed.
+func publishTransferEvents(ctx context.Context, q *Queries, response TransfersTxResponse) error {
+	err := publishAccountEvent(ctx, q, response.FromAccount, util.EventTransferSent, TransferEvent{
+		Account:  response.FromAccount,
+		Transfer: response.Transfer,
+	})
+	if err != nil {
+		return err
+	}
+
+	return publishAccountEvent(ctx, q, response.ToAccount, util.EventTransferReceived, TransferEvent{
+		Account:  response.ToAccount,
+		Transfer: response.Transfer,
+	})
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func accountOutboxEvents(t *testing.T, account Accounts) []OutboxEvents {
+	events, err := testQueries.ListOutboxEventsByAggregate(context.Background(), ListOutboxEventsByAggregateParams{
+		AggregateType: util.AccountAggregate,
+		AggregateID:   strconv.FormatInt(account.ID, 10),
+	})
+	require.NoError(t, err)
+	return events
+}
+
+func TestCreateUserTxPublishesEvent(t *testing.T) {
+	store := NewStore(pool)
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	user, err := store.CreateUserTx(context.Background(), CreateUserTxRequest{
+		CreateUserParams: CreateUserParams{
+			Username: util.RandomOwner(),
+			Password: hashedPassword,
+			FullName: util.RandomOwner(),
+			Email:    util.RandomEmail(),
+		},
+	})
+	require.NoError(t, err)
+
+	events, err := testQueries.ListOutboxEventsByAggregate(context.Background(), ListOutboxEventsByAggregateParams{
+		AggregateType: util.UserAggregate,
+		AggregateID:   user.Username,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, util.EventUserCreated, events[0].EventType)
+	require.False(t, events[0].DeliveredAt.Valid)
+	require.NotContains(t, string(events[0].Payload), hashedPassword)
+
+	var payload UserEvent
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, user.Email, payload.User.Email)
+}
+
+func TestCreateAccountTxPublishesEvent(t *testing.T) {
+	store := NewStore(pool)
+	user := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountTxRequest{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  decimal.Zero,
+			Currency: "USD",
+		},
+	})
+	require.NoError(t, err)
+
+	events := accountOutboxEvents(t, account)
+	require.Len(t, events, 1)
+	require.Equal(t, util.EventAccountCreated, events[0].EventType)
+
+	var payload AccountEvent
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, account.ID, payload.Account.ID)
+	require.Equal(t, user.Username, payload.Account.Owner)
+}
+
+func TestTransferTxPublishesEvents(t *testing.T) {
+	store := NewStore(pool)
+	fromAccount := createFundedAccount(t, store)
+	toAccount := createRandomAccountIn(t, "USD")
+
+	result, err := store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      "USD",
+	})
+	require.NoError(t, err)
+
+	sent := accountOutboxEvents(t, fromAccount)
+	require.Len(t, sent, 1)
+	require.Equal(t, util.EventTransferSent, sent[0].EventType)
+
+	var payload TransferEvent
+	require.NoError(t, json.Unmarshal(sent[0].Payload, &payload))
+	require.Equal(t, result.Transfer.ID, payload.Transfer.ID)
+	require.True(t, result.FromAccount.Balance.Equal(payload.Account.Balance))
+
+	received := accountOutboxEvents(t, toAccount)
+	require.Len(t, received, 1)
+	require.Equal(t, util.EventTransferReceived, received[0].EventType)
+	require.Greater(t, received[0].ID, sent[0].ID)
+
+	// transfers that fail, or move no money yet, publish nothing
+	require.ErrorIs(t, transferUSD(store, toAccount, fromAccount, 1000), ErrInsufficientFunds)
+
+	_, err = store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      "USD",
+		Pending:       true,
+	})
+	require.NoError(t, err)
+	require.Len(t, accountOutboxEvents(t, fromAccount), 1)
+	require.Len(t, accountOutboxEvents(t, toAccount), 1)
+}
+
+func TestClaimOutboxEvents(t *testing.T) {
+	store := NewStore(pool)
+	fromAccount := createFundedAccount(t, store)
+	toAccount := createRandomAccountIn(t, "USD")
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, transferUSD(store, fromAccount, toAccount, 1))
+	}
+	events := accountOutboxEvents(t, toAccount)
+	require.Len(t, events, 3)
+
+	claim := func(now time.Time) []int64 {
+		claimed, err := testQueries.ClaimOutboxEvents(context.Background(), ClaimOutboxEventsParams{
+			LockedUntil: pgtype.Timestamptz{Time: now.Add(time.Minute), Valid: true},
+			Now:         now,
+			Limit:       10000,
+		})
+		require.NoError(t, err)
+
+		var ids []int64
+		for _, event := range claimed {
+			if event.AggregateID == events[0].AggregateID && event.AggregateType == util.AccountAggregate {
+				ids = append(ids, event.ID)
+			}
+		}
+		return ids
+	}
+
+	// only the oldest undelivered event of the account is handed out
+	now := time.Now().Add(time.Second)
+	require.Equal(t, []int64{events[0].ID}, claim(now))
+
+	// and not again while it is leased
+	require.Empty(t, claim(now))
+
+	// a failed event holds back the ones after it until it is retried
+	_, err := testQueries.RetryOutboxEvent(context.Background(), RetryOutboxEventParams{
+		LastError:     "connection refused",
+		NextAttemptAt: now.Add(time.Hour),
+		ID:            events[0].ID,
+	})
+	require.NoError(t, err)
+	require.Empty(t, claim(now))
+	require.Equal(t, []int64{events[0].ID}, claim(now.Add(time.Hour)))
+
+	delivered, err := testQueries.MarkOutboxEventDelivered(context.Background(), events[0].ID)
+	require.NoError(t, err)
+	require.True(t, delivered.DeliveredAt.Valid)
+	require.Equal(t, int32(1), delivered.Attempts)
+
+	require.Equal(t, []int64{events[1].ID}, claim(now.Add(2*time.Hour)))
+}
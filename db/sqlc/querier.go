@@ -6,24 +6,147 @@ package db
 
 import (
 	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Accounts, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (Sessions, error)
+	CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfers, error)
+	// Leases due schedules to the caller; rows another replica is claiming are
+	// skipped rather than waited for, and leased rows are not claimed again until
+	// the lease runs out
+	ClaimDueScheduledTransfers(ctx context.Context, arg ClaimDueScheduledTransfersParams) ([]ScheduledTransfers, error)
+	// Leases due deliveries to the caller; rows another replica is claiming are
+	// skipped rather than waited for, and leased rows are not claimed again until
+	// the lease runs out
+	ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]WebhookDeliveries, error)
+	// Leases the oldest undelivered event of each aggregate that is due; later
+	// events of an aggregate wait until the ones before them are delivered, and
+	// rows another replica is claiming are skipped rather than waited for
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]OutboxEvents, error)
+	CloseTransferReview(ctx context.Context, arg CloseTransferReviewParams) (TransferReviews, error)
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (IdempotencyKeys, error)
+	CountAccountHolds(ctx context.Context, accountID int64) (int64, error)
+	CountAccounts(ctx context.Context, owner string) (int64, error)
+	CountAuditEvents(ctx context.Context, arg CountAuditEventsParams) (int64, error)
+	// CountCrossCurrencyTransfers counts the owner's transfers since the given time
+	// that moved money between accounts of different currencies
+	CountCrossCurrencyTransfers(ctx context.Context, arg CountCrossCurrencyTransfersParams) (int64, error)
+	CountEntries(ctx context.Context, arg CountEntriesParams) (int64, error)
+	// CountPayeeTransfers counts the completed transfers the owner has made to the account
+	CountPayeeTransfers(ctx context.Context, arg CountPayeeTransfersParams) (int64, error)
+	CountPendingTransferReviews(ctx context.Context) (int64, error)
+	CountScheduledTransferRuns(ctx context.Context, scheduledTransferID int64) (int64, error)
+	CountScheduledTransfers(ctx context.Context, owner string) (int64, error)
+	CountTransfers(ctx context.Context, arg CountTransfersParams) (int64, error)
+	CountWebhookDeliveries(ctx context.Context, arg CountWebhookDeliveriesParams) (int64, error)
+	CountWebhookEndpoints(ctx context.Context, owner string) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Accounts, error)
+	CreateAccountHold(ctx context.Context, arg CreateAccountHoldParams) (AccountHolds, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvents, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entries, error)
+	CreateExchangeRate(ctx context.Context, arg CreateExchangeRateParams) (ExchangeRates, error)
+	CreateFeeSchedule(ctx context.Context, arg CreateFeeScheduleParams) (FeeSchedules, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKeys, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvents, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfers, error)
+	CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRuns, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Sessions, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfers, error)
+	CreateTransferLimit(ctx context.Context, arg CreateTransferLimitParams) (TransferLimits, error)
+	CreateTransferQuote(ctx context.Context, arg CreateTransferQuoteParams) (TransferQuotes, error)
+	CreateTransferReversal(ctx context.Context, arg CreateTransferReversalParams) (TransferReversals, error)
+	CreateTransferReview(ctx context.Context, arg CreateTransferReviewParams) (TransferReviews, error)
+	CreateTransferStatusChange(ctx context.Context, arg CreateTransferStatusChangeParams) (TransferStatusChanges, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (Users, error)
+	// Queues the event for every active endpoint of owner subscribed to it;
+	// endpoints the event was already queued for are skipped
+	CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) ([]WebhookDeliveries, error)
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoints, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteExpiredRevokedTokens(ctx context.Context) error
+	DeleteFeeSchedule(ctx context.Context, id int64) (FeeSchedules, error)
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
+	DeleteTransferLimit(ctx context.Context, id int64) (TransferLimits, error)
+	DisableWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoints, error)
+	EnsureSystemAccount(ctx context.Context, arg EnsureSystemAccountParams) (Accounts, error)
 	GetAccount(ctx context.Context, id int64) (Accounts, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Accounts, error)
+	GetAccountHold(ctx context.Context, id int64) (AccountHolds, error)
+	GetAccountHoldForUpdate(ctx context.Context, id int64) (AccountHolds, error)
+	GetEntriesBalance(ctx context.Context, arg GetEntriesBalanceParams) (GetEntriesBalanceRow, error)
 	GetEntry(ctx context.Context, id int64) (Entries, error)
+	GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRates, error)
+	// GetHeldAmount sums the holds reserving money on the account at the given time
+	GetHeldAmount(ctx context.Context, arg GetHeldAmountParams) (decimal.Decimal, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKeys, error)
+	GetLastAuditEvent(ctx context.Context) (AuditEvents, error)
+	// GetOutgoingTransferTotals adds up what the owner's transfers debited since
+	// the given time, from one account or currency when set. Failed, cancelled and
+	// reversing transfers do not count.
+	GetOutgoingTransferTotals(ctx context.Context, arg GetOutgoingTransferTotalsParams) (GetOutgoingTransferTotalsRow, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfers, error)
+	GetScheduledTransferForUpdate(ctx context.Context, id int64) (ScheduledTransfers, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Sessions, error)
 	GetTransfer(ctx context.Context, id int64) (Transfers, error)
+	// GetTransferAmountStats sums up the completed transfers made from the account
+	// in the currency, for telling usual amounts from unusual ones
+	GetTransferAmountStats(ctx context.Context, arg GetTransferAmountStatsParams) (GetTransferAmountStatsRow, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfers, error)
+	// GetTransferHoldForUpdate locks the active hold reserving the money of a pending transfer
+	GetTransferHoldForUpdate(ctx context.Context, transferID pgtype.Int8) (AccountHolds, error)
+	GetTransferQuote(ctx context.Context, id uuid.UUID) (TransferQuotes, error)
+	GetTransferQuoteForUpdate(ctx context.Context, id uuid.UUID) (TransferQuotes, error)
+	GetTransferReversalTotals(ctx context.Context, transferID int64) (GetTransferReversalTotalsRow, error)
+	GetTransferReviewForUpdate(ctx context.Context, transferID int64) (TransferReviews, error)
 	GetUser(ctx context.Context, username string) (Users, error)
+	GetUserForUpdate(ctx context.Context, username string) (Users, error)
+	GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoints, error)
+	IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error)
+	IsTransferReversal(ctx context.Context, reversalTransferID int64) (bool, error)
+	ListAccountHolds(ctx context.Context, arg ListAccountHoldsParams) ([]AccountHolds, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Accounts, error)
+	ListApplicableTransferLimits(ctx context.Context, arg ListApplicableTransferLimitsParams) ([]TransferLimits, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvents, error)
+	ListBalanceMismatches(ctx context.Context) ([]ListBalanceMismatchesRow, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entries, error)
+	ListEntriesWithBalance(ctx context.Context, arg ListEntriesWithBalanceParams) ([]ListEntriesWithBalanceRow, error)
+	ListExchangeRates(ctx context.Context, arg ListExchangeRatesParams) ([]ExchangeRates, error)
+	ListFeeSchedules(ctx context.Context, currency pgtype.Text) ([]FeeSchedules, error)
+	ListLedgerImbalances(ctx context.Context) ([]ListLedgerImbalancesRow, error)
+	ListOrphanedEntries(ctx context.Context) ([]Entries, error)
+	ListOutboxEventsByAggregate(ctx context.Context, arg ListOutboxEventsByAggregateParams) ([]OutboxEvents, error)
+	// ListPendingTransferReviews lists the reviews still awaiting a banker, leaving
+	// out those whose transfer was cancelled meanwhile
+	ListPendingTransferReviews(ctx context.Context, arg ListPendingTransferReviewsParams) ([]TransferReviews, error)
+	ListScheduledTransferRuns(ctx context.Context, arg ListScheduledTransferRunsParams) ([]ScheduledTransferRuns, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfers, error)
+	ListTransferLimits(ctx context.Context, arg ListTransferLimitsParams) ([]TransferLimits, error)
+	ListTransferReversals(ctx context.Context, transferID int64) ([]TransferReversals, error)
+	ListTransferStatusChanges(ctx context.Context, transferID int64) ([]TransferStatusChanges, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfers, error)
+	ListUnpairedTransfers(ctx context.Context) ([]Transfers, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDeliveries, error)
+	ListWebhookEndpoints(ctx context.Context, arg ListWebhookEndpointsParams) ([]WebhookEndpoints, error)
+	// LockAuditChain makes other transactions wait to append to the audit log
+	// until this one ends, so each event chains onto the one committed before it
+	LockAuditChain(ctx context.Context) error
+	MarkOutboxEventDelivered(ctx context.Context, id int64) (OutboxEvents, error)
+	RecordWebhookDeliveryAttempt(ctx context.Context, arg RecordWebhookDeliveryAttemptParams) (WebhookDeliveries, error)
+	RetryOutboxEvent(ctx context.Context, arg RetryOutboxEventParams) (OutboxEvents, error)
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) (Users, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Accounts, error)
+	UpdateAccountHoldStatus(ctx context.Context, arg UpdateAccountHoldStatusParams) (AccountHolds, error)
+	UpdateScheduledTransferState(ctx context.Context, arg UpdateScheduledTransferStateParams) (ScheduledTransfers, error)
+	UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfers, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (Users, error)
+	UpdateUserTier(ctx context.Context, arg UpdateUserTierParams) (Users, error)
+	UseTransferQuote(ctx context.Context, arg UseTransferQuoteParams) (TransferQuotes, error)
 }
 
 var _ Querier = (*Queries)(nil)
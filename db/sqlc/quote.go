@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+// ErrQuoteExpired is returned when a transfer executes a quote past its expiry
+var ErrQuoteExpired = errors.New("transfer quote has expired")
+
+// ErrQuoteUsed is returned when a transfer executes a quote that already has been
+var ErrQuoteUsed = errors.New("transfer quote has already been used")
+
+// ErrQuoteMismatch is returned when a transfer does not match the quote it executes
+var ErrQuoteMismatch = errors.New("transfer does not match its quote")
+
+type QuoteTransferRequest struct {
+	Username      string          `json:"username"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	// Audit, when set, records the quote in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// transferPrice is what a transfer debits and credits at the rates and fees in force
+type transferPrice struct {
+	FromRate   decimal.Decimal
+	FromAmount decimal.Decimal
+	ToRate     decimal.Decimal
+	ToAmount   decimal.Decimal
+	Fee        decimal.Decimal
+}
+
+// QuoteTransfer prices a transfer at the current rates and fees, and records
+// the price so a transfer executing the quote before ExpiresAt gets exactly it
+func (store *SQLStore) QuoteTransfer(ctx context.Context, arg QuoteTransferRequest) (TransferQuotes, error) {
+	var quote TransferQuotes
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+		if err != nil {
+			return err
+		}
+
+		toAccount, err := q.GetAccount(ctx, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
+
+		price, err := store.priceTransfer(ctx, q, fromAccount, toAccount, arg.Amount, arg.Currency, time.Now())
+		if err != nil {
+			return err
+		}
+
+		quote, err = q.CreateTransferQuote(ctx, CreateTransferQuoteParams{
+			ID:            uuid.New(),
+			Username:      arg.Username,
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			Currency:      arg.Currency,
+			FromRate:      price.FromRate,
+			FromAmount:    price.FromAmount,
+			ToRate:        price.ToRate,
+			ToAmount:      price.ToAmount,
+			Fee:           price.Fee,
+			ExpiresAt:     arg.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		resource := "transfer_quote/" + quote.ID.String()
+		return recordAudit(ctx, q, arg.Audit, util.AuditQuoteCreate, resource, nil, quote)
+	})
+
+	return quote, err
+}
+
+// priceTransfer converts amount into both account currencies at the rates in
+// force at the given time and works out the fee charged to the source account
+func (store *SQLStore) priceTransfer(ctx context.Context, q *Queries, fromAccount Accounts, toAccount Accounts, amount decimal.Decimal, currency string, at time.Time) (transferPrice, error) {
+	var price transferPrice
+	var err error
+
+	rates := store.rateProvider(q)
+
+	price.FromRate, err = rates.ExchangeRateAt(ctx, currency, fromAccount.Currency, at)
+	if err != nil {
+		return price, err
+	}
+	price.FromAmount = util.ConvertAmount(amount, price.FromRate, fromAccount.Currency)
+
+	price.ToRate, err = rates.ExchangeRateAt(ctx, currency, toAccount.Currency, at)
+	if err != nil {
+		return price, err
+	}
+	price.ToAmount = util.ConvertAmount(amount, price.ToRate, toAccount.Currency)
+
+	// Fees are charged to the source account in its currency, on top of the amount debited
+	schedules, err := q.ListFeeSchedules(ctx, pgtype.Text{String: fromAccount.Currency, Valid: true})
+	if err != nil {
+		return price, err
+	}
+	price.Fee = transferFee(schedules, price.FromAmount, fromAccount.Currency, fromAccount.Currency != toAccount.Currency)
+
+	return price, nil
+}
+
+// quotedPrice locks the quote the transfer executes and returns its price,
+// provided the quote is still open and was given for this very transfer
+func quotedPrice(ctx context.Context, q *Queries, arg TransferTxRequest, at time.Time) (transferPrice, error) {
+	quote, err := q.GetTransferQuoteForUpdate(ctx, arg.QuoteID.UUID)
+	if err != nil {
+		return transferPrice{}, err
+	}
+
+	if quote.TransferID.Valid {
+		return transferPrice{}, ErrQuoteUsed
+	}
+	if !at.Before(quote.ExpiresAt) {
+		return transferPrice{}, ErrQuoteExpired
+	}
+	if quote.FromAccountID != arg.FromAccountID || quote.ToAccountID != arg.ToAccountID ||
+		!quote.Amount.Equal(arg.Amount) || quote.Currency != arg.Currency {
+		return transferPrice{}, ErrQuoteMismatch
+	}
+
+	return transferPrice{
+		FromRate:   quote.FromRate,
+		FromAmount: quote.FromAmount,
+		ToRate:     quote.ToRate,
+		ToAmount:   quote.ToAmount,
+		Fee:        quote.Fee,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomQuote(t *testing.T, store Store, fromAccount Accounts, toAccount Accounts, expiresAt time.Time) TransferQuotes {
+	arg := QuoteTransferRequest{
+		Username:      fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      fromAccount.Currency,
+		ExpiresAt:     expiresAt,
+	}
+
+	quote, err := store.QuoteTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEqual(t, uuid.Nil, quote.ID)
+	require.Equal(t, arg.Username, quote.Username)
+	require.Equal(t, arg.FromAccountID, quote.FromAccountID)
+	require.Equal(t, arg.ToAccountID, quote.ToAccountID)
+	require.True(t, arg.Amount.Equal(quote.Amount))
+	require.Equal(t, arg.Currency, quote.Currency)
+	require.WithinDuration(t, arg.ExpiresAt, quote.ExpiresAt, time.Second)
+	require.False(t, quote.TransferID.Valid)
+
+	return quote
+}
+
+func TestTransferTxExecutesQuote(t *testing.T) {
+	fromAccount := createRandomAccountIn(t, "EUR")
+	toAccount := createRandomAccountIn(t, "USD")
+
+	quoteStore := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	_, err := quoteStore.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	quote := createRandomQuote(t, quoteStore, fromAccount, toAccount, time.Now().Add(time.Minute))
+	require.True(t, decimal.NewFromInt(10).Equal(quote.FromAmount))
+	require.True(t, decimal.NewFromInt(11).Equal(quote.ToAmount))
+
+	// the rate moves before the quote is executed
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.2")))
+	arg := TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        quote.Amount,
+		Currency:      quote.Currency,
+		QuoteID:       uuid.NullUUID{UUID: quote.ID, Valid: true},
+	}
+
+	response, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, quote.ToRate.Equal(response.Transfer.ToRate))
+	require.True(t, quote.ToAmount.Equal(response.ToEntry.Amount))
+
+	quote, err = testQueries.GetTransferQuote(context.Background(), quote.ID)
+	require.NoError(t, err)
+	require.Equal(t, response.Transfer.ID, quote.TransferID.Int64)
+
+	// a quote is executed once
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrQuoteUsed)
+}
+
+func TestTransferTxRejectsQuote(t *testing.T) {
+	store := NewStore(pool)
+
+	fromAccount := createRandomAccountIn(t, "USD")
+	toAccount := createRandomAccountIn(t, "USD")
+	otherAccount := createRandomAccountIn(t, "USD")
+
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	expired := createRandomQuote(t, store, fromAccount, toAccount, time.Now().Add(-time.Second))
+	_, err = store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        expired.Amount,
+		Currency:      expired.Currency,
+		QuoteID:       uuid.NullUUID{UUID: expired.ID, Valid: true},
+	})
+	require.ErrorIs(t, err, ErrQuoteExpired)
+
+	quote := createRandomQuote(t, store, fromAccount, toAccount, time.Now().Add(time.Minute))
+	_, err = store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   otherAccount.ID,
+		Amount:        quote.Amount,
+		Currency:      quote.Currency,
+		QuoteID:       uuid.NullUUID{UUID: quote.ID, Valid: true},
+	})
+	require.ErrorIs(t, err, ErrQuoteMismatch)
+
+	_, err = store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        quote.Amount,
+		Currency:      quote.Currency,
+		QuoteID:       uuid.NullUUID{UUID: uuid.New(), Valid: true},
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ReconcileReport lists everything in the ledger that does not add up
+type ReconcileReport struct {
+	// accounts whose balance differs from the sum of their entries
+	BalanceMismatches []ListBalanceMismatchesRow `json:"balance_mismatches"`
+	// transfer and fee entries pointing at no transfer, or at a transfer their account is not part of
+	OrphanedEntries []Entries `json:"orphaned_entries"`
+	// transfers missing the debit or credit entry on either customer account
+	UnpairedTransfers []Transfers `json:"unpaired_transfers"`
+	// currencies whose entries do not sum to zero
+	LedgerImbalances []ListLedgerImbalancesRow `json:"ledger_imbalances"`
+}
+
+// OK returns true if reconciliation found nothing wrong
+func (report ReconcileReport) OK() bool {
+	return len(report.BalanceMismatches) == 0 &&
+		len(report.OrphanedEntries) == 0 &&
+		len(report.UnpairedTransfers) == 0 &&
+		len(report.LedgerImbalances) == 0
+}
+
+// Reconcile checks every account balance against its entries, and every
+// transfer against the entries it posted. All checks read the same snapshot,
+// so movements committing meanwhile cannot show up as false mismatches.
+func (store *SQLStore) Reconcile(ctx context.Context) (ReconcileReport, error) {
+	var report ReconcileReport
+
+	tx, err := store.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := New(tx)
+
+	report.BalanceMismatches, err = q.ListBalanceMismatches(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	report.OrphanedEntries, err = q.ListOrphanedEntries(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	report.UnpairedTransfers, err = q.ListUnpairedTransfers(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	report.LedgerImbalances, err = q.ListLedgerImbalances(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	return report, tx.Commit(ctx)
+}
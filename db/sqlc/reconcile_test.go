@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcile(t *testing.T) {
+	store := NewStore(pool)
+
+	clean := createRandomAccountIn(t, "USD")
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: clean.ID,
+		Amount:    decimal.NewFromInt(40),
+	})
+	require.NoError(t, err)
+
+	// a balance changed without an entry is a mismatch
+	broken := createRandomAccountIn(t, "USD")
+	_, err = store.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      broken.ID,
+		Balance: decimal.NewFromInt(15),
+	})
+	require.NoError(t, err)
+
+	report, err := store.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.False(t, report.OK())
+
+	mismatches := make(map[int64]ListBalanceMismatchesRow)
+	for _, mismatch := range report.BalanceMismatches {
+		mismatches[mismatch.ID] = mismatch
+	}
+
+	require.NotContains(t, mismatches, clean.ID)
+	require.Contains(t, mismatches, broken.ID)
+	require.True(t, decimal.NewFromInt(15).Equal(mismatches[broken.ID].Balance))
+	require.True(t, mismatches[broken.ID].EntriesTotal.IsZero())
+}
+
+func TestReconcileReportOK(t *testing.T) {
+	require.True(t, ReconcileReport{}.OK())
+	require.False(t, ReconcileReport{UnpairedTransfers: []Transfers{{ID: 1}}}.OK())
+}
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+// ErrTransferReversed is returned when reversing a transfer already reversed in full
+var ErrTransferReversed = errors.New("transfer has already been reversed")
+
+// ErrReversalExceedsTransfer is returned when a reversal is for more than what
+// is left of the transfer once its earlier reversals are taken off
+var ErrReversalExceedsTransfer = errors.New("reversal exceeds the amount left to reverse")
+
+// ErrReversalOfReversal is returned when reversing a transfer that is itself a reversal
+var ErrReversalOfReversal = errors.New("a reversal cannot be reversed")
+
+type ReverseTransferTxRequest struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount to reverse in the currency of the transfer, everything left to
+	// reverse when zero
+	Amount      decimal.Decimal `json:"amount"`
+	InitiatedBy string          `json:"initiated_by"`
+	Reason      string          `json:"reason"`
+	// Audit, when set, records the reversal in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+type ReverseTransferTxResponse struct {
+	TransfersTxResponse
+	Reversal TransferReversals `json:"reversal"`
+}
+
+// ReverseTransferTx moves the transfer's money back, in full or in part, with a
+// compensating transfer from the recipient to the sender. It is priced at the
+// rates of the original transfer so the conversion is undone exactly, and the
+// final reversal takes back precisely what earlier ones left. Fees are kept.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxRequest) (ReverseTransferTxResponse, error) {
+	var response ReverseTransferTxResponse
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// Locking the original serializes its reversals
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+
+		if original.Status != util.TransferCompleted {
+			return fmt.Errorf("%w: only completed transfers can be reversed", ErrInvalidTransferStatus)
+		}
+
+		isReversal, err := q.IsTransferReversal(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+		if isReversal {
+			return ErrReversalOfReversal
+		}
+
+		reversed, err := q.GetTransferReversalTotals(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+
+		remaining := original.Amount.Sub(reversed.Amount)
+		if !remaining.IsPositive() {
+			return ErrTransferReversed
+		}
+
+		amount := arg.Amount
+		if amount.IsZero() {
+			amount = remaining
+		}
+		if amount.GreaterThan(remaining) {
+			return ErrReversalExceedsTransfer
+		}
+		if err := checkPrecision(amount, original.Currency); err != nil {
+			return err
+		}
+
+		recipient, sender, err := lockAccountPair(ctx, q, original.ToAccountID, original.FromAccountID)
+		if err != nil {
+			return err
+		}
+
+		// The reversal runs the other way, so it debits what the original
+		// credited and credits what it debited
+		debit := original.ToAmount.Sub(reversed.FromAmount)
+		credit := original.FromAmount.Sub(reversed.ToAmount)
+		if !amount.Equal(remaining) {
+			debit = decimal.Min(debit, util.RoundToCurrency(original.ToAmount.Mul(amount).Div(original.Amount), recipient.Currency))
+			credit = decimal.Min(credit, util.RoundToCurrency(original.FromAmount.Mul(amount).Div(original.Amount), sender.Currency))
+		}
+
+		available, err := availableBalance(ctx, q, recipient, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if available.LessThan(debit) {
+			return ErrInsufficientFunds
+		}
+
+		transfer, err := q.CreateTransfer(ctx, CreateTransferParams{
+			FromAccountID: recipient.ID,
+			ToAccountID:   sender.ID,
+			Amount:        amount,
+			Currency:      original.Currency,
+			FromRate:      original.ToRate,
+			FromAmount:    debit,
+			ToRate:        original.FromRate,
+			ToAmount:      credit,
+			Fee:           decimal.Zero,
+			Status:        util.TransferCompleted,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordTransferCreated(ctx, q, transfer, arg.InitiatedBy); err != nil {
+			return err
+		}
+
+		response.Reversal, err = q.CreateTransferReversal(ctx, CreateTransferReversalParams{
+			TransferID:         original.ID,
+			ReversalTransferID: transfer.ID,
+			InitiatedBy:        arg.InitiatedBy,
+			Reason:             arg.Reason,
+		})
+		if err != nil {
+			return err
+		}
+
+		response.TransfersTxResponse, err = postTransfer(ctx, q, transfer, recipient, sender)
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("transfer/%d", original.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditTransferReverse, resource, original, response)
+	})
+
+	return response, err
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// createFundedTransfer transfers 10 EUR from a funded EUR account to a USD account at 1.1
+func createFundedTransfer(t *testing.T, store Store) (TransfersTxResponse, Accounts, Accounts) {
+	fromAccount := createRandomAccountIn(t, "EUR")
+	toAccount := createRandomAccountIn(t, "USD")
+
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      "EUR",
+	})
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(11).Equal(result.Transfer.ToAmount))
+
+	return result, fromAccount, toAccount
+}
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	original, fromAccount, toAccount := createFundedTransfer(t, store)
+
+	user := createRandomUser(t)
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxRequest{
+		TransferID:  original.Transfer.ID,
+		InitiatedBy: user.Username,
+		Reason:      "sent by mistake",
+	})
+	require.NoError(t, err)
+
+	// the reversal runs the other way at the original rates
+	reversal := result.Transfer
+	require.Equal(t, toAccount.ID, reversal.FromAccountID)
+	require.Equal(t, fromAccount.ID, reversal.ToAccountID)
+	require.True(t, original.Transfer.Amount.Equal(reversal.Amount))
+	require.True(t, original.Transfer.ToAmount.Equal(reversal.FromAmount))
+	require.True(t, original.Transfer.FromAmount.Equal(reversal.ToAmount))
+	require.True(t, reversal.Fee.IsZero())
+
+	require.Equal(t, original.Transfer.ID, result.Reversal.TransferID)
+	require.Equal(t, reversal.ID, result.Reversal.ReversalTransferID)
+	require.Equal(t, user.Username, result.Reversal.InitiatedBy)
+	require.Equal(t, "sent by mistake", result.Reversal.Reason)
+
+	require.True(t, decimal.NewFromInt(100).Equal(result.ToAccount.Balance))
+	require.True(t, result.FromAccount.Balance.IsZero())
+
+	// a transfer is reversed once, and a reversal is never reversed
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxRequest{
+		TransferID:  original.Transfer.ID,
+		InitiatedBy: user.Username,
+	})
+	require.ErrorIs(t, err, ErrTransferReversed)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxRequest{
+		TransferID:  reversal.ID,
+		InitiatedBy: user.Username,
+	})
+	require.ErrorIs(t, err, ErrReversalOfReversal)
+
+	require.NoError(t, store.VerifyLedger(context.Background()))
+}
+
+func TestReverseTransferTxPartially(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	original, fromAccount, _ := createFundedTransfer(t, store)
+	user := createRandomUser(t)
+
+	first, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxRequest{
+		TransferID:  original.Transfer.ID,
+		Amount:      decimal.RequireFromString("3.33"),
+		InitiatedBy: user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, decimal.RequireFromString("3.66").Equal(first.Transfer.FromAmount))
+	require.True(t, decimal.RequireFromString("3.33").Equal(first.Transfer.ToAmount))
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxRequest{
+		TransferID:  original.Transfer.ID,
+		Amount:      decimal.NewFromInt(7),
+		InitiatedBy: user.Username,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	// the rest takes back exactly what the first reversal left
+	rest, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxRequest{
+		TransferID:  original.Transfer.ID,
+		InitiatedBy: user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, decimal.RequireFromString("6.67").Equal(rest.Transfer.Amount))
+	require.True(t, decimal.RequireFromString("7.34").Equal(rest.Transfer.FromAmount))
+	require.True(t, decimal.RequireFromString("6.67").Equal(rest.Transfer.ToAmount))
+	require.True(t, rest.ToAccount.Balance.IsZero())
+
+	account, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(100).Equal(account.Balance))
+
+	reversals, err := testQueries.ListTransferReversals(context.Background(), original.Transfer.ID)
+	require.NoError(t, err)
+	require.Len(t, reversals, 2)
+}
+
+func TestReverseTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	original, _, toAccount := createFundedTransfer(t, store)
+
+	_, err := store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: toAccount.ID,
+		Amount:    decimal.NewFromInt(5),
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxRequest{
+		TransferID:  original.Transfer.ID,
+		InitiatedBy: toAccount.Owner,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	totals, err := testQueries.GetTransferReversalTotals(context.Background(), original.Transfer.ID)
+	require.NoError(t, err)
+	require.True(t, totals.Amount.IsZero())
+}
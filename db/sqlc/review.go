@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+// ErrReviewClosed is returned when deciding a transfer review that was already decided
+var ErrReviewClosed = errors.New("transfer review has already been decided")
+
+// ErrTransferBlocked is returned when screening refuses a transfer outright
+var ErrTransferBlocked = errors.New("transfer blocked by screening")
+
+// TransferScreener judges every transfer TransferTx makes before any money moves,
+// whether a customer, a hold capture or a schedule asked for it
+type TransferScreener interface {
+	// ScreenTransferTx returns why the transfer must wait for a banker's review,
+	// nothing when it may go through, or an error wrapping ErrTransferBlocked
+	// to refuse it
+	ScreenTransferTx(ctx context.Context, fromAccount Accounts, toAccount Accounts, amount decimal.Decimal, currency string) ([]string, error)
+}
+
+// screenTransfer runs the store's screener on a transfer between the locked
+// accounts, and returns every reason it has to be reviewed
+func (store *SQLStore) screenTransfer(ctx context.Context, arg TransferTxRequest, fromAccount Accounts, toAccount Accounts) (string, error) {
+	var reasons []string
+	if arg.ReviewReasons != "" {
+		reasons = append(reasons, arg.ReviewReasons)
+	}
+
+	if store.screener != nil {
+		flagged, err := store.screener.ScreenTransferTx(ctx, fromAccount, toAccount, arg.Amount, arg.Currency)
+		if err != nil {
+			return "", err
+		}
+		reasons = append(reasons, flagged...)
+	}
+
+	return strings.Join(reasons, "; "), nil
+}
+
+type ReviewTransferTxRequest struct {
+	TransferID int64 `json:"transfer_id"`
+	// Approve completes the transfer, otherwise it fails
+	Approve    bool   `json:"approve"`
+	ReviewedBy string `json:"reviewed_by"`
+	Note       string `json:"note"`
+	// Audit, when set, records the decision in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+type ReviewTransferTxResponse struct {
+	TransfersTxResponse
+	Review TransferReviews `json:"review"`
+}
+
+// ReviewTransferTx decides a transfer queued for review. Approving it moves the
+// money like UpdateTransferStatusTx would, capturing the hold that reserved it;
+// rejecting it fails the transfer with the reviewer's note and releases the hold.
+func (store *SQLStore) ReviewTransferTx(ctx context.Context, arg ReviewTransferTxRequest) (ReviewTransferTxResponse, error) {
+	var response ReviewTransferTxResponse
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		review, err := q.GetTransferReviewForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+
+		if review.Status != util.ReviewPending {
+			return ErrReviewClosed
+		}
+
+		status, decision, action := util.TransferFailed, util.ReviewRejected, util.AuditTransferReject
+		if arg.Approve {
+			status, decision, action = util.TransferCompleted, util.ReviewApproved, util.AuditTransferApprove
+		}
+
+		reason := arg.Note
+		if reason == "" {
+			reason = "transfer " + decision + " on review"
+		}
+
+		response.TransfersTxResponse, err = changeTransferStatus(ctx, q, UpdateTransferStatusTxRequest{
+			TransferID: arg.TransferID,
+			Status:     status,
+			Reason:     reason,
+			ChangedBy:  arg.ReviewedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		response.Review, err = q.CloseTransferReview(ctx, CloseTransferReviewParams{
+			Status:     decision,
+			ReviewedBy: arg.ReviewedBy,
+			Note:       arg.Note,
+			ID:         review.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("transfer/%d", arg.TransferID)
+		return recordAudit(ctx, q, arg.Audit, action, resource, review, response)
+	})
+
+	return response, err
+}
@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// createReviewedTransfer records 100 USD from a funded account held for review
+func createReviewedTransfer(t *testing.T, store Store) (TransfersTxResponse, Accounts) {
+	fromAccount := createFundedAccount(t, store)
+	toAccount := createRandomAccountIn(t, "USD")
+
+	result, err := store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(100),
+		Currency:      "USD",
+		ReviewReasons: "unusual amount",
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPending, result.Transfer.Status)
+	require.Empty(t, result.FromEntry)
+
+	return result, fromAccount
+}
+
+func TestTransferTxQueuesReview(t *testing.T) {
+	store := NewStore(pool)
+	result, fromAccount := createReviewedTransfer(t, store)
+
+	review, err := testQueries.GetTransferReviewForUpdate(context.Background(), result.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, "unusual amount", review.Reasons)
+	require.Equal(t, util.ReviewPending, review.Status)
+	require.False(t, review.ReviewedAt.Valid)
+
+	from, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(1000).Equal(from.Balance))
+
+	// the amount under review is held back from the sender
+	require.NotNil(t, result.Hold)
+	require.Equal(t, util.HoldActive, result.Hold.Status)
+	require.True(t, decimal.NewFromInt(100).Equal(result.Hold.Amount))
+
+	_, err = store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(901),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	reviews, err := testQueries.ListPendingTransferReviews(context.Background(), ListPendingTransferReviewsParams{
+		AfterID: review.ID - 1,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	require.Equal(t, review.ID, reviews[0].ID)
+}
+
+func TestReviewTransferTxApproves(t *testing.T) {
+	store := NewStore(pool)
+	result, _ := createReviewedTransfer(t, store)
+	banker := createRandomUser(t)
+
+	reviewed, err := store.ReviewTransferTx(context.Background(), ReviewTransferTxRequest{
+		TransferID: result.Transfer.ID,
+		Approve:    true,
+		ReviewedBy: banker.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferCompleted, reviewed.Transfer.Status)
+	require.True(t, decimal.NewFromInt(900).Equal(reviewed.FromAccount.Balance))
+	require.True(t, decimal.NewFromInt(100).Equal(reviewed.ToAccount.Balance))
+	require.Equal(t, util.HoldCaptured, reviewed.Hold.Status)
+
+	require.Equal(t, util.ReviewApproved, reviewed.Review.Status)
+	require.Equal(t, banker.Username, reviewed.Review.ReviewedBy)
+	require.WithinDuration(t, time.Now(), reviewed.Review.ReviewedAt.Time, time.Minute)
+
+	changes, err := testQueries.ListTransferStatusChanges(context.Background(), result.Transfer.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, banker.Username, changes[1].ChangedBy)
+
+	// a review is decided once
+	_, err = store.ReviewTransferTx(context.Background(), ReviewTransferTxRequest{
+		TransferID: result.Transfer.ID,
+		ReviewedBy: banker.Username,
+	})
+	require.ErrorIs(t, err, ErrReviewClosed)
+}
+
+func TestReviewTransferTxRejects(t *testing.T) {
+	store := NewStore(pool)
+	result, fromAccount := createReviewedTransfer(t, store)
+	banker := createRandomUser(t)
+
+	reviewed, err := store.ReviewTransferTx(context.Background(), ReviewTransferTxRequest{
+		TransferID: result.Transfer.ID,
+		ReviewedBy: banker.Username,
+		Note:       "payee flagged by the bank",
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferFailed, reviewed.Transfer.Status)
+	require.Equal(t, "payee flagged by the bank", reviewed.Transfer.FailureReason)
+	require.Equal(t, util.ReviewRejected, reviewed.Review.Status)
+	require.Equal(t, util.HoldReleased, reviewed.Hold.Status)
+
+	from, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(1000).Equal(from.Balance))
+
+	// rejecting the transfer frees the money it reserved
+	_, err = store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(1000),
+	})
+	require.NoError(t, err)
+}
+
+func TestReviewTransferTxCancelledTransfer(t *testing.T) {
+	store := NewStore(pool)
+	result, fromAccount := createReviewedTransfer(t, store)
+
+	_, err := store.UpdateTransferStatusTx(context.Background(), UpdateTransferStatusTxRequest{
+		TransferID: result.Transfer.ID,
+		Status:     util.TransferCancelled,
+		ChangedBy:  fromAccount.Owner,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReviewTransferTx(context.Background(), ReviewTransferTxRequest{
+		TransferID: result.Transfer.ID,
+		Approve:    true,
+	})
+	require.ErrorIs(t, err, ErrInvalidTransferStatus)
+
+	// the review stays open, but a cancelled transfer is out of the queue
+	count, err := testQueries.CountPendingTransferReviews(context.Background())
+	require.NoError(t, err)
+
+	reviews, err := testQueries.ListPendingTransferReviews(context.Background(), ListPendingTransferReviewsParams{
+		Limit: int32(count) + 1,
+	})
+	require.NoError(t, err)
+	for _, review := range reviews {
+		require.NotEqual(t, result.Transfer.ID, review.TransferID)
+	}
+}
+
+func TestTransferScreeningQueries(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	fromAccount := createFundedAccount(t, store)
+	toAccount := createRandomAccountIn(t, "USD")
+	eurAccount := createRandomAccountIn(t, "EUR")
+	since := time.Now().Add(-time.Minute)
+
+	paid, err := testQueries.CountPayeeTransfers(context.Background(), CountPayeeTransfersParams{
+		Owner:       fromAccount.Owner,
+		ToAccountID: toAccount.ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, paid)
+
+	require.NoError(t, transferUSD(store, fromAccount, toAccount, 10))
+	require.NoError(t, transferUSD(store, fromAccount, toAccount, 30))
+	require.NoError(t, transferUSD(store, fromAccount, eurAccount, 20))
+
+	paid, err = testQueries.CountPayeeTransfers(context.Background(), CountPayeeTransfersParams{
+		Owner:       fromAccount.Owner,
+		ToAccountID: toAccount.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), paid)
+
+	stats, err := testQueries.GetTransferAmountStats(context.Background(), GetTransferAmountStatsParams{
+		FromAccountID: fromAccount.ID,
+		Currency:      "USD",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), stats.Count)
+	require.True(t, decimal.NewFromInt(20).Equal(stats.Average))
+
+	hops, err := testQueries.CountCrossCurrencyTransfers(context.Background(), CountCrossCurrencyTransfersParams{
+		Owner: fromAccount.Owner,
+		Since: since,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), hops)
+}
+
+// stubScreener flags every transfer the same way
+type stubScreener struct {
+	reasons []string
+	err     error
+}
+
+func (screener stubScreener) ScreenTransferTx(ctx context.Context, fromAccount Accounts, toAccount Accounts, amount decimal.Decimal, currency string) ([]string, error) {
+	return screener.reasons, screener.err
+}
+
+func TestCaptureHoldTxIsScreened(t *testing.T) {
+	blocked := fmt.Errorf("%w: new payee soon after a password change", ErrTransferBlocked)
+	store := NewStoreWithOptions(pool, StoreOptions{Screener: stubScreener{err: blocked}})
+	hold, account := createFundedHold(t, store, time.Now().Add(time.Hour))
+	toAccount := createRandomAccountIn(t, "USD")
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxRequest{
+		HoldID:      hold.ID,
+		ToAccountID: toAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrTransferBlocked)
+
+	// the hold is left as it was and no money moved
+	held, err := testQueries.GetAccountHold(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.HoldActive, held.Status)
+
+	from, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(100).Equal(from.Balance))
+}
+
+func TestScheduledTransferIsScreened(t *testing.T) {
+	store := NewStoreWithOptions(pool, StoreOptions{Screener: stubScreener{reasons: []string{"unusual amount"}}})
+	fromAccount := createFundedAccount(t, store)
+	toAccount := createRandomAccountIn(t, "USD")
+	schedule := createRandomScheduledTransfer(t, fromAccount, toAccount, util.OnceFrequency, time.Now().Add(-time.Minute))
+
+	result, err := store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID:       schedule.FromAccountID,
+		ToAccountID:         schedule.ToAccountID,
+		Amount:              schedule.Amount,
+		Currency:            schedule.Currency,
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        schedule.DueAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPending, result.Transfer.Status)
+	require.Empty(t, result.FromEntry)
+
+	review, err := testQueries.GetTransferReviewForUpdate(context.Background(), result.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, "unusual amount", review.Reasons)
+	require.Equal(t, util.ReviewPending, review.Status)
+}
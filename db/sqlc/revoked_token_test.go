@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeToken(t *testing.T) {
+	user := createRandomUser(t)
+	arg := IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user.Username,
+		IssuedAt: time.Now(),
+	}
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	revokeArg := RevokeTokenParams{
+		ID:        arg.ID,
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	require.NoError(t, testQueries.RevokeToken(context.Background(), revokeArg))
+
+	// revoking twice is harmless
+	require.NoError(t, testQueries.RevokeToken(context.Background(), revokeArg))
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	user1 := createRandomUser(t)
+	oldToken := IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user1.Username,
+		IssuedAt: time.Now().Add(-time.Minute),
+	}
+
+	user2, err := testQueries.RevokeUserTokens(context.Background(), RevokeUserTokensParams{
+		TokensValidAfter: time.Now(),
+		Username:         user1.Username,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), user2.TokensValidAfter, time.Second)
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), oldToken)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	newToken := IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user1.Username,
+		IssuedAt: time.Now().Add(time.Second),
+	}
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), newToken)
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestDeleteExpiredRevokedTokens(t *testing.T) {
+	user := createRandomUser(t)
+	arg := RevokeTokenParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+	require.NoError(t, testQueries.RevokeToken(context.Background(), arg))
+
+	require.NoError(t, testQueries.DeleteExpiredRevokedTokens(context.Background()))
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), IsTokenRevokedParams{
+		ID:       arg.ID,
+		Username: user.Username,
+		IssuedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+)
+
+// ErrScheduleNotDue is returned when running an occurrence of a scheduled
+// transfer that has already run, or whose schedule is no longer active
+var ErrScheduleNotDue = errors.New("scheduled transfer occurrence is not due")
+
+type CreateScheduledTransferTxRequest struct {
+	CreateScheduledTransferParams
+	// Audit, when set, records the new schedule in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// CreateScheduledTransferTx sets up a transfer to run from its first due time on
+func (store *SQLStore) CreateScheduledTransferTx(ctx context.Context, arg CreateScheduledTransferTxRequest) (ScheduledTransfers, error) {
+	var schedule ScheduledTransfers
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		schedule, err = q.CreateScheduledTransfer(ctx, arg.CreateScheduledTransferParams)
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("scheduled_transfer/%d", schedule.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditScheduleCreate, resource, nil, schedule)
+	})
+
+	return schedule, err
+}
+
+type CancelScheduledTransferTxRequest struct {
+	ID int64 `json:"id"`
+	// Audit, when set, records the cancellation in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// CancelScheduledTransferTx stops an active schedule from running any further
+// transfer, failing with ErrRecordNotFound when it is not active
+func (store *SQLStore) CancelScheduledTransferTx(ctx context.Context, arg CancelScheduledTransferTxRequest) (ScheduledTransfers, error) {
+	var schedule ScheduledTransfers
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetScheduledTransferForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		schedule, err = q.CancelScheduledTransfer(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("scheduled_transfer/%d", schedule.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditScheduleCancel, resource, before, schedule)
+	})
+
+	return schedule, err
+}
+
+type FailScheduledTransferTxRequest struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	Error               string    `json:"error"`
+	// RetryAt, when set, tries the occurrence again then, otherwise it is given
+	// up and the schedule moves on to its next occurrence
+	RetryAt time.Time `json:"retry_at"`
+}
+
+type ScheduledTransferTxResponse struct {
+	ScheduledTransfer ScheduledTransfers    `json:"scheduled_transfer"`
+	Run               ScheduledTransferRuns `json:"run"`
+}
+
+// FailScheduledTransferTx records a failed attempt at the occurrence of a
+// scheduled transfer due at ScheduledFor, and reschedules it
+func (store *SQLStore) FailScheduledTransferTx(ctx context.Context, arg FailScheduledTransferTxRequest) (ScheduledTransferTxResponse, error) {
+	var response ScheduledTransferTxResponse
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		schedule, err := lockDueSchedule(ctx, q, arg.ScheduledTransferID, arg.ScheduledFor)
+		if err != nil {
+			return err
+		}
+
+		status := util.RunFailed
+		if !arg.RetryAt.IsZero() {
+			status = util.RunRetrying
+		}
+
+		response.Run, err = q.CreateScheduledTransferRun(ctx, CreateScheduledTransferRunParams{
+			ScheduledTransferID: schedule.ID,
+			DueAt:               schedule.DueAt,
+			Attempt:             schedule.Attempts + 1,
+			Status:              status,
+			Error:               arg.Error,
+		})
+		if err != nil {
+			return err
+		}
+
+		if status == util.RunRetrying {
+			response.ScheduledTransfer, err = q.UpdateScheduledTransferState(ctx, UpdateScheduledTransferStateParams{
+				Status:    util.ScheduleActive,
+				DueAt:     schedule.DueAt,
+				NextRunAt: arg.RetryAt,
+				Attempts:  schedule.Attempts + 1,
+				ID:        schedule.ID,
+			})
+			return err
+		}
+
+		response.ScheduledTransfer, err = advanceSchedule(ctx, q, schedule, util.ScheduleFailed)
+		return err
+	})
+
+	return response, err
+}
+
+// lockDueSchedule locks a scheduled transfer, checking its occurrence due at
+// dueAt is still waiting to run
+func lockDueSchedule(ctx context.Context, q *Queries, id int64, dueAt time.Time) (ScheduledTransfers, error) {
+	schedule, err := q.GetScheduledTransferForUpdate(ctx, id)
+	if err != nil {
+		return schedule, err
+	}
+
+	if schedule.Status != util.ScheduleActive || !schedule.DueAt.Equal(dueAt) {
+		return schedule, ErrScheduleNotDue
+	}
+
+	return schedule, nil
+}
+
+// completeScheduledRun records the transfer as the successful run of the
+// schedule's due occurrence and moves the schedule on
+func completeScheduledRun(ctx context.Context, q *Queries, schedule ScheduledTransfers, transferID int64) (ScheduledTransferRuns, error) {
+	run, err := q.CreateScheduledTransferRun(ctx, CreateScheduledTransferRunParams{
+		ScheduledTransferID: schedule.ID,
+		DueAt:               schedule.DueAt,
+		Attempt:             schedule.Attempts + 1,
+		Status:              util.RunSucceeded,
+		TransferID:          pgtype.Int8{Int64: transferID, Valid: true},
+	})
+	if err != nil {
+		return run, err
+	}
+
+	_, err = advanceSchedule(ctx, q, schedule, util.ScheduleCompleted)
+	return run, err
+}
+
+// advanceSchedule moves the schedule on to its next occurrence, or leaves it
+// in the final status when it does not recur
+func advanceSchedule(ctx context.Context, q *Queries, schedule ScheduledTransfers, final string) (ScheduledTransfers, error) {
+	arg := UpdateScheduledTransferStateParams{
+		Status:    final,
+		DueAt:     schedule.DueAt,
+		NextRunAt: schedule.DueAt,
+		ID:        schedule.ID,
+	}
+
+	if next, ok := util.NextRun(schedule.Frequency, schedule.DayOfMonth, schedule.DueAt); ok {
+		arg.Status = util.ScheduleActive
+		arg.DueAt = next
+		arg.NextRunAt = next
+	}
+
+	return q.UpdateScheduledTransferState(ctx, arg)
+}
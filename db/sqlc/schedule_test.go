@@ -0,0 +1,195 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomScheduledTransfer(t *testing.T, fromAccount Accounts, toAccount Accounts, frequency string, dueAt time.Time) ScheduledTransfers {
+	arg := CreateScheduledTransferParams{
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      fromAccount.Currency,
+		Frequency:     frequency,
+		DueAt:         dueAt,
+	}
+	if frequency == util.MonthlyFrequency {
+		arg.DayOfMonth = int32(dueAt.UTC().Day())
+	}
+
+	schedule, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, schedule.ID)
+	require.Equal(t, arg.Owner, schedule.Owner)
+	require.Equal(t, arg.Frequency, schedule.Frequency)
+	require.Equal(t, util.ScheduleActive, schedule.Status)
+	require.WithinDuration(t, dueAt, schedule.DueAt, time.Millisecond)
+	require.Equal(t, schedule.DueAt, schedule.NextRunAt)
+	require.Zero(t, schedule.Attempts)
+	require.False(t, schedule.LockedUntil.Valid)
+
+	return schedule
+}
+
+func TestClaimDueScheduledTransfers(t *testing.T) {
+	fromAccount := createRandomAccountIn(t, "USD")
+	toAccount := createRandomAccountIn(t, "USD")
+
+	now := time.Now()
+	due := createRandomScheduledTransfer(t, fromAccount, toAccount, util.OnceFrequency, now.Add(-time.Minute))
+	later := createRandomScheduledTransfer(t, fromAccount, toAccount, util.OnceFrequency, now.Add(time.Hour))
+
+	claim := func() map[int64]ScheduledTransfers {
+		schedules, err := testQueries.ClaimDueScheduledTransfers(context.Background(), ClaimDueScheduledTransfersParams{
+			LockedUntil: pgtype.Timestamptz{Time: now.Add(time.Minute), Valid: true},
+			Now:         now,
+			Limit:       1000,
+		})
+		require.NoError(t, err)
+
+		claimed := map[int64]ScheduledTransfers{}
+		for _, schedule := range schedules {
+			claimed[schedule.ID] = schedule
+		}
+		return claimed
+	}
+
+	claimed := claim()
+	require.Contains(t, claimed, due.ID)
+	require.NotContains(t, claimed, later.ID)
+	require.True(t, claimed[due.ID].LockedUntil.Valid)
+
+	// leased schedules are left to the replica running them
+	require.NotContains(t, claim(), due.ID)
+}
+
+func TestTransferTxRunsScheduledTransfer(t *testing.T) {
+	store := NewStore(pool)
+
+	fromAccount := createRandomAccountIn(t, "USD")
+	toAccount := createRandomAccountIn(t, "USD")
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	schedule := createRandomScheduledTransfer(t, fromAccount, toAccount, util.MonthlyFrequency, time.Now().Add(-time.Minute))
+
+	arg := TransferTxRequest{
+		FromAccountID:       schedule.FromAccountID,
+		ToAccountID:         schedule.ToAccountID,
+		Amount:              schedule.Amount,
+		Currency:            schedule.Currency,
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        schedule.DueAt,
+	}
+
+	response, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotNil(t, response.ScheduledRun)
+	require.Equal(t, util.RunSucceeded, response.ScheduledRun.Status)
+	require.Equal(t, int32(1), response.ScheduledRun.Attempt)
+	require.Equal(t, response.Transfer.ID, response.ScheduledRun.TransferID.Int64)
+
+	// the schedule moved on to next month
+	advanced, err := testQueries.GetScheduledTransfer(context.Background(), schedule.ID)
+	require.NoError(t, err)
+	next, _ := util.NextRun(schedule.Frequency, schedule.DayOfMonth, schedule.DueAt)
+	require.Equal(t, util.ScheduleActive, advanced.Status)
+	require.True(t, next.Equal(advanced.DueAt))
+	require.True(t, next.Equal(advanced.NextRunAt))
+	require.False(t, advanced.LockedUntil.Valid)
+
+	// the occurrence does not run twice
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrScheduleNotDue)
+
+	account, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(90).Equal(account.Balance))
+}
+
+func TestFailScheduledTransferTx(t *testing.T) {
+	store := NewStore(pool)
+
+	fromAccount := createRandomAccountIn(t, "USD")
+	toAccount := createRandomAccountIn(t, "USD")
+	schedule := createRandomScheduledTransfer(t, fromAccount, toAccount, util.OnceFrequency, time.Now().Add(-time.Minute))
+
+	// nothing to transfer from, so the transfer is refused and nothing is recorded
+	_, err := store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID:       schedule.FromAccountID,
+		ToAccountID:         schedule.ToAccountID,
+		Amount:              schedule.Amount,
+		Currency:            schedule.Currency,
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        schedule.DueAt,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	retryAt := time.Now().Add(time.Minute)
+	retried, err := store.FailScheduledTransferTx(context.Background(), FailScheduledTransferTxRequest{
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        schedule.DueAt,
+		Error:               "connection reset",
+		RetryAt:             retryAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.RunRetrying, retried.Run.Status)
+	require.Equal(t, "connection reset", retried.Run.Error)
+	require.False(t, retried.Run.TransferID.Valid)
+	require.Equal(t, util.ScheduleActive, retried.ScheduledTransfer.Status)
+	require.Equal(t, int32(1), retried.ScheduledTransfer.Attempts)
+	require.True(t, schedule.DueAt.Equal(retried.ScheduledTransfer.DueAt))
+	require.WithinDuration(t, retryAt, retried.ScheduledTransfer.NextRunAt, time.Millisecond)
+
+	failed, err := store.FailScheduledTransferTx(context.Background(), FailScheduledTransferTxRequest{
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        schedule.DueAt,
+		Error:               ErrInsufficientFunds.Error(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.RunFailed, failed.Run.Status)
+	require.Equal(t, int32(2), failed.Run.Attempt)
+	// a one-off schedule has nothing left to run
+	require.Equal(t, util.ScheduleFailed, failed.ScheduledTransfer.Status)
+
+	runs, err := testQueries.ListScheduledTransferRuns(context.Background(), ListScheduledTransferRunsParams{
+		ScheduledTransferID: schedule.ID,
+		Limit:               10,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	_, err = store.FailScheduledTransferTx(context.Background(), FailScheduledTransferTxRequest{
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        schedule.DueAt,
+	})
+	require.ErrorIs(t, err, ErrScheduleNotDue)
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	fromAccount := createRandomAccountIn(t, "USD")
+	toAccount := createRandomAccountIn(t, "USD")
+	schedule := createRandomScheduledTransfer(t, fromAccount, toAccount, util.WeeklyFrequency, time.Now().Add(time.Hour))
+
+	cancelled, err := testQueries.CancelScheduledTransfer(context.Background(), schedule.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.ScheduleCancelled, cancelled.Status)
+
+	_, err = testQueries.CancelScheduledTransfer(context.Background(), schedule.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	count, err := testQueries.CountScheduledTransfers(context.Background(), fromAccount.Owner)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+}
@@ -3,26 +3,101 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
 )
 
 type Store interface {
 	Querier
+	RateProvider
 	TransferTx(ctx context.Context, arg TransferTxRequest) (TransfersTxResponse, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxRequest) (ReverseTransferTxResponse, error)
+	UpdateTransferStatusTx(ctx context.Context, arg UpdateTransferStatusTxRequest) (TransfersTxResponse, error)
+	ReviewTransferTx(ctx context.Context, arg ReviewTransferTxRequest) (ReviewTransferTxResponse, error)
+	DepositTx(ctx context.Context, arg AccountTxRequest) (AccountTxResponse, error)
+	WithdrawTx(ctx context.Context, arg AccountTxRequest) (AccountTxResponse, error)
+	PlaceHoldTx(ctx context.Context, arg PlaceHoldTxRequest) (AccountHolds, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxRequest) (TransfersTxResponse, error)
+	ReleaseHoldTx(ctx context.Context, arg ReleaseHoldTxRequest) (AccountHolds, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxRequest) (Users, error)
+	CreateSessionTx(ctx context.Context, arg CreateSessionTxRequest) (Sessions, error)
+	BlockSessionTx(ctx context.Context, arg BlockSessionTxRequest) (Sessions, error)
+	RevokeUserTokensTx(ctx context.Context, arg RevokeUserTokensTxRequest) (Users, error)
+	UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleTxRequest) (Users, error)
+	UpdateUserTierTx(ctx context.Context, arg UpdateUserTierTxRequest) (Users, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxRequest) (Accounts, error)
+	CreateFeeScheduleTx(ctx context.Context, arg CreateFeeScheduleTxRequest) (FeeSchedules, error)
+	DeleteFeeScheduleTx(ctx context.Context, arg DeleteFeeScheduleTxRequest) (FeeSchedules, error)
+	CreateTransferLimitTx(ctx context.Context, arg CreateTransferLimitTxRequest) (TransferLimits, error)
+	DeleteTransferLimitTx(ctx context.Context, arg DeleteTransferLimitTxRequest) (TransferLimits, error)
+	CreateWebhookEndpointTx(ctx context.Context, arg CreateWebhookEndpointTxRequest) (WebhookEndpoints, error)
+	DisableWebhookEndpointTx(ctx context.Context, arg DisableWebhookEndpointTxRequest) (WebhookEndpoints, error)
+	QuoteTransfer(ctx context.Context, arg QuoteTransferRequest) (TransferQuotes, error)
+	CreateScheduledTransferTx(ctx context.Context, arg CreateScheduledTransferTxRequest) (ScheduledTransfers, error)
+	CancelScheduledTransferTx(ctx context.Context, arg CancelScheduledTransferTxRequest) (ScheduledTransfers, error)
+	FailScheduledTransferTx(ctx context.Context, arg FailScheduledTransferTxRequest) (ScheduledTransferTxResponse, error)
+	VerifyLedger(ctx context.Context) error
+	VerifyAuditChain(ctx context.Context) (AuditChainReport, error)
+	Reconcile(ctx context.Context) (ReconcileReport, error)
+}
+
+// RateProvider supplies the exchange rates applied to cross-currency transfers
+type RateProvider interface {
+	// ExchangeRateAt returns how many units of toCurrency one unit of fromCurrency buys at the given time
+	ExchangeRateAt(ctx context.Context, fromCurrency string, toCurrency string, at time.Time) (decimal.Decimal, error)
 }
 
 type SQLStore struct {
 	*Queries
 	pool *pgxpool.Pool
+	// rates is consulted by TransferTx when set, otherwise the exchange_rates table is read inside the transaction
+	rates RateProvider
+	// screener judges every transfer when set, otherwise only the transfers asking for review are reviewed
+	screener TransferScreener
+}
+
+// StoreOptions plugs the services a store consults into it, any of them can be left out
+type StoreOptions struct {
+	// Rates supplies the exchange rates applied to cross-currency transfers
+	Rates RateProvider
+	// Screener judges every transfer before any money moves
+	Screener TransferScreener
 }
 
 type TransferTxRequest struct {
-	FromAccountID int64   `json:"from_account_id"`
-	ToAccountID   int64   `json:"to_account_id"`
-	Amount        float64 `json:"amount"`
-	Currency      string  `json:"currency"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	// QuoteID, when set, makes the transfer execute that quote at its price
+	QuoteID uuid.NullUUID `json:"quote_id"`
+	// ScheduledTransferID, when set, runs the transfer as the occurrence of that
+	// schedule due at ScheduledFor, so each occurrence moves money at most once
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	// Pending records the priced transfer without moving any money, until
+	// UpdateTransferStatusTx completes it. A hold reserves the money meanwhile.
+	Pending bool `json:"pending"`
+	// HoldID, when set, captures that hold on the source account, spending the
+	// money it reserved
+	HoldID int64 `json:"hold_id"`
+	// ReviewReasons, when set, records the transfer pending and queues it for a
+	// banker to approve or reject with ReviewTransferTx, along with whatever the
+	// store's screener flags
+	ReviewReasons string `json:"review_reasons"`
+	// Audit, when set, records the transfer in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// transferAccounts is what the audit log keeps of the accounts before a transfer
+type transferAccounts struct {
+	FromAccount Accounts `json:"from_account"`
+	ToAccount   Accounts `json:"to_account"`
 }
 
 type TransfersTxResponse struct {
@@ -31,10 +106,15 @@ type TransfersTxResponse struct {
 	ToAccount   Accounts  `json:"to_account"`
 	FromEntry   Entries   `json:"from_entry"`
 	ToEntry     Entries   `json:"to_entry"`
+	// FeeEntry debits the transfer's fee from the source account, nil when no fee applies
+	FeeEntry *Entries `json:"fee_entry,omitempty"`
+	// ScheduledRun records the transfer against its schedule, nil for transfers not run by one
+	ScheduledRun *ScheduledTransferRuns `json:"scheduled_run,omitempty"`
+	// Hold reserves the money of a pending transfer; once the transfer is
+	// decided it shows the hold captured or released
+	Hold *AccountHolds `json:"hold,omitempty"`
 }
 
-// CurrencyRate represents the exchange rate for a currency relative to USD
-
 func NewStore(pool *pgxpool.Pool) Store {
 	return &SQLStore{
 		pool:    pool,
@@ -42,6 +122,21 @@ func NewStore(pool *pgxpool.Pool) Store {
 	}
 }
 
+// NewStoreWithRates creates a store whose transfers use the rates supplied by provider
+func NewStoreWithRates(pool *pgxpool.Pool, provider RateProvider) Store {
+	return NewStoreWithOptions(pool, StoreOptions{Rates: provider})
+}
+
+// NewStoreWithOptions creates a store consulting the services in options
+func NewStoreWithOptions(pool *pgxpool.Pool, options StoreOptions) Store {
+	return &SQLStore{
+		pool:     pool,
+		Queries:  New(pool),
+		rates:    options.Rates,
+		screener: options.Screener,
+	}
+}
+
 // Executes a function withing a database transaction
 func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
 	tx, err := store.pool.Begin(ctx)
@@ -67,116 +162,290 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxRequest) (TransfersTxResponse, error) {
 	var response TransfersTxResponse
 
+	if err := checkPrecision(arg.Amount, arg.Currency); err != nil {
+		return response, err
+	}
+
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
-		var fromAccount Accounts
-		var toAccount Accounts
-		var fromAmount float64
-		var toAmount float64
-
-		if arg.FromAccountID < arg.ToAccountID {
-			// Acquire locks on the accounts based on their IDs
-			fromAccount, err = q.GetAccountForUpdate(ctx, arg.FromAccountID)
-			if err != nil {
-				return err
-			}
 
-			toAccount, err = q.GetAccountForUpdate(ctx, arg.ToAccountID)
-			if err != nil {
-				return err
-			}
+		now := time.Now()
 
-		} else {
-			// Acquire locks on the accounts based on their IDs
-			toAccount, err = q.GetAccountForUpdate(ctx, arg.ToAccountID)
+		var schedule ScheduledTransfers
+		if arg.ScheduledTransferID != 0 {
+			schedule, err = lockDueSchedule(ctx, q, arg.ScheduledTransferID, arg.ScheduledFor)
 			if err != nil {
 				return err
 			}
+		}
 
-			fromAccount, err = q.GetAccountForUpdate(ctx, arg.FromAccountID)
+		var hold AccountHolds
+		if arg.HoldID != 0 {
+			hold, err = lockCapturableHold(ctx, q, arg, now)
 			if err != nil {
 				return err
 			}
+		}
+
+		fromAccount, toAccount, err := lockAccountPair(ctx, q, arg.FromAccountID, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
+
+		if fromAccount.Kind != util.CustomerAccount || toAccount.Kind != util.CustomerAccount {
+			return ErrSystemAccount
+		}
 
+		if arg.HoldID != 0 && arg.Currency != fromAccount.Currency {
+			return ErrHoldMismatch
 		}
 
-		// Perform the transfer logic
-		fromAmount, err = util.Converter(arg.Currency, fromAccount.Currency, arg.Amount)
+		reviewReasons, err := store.screenTransfer(ctx, arg, fromAccount, toAccount)
 		if err != nil {
 			return err
 		}
 
-		toAmount, err = util.Converter(arg.Currency, toAccount.Currency, arg.Amount)
+		// Perform the transfer logic at the quoted price, or with the rates and
+		// fees in force right now
+		var price transferPrice
+		if arg.QuoteID.Valid {
+			price, err = quotedPrice(ctx, q, arg, now)
+		} else {
+			price, err = store.priceTransfer(ctx, q, fromAccount, toAccount, arg.Amount, arg.Currency, now)
+		}
 		if err != nil {
 			return err
 		}
+		fromAmount, toAmount, fee := price.FromAmount, price.ToAmount, price.Fee
+
+		if err := checkTransferLimits(ctx, q, fromAccount, fromAmount, now); err != nil {
+			return err
+		}
 
-		if balance := fromAccount.Balance; balance < fromAmount {
-			err = fmt.Errorf("insufficient balance to perform transaction")
+		available, err := availableBalance(ctx, q, fromAccount, now)
+		if err != nil {
 			return err
 		}
 
-		response.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		// the hold being captured reserved its money for this transfer
+		available = available.Add(hold.Amount)
+		if available.LessThan(fromAmount.Add(fee)) {
+			return ErrInsufficientFunds
+		}
+
+		pending := arg.Pending || reviewReasons != ""
+
+		status := util.TransferCompleted
+		if pending {
+			status = util.TransferPending
+		}
+
+		transfer, err := q.CreateTransfer(ctx, CreateTransferParams{
 			FromAccountID: arg.FromAccountID,
 			ToAccountID:   arg.ToAccountID,
 			Amount:        arg.Amount,
 			Currency:      arg.Currency,
+			FromRate:      price.FromRate,
+			FromAmount:    fromAmount,
+			ToRate:        price.ToRate,
+			ToAmount:      toAmount,
+			Fee:           fee,
+			Status:        status,
 		})
 		if err != nil {
 			return err
 		}
 
-		response.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    toAmount,
-		})
-		if err != nil {
+		if err := recordTransferCreated(ctx, q, transfer, ""); err != nil {
 			return err
 		}
 
-		response.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -fromAmount,
-		})
-		if err != nil {
-			return err
+		if arg.QuoteID.Valid {
+			_, err = q.UseTransferQuote(ctx, UseTransferQuoteParams{
+				TransferID: pgtype.Int8{Int64: transfer.ID, Valid: true},
+				ID:         arg.QuoteID.UUID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		var captured AccountHolds
+		if arg.HoldID != 0 {
+			captured, err = captureHold(ctx, q, hold, transfer.ID)
+			if err != nil {
+				return err
+			}
 		}
 
-		// Update the balances of the accounts
-		if fromAccount.ID < toAccount.ID {
-			response.FromAccount, response.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, response.FromEntry.Amount, arg.ToAccountID, response.ToEntry.Amount)
+		if reviewReasons != "" {
+			_, err = q.CreateTransferReview(ctx, CreateTransferReviewParams{
+				TransferID: transfer.ID,
+				Reasons:    reviewReasons,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if pending {
+			reserved, err := reserveTransfer(ctx, q, transfer, fromAccount, now)
+			if err != nil {
+				return err
+			}
+			response = TransfersTxResponse{Transfer: transfer, FromAccount: fromAccount, ToAccount: toAccount, Hold: &reserved}
 		} else {
-			response.ToAccount, response.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, response.ToEntry.Amount, arg.FromAccountID, response.FromEntry.Amount)
+			response, err = postTransfer(ctx, q, transfer, fromAccount, toAccount)
+			if err != nil {
+				return err
+			}
 		}
-		if err != nil {
-			return err
+
+		if arg.ScheduledTransferID != 0 {
+			run, err := completeScheduledRun(ctx, q, schedule, transfer.ID)
+			if err != nil {
+				return err
+			}
+			response.ScheduledRun = &run
 		}
 
-		return nil
+		// the audit chain is locked last, after the accounts and system accounts
+		if arg.HoldID != 0 {
+			resource := fmt.Sprintf("hold/%d", hold.ID)
+			if err := recordAudit(ctx, q, arg.Audit, util.AuditHoldCapture, resource, hold, captured); err != nil {
+				return err
+			}
+		}
+
+		before := transferAccounts{FromAccount: fromAccount, ToAccount: toAccount}
+		resource := fmt.Sprintf("transfer/%d", transfer.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditTransferCreate, resource, before, response)
 	})
 
 	return response, err
 }
 
-func addMoney(
-	ctx context.Context,
-	q *Queries,
-	accountID1 int64,
-	amount1 float64,
-	accountID2 int64,
-	amount2 float64,
-) (account1 Accounts, account2 Accounts, err error) {
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID1,
-		Amount: amount1,
+// lockAccountPair locks both accounts of a transfer, always in the order of their
+// IDs so concurrent transfers between the same accounts cannot deadlock
+func lockAccountPair(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) (Accounts, Accounts, error) {
+	var fromAccount, toAccount Accounts
+	var err error
+
+	if fromAccountID < toAccountID {
+		fromAccount, err = q.GetAccountForUpdate(ctx, fromAccountID)
+		if err != nil {
+			return fromAccount, toAccount, err
+		}
+
+		toAccount, err = q.GetAccountForUpdate(ctx, toAccountID)
+		return fromAccount, toAccount, err
+	}
+
+	toAccount, err = q.GetAccountForUpdate(ctx, toAccountID)
+	if err != nil {
+		return fromAccount, toAccount, err
+	}
+
+	fromAccount, err = q.GetAccountForUpdate(ctx, fromAccountID)
+	return fromAccount, toAccount, err
+}
+
+// postTransfer posts the journal of a recorded transfer between the locked
+// accounts: the customers' sides, with the conversion booked through FX
+// clearing so each currency still balances, and the fee booked to fees income.
+// Both accounts are told of the money moving through the outbox.
+func postTransfer(ctx context.Context, q *Queries, transfer Transfers, fromAccount Accounts, toAccount Accounts) (TransfersTxResponse, error) {
+	response := TransfersTxResponse{Transfer: transfer}
+	fromAmount, toAmount, fee := transfer.FromAmount, transfer.ToAmount, transfer.Fee
+	crossCurrency := fromAccount.Currency != toAccount.Currency
+
+	lines := []journalLine{
+		{Account: toAccount, Amount: toAmount, Type: util.TransferEntry},
+		{Account: fromAccount, Amount: fromAmount.Neg(), Type: util.TransferEntry},
+	}
+
+	fromClearing := systemAccountKey{Kind: util.FXClearingAccount, Currency: fromAccount.Currency}
+	toClearing := systemAccountKey{Kind: util.FXClearingAccount, Currency: toAccount.Currency}
+	feesIncome := systemAccountKey{Kind: util.FeesIncomeAccount, Currency: fromAccount.Currency}
+
+	var keys []systemAccountKey
+	if crossCurrency {
+		keys = append(keys, fromClearing, toClearing)
+	}
+	if fee.IsPositive() {
+		keys = append(keys, feesIncome)
+	}
+
+	system, err := lockSystemAccounts(ctx, q, keys...)
+	if err != nil {
+		return response, err
+	}
+
+	if crossCurrency {
+		lines = append(lines,
+			journalLine{Account: system[fromClearing], Amount: fromAmount, Type: util.TransferEntry},
+			journalLine{Account: system[toClearing], Amount: toAmount.Neg(), Type: util.TransferEntry},
+		)
+	}
+	if fee.IsPositive() {
+		lines = append(lines,
+			journalLine{Account: fromAccount, Amount: fee.Neg(), Type: util.FeeEntry},
+			journalLine{Account: system[feesIncome], Amount: fee, Type: util.FeeEntry},
+		)
+	}
+
+	entries, accounts, err := postJournal(ctx, q, pgtype.Int8{Int64: transfer.ID, Valid: true}, lines)
+	if err != nil {
+		return response, err
+	}
+
+	response.ToEntry = entries[0]
+	response.FromEntry = entries[1]
+	if fee.IsPositive() {
+		response.FeeEntry = &entries[len(entries)-2]
+	}
+	response.ToAccount = accounts[toAccount.ID]
+	response.FromAccount = accounts[fromAccount.ID]
+
+	return response, publishTransferEvents(ctx, q, response)
+}
+
+// ExchangeRateAt returns how many units of toCurrency one unit of fromCurrency
+// buys at the given time, from the same provider transfers use
+func (store *SQLStore) ExchangeRateAt(ctx context.Context, fromCurrency string, toCurrency string, at time.Time) (decimal.Decimal, error) {
+	return store.rateProvider(store.Queries).ExchangeRateAt(ctx, fromCurrency, toCurrency, at)
+}
+
+// rateProvider returns the provider transfers should use, falling back to the
+// exchange rate history as seen by the transaction q
+func (store *SQLStore) rateProvider(q *Queries) RateProvider {
+	if store.rates != nil {
+		return store.rates
+	}
+	return q
+}
+
+// ExchangeRateAt looks both currencies up in the exchange_rates table, so Queries is itself a RateProvider
+func (q *Queries) ExchangeRateAt(ctx context.Context, fromCurrency string, toCurrency string, at time.Time) (decimal.Decimal, error) {
+	if fromCurrency == toCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+
+	fromRate, err := q.GetExchangeRate(ctx, GetExchangeRateParams{
+		Currency: fromCurrency,
+		At:       at,
 	})
 	if err != nil {
-		return
+		return decimal.Zero, fmt.Errorf("no %s exchange rate in force at %s: %w", fromCurrency, at.Format(time.RFC3339), err)
 	}
 
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID2,
-		Amount: amount2,
+	toRate, err := q.GetExchangeRate(ctx, GetExchangeRateParams{
+		Currency: toCurrency,
+		At:       at,
 	})
-	return
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("no %s exchange rate in force at %s: %w", toCurrency, at.Format(time.RFC3339), err)
+	}
+
+	return util.CrossRate(fromRate.Rate, toRate.Rate), nil
 }
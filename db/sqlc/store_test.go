@@ -2,10 +2,10 @@ package db
 
 import (
 	"context"
-	"fmt"
 	"testing"
 
 	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,7 +18,7 @@ func TestTransferTx(t *testing.T) {
 	// run a concurrent transfer transaction
 	n := 5
 
-	amount := 10.00
+	amount := decimal.NewFromInt(10)
 
 	errs := make(chan error)
 
@@ -40,11 +40,8 @@ func TestTransferTx(t *testing.T) {
 
 	// check responses
 	existed := make(map[int]bool)
+	var sentPerTransfer, receivedPerTransfer decimal.Decimal
 	for i := 0; i < n; i++ {
-		var fromAmount float64
-		var toAmount float64
-		var conversionError error
-
 		err := <-errs
 
 		require.NoError(t, err)
@@ -56,19 +53,18 @@ func TestTransferTx(t *testing.T) {
 		//check transfer
 		transfer := response.Transfer
 
-		fromAmount, conversionError = util.Converter(transfer.Currency, response.FromAccount.Currency, transfer.Amount)
-
-		require.NoError(t, conversionError)
-
-		toAmount, conversionError = util.Converter(transfer.Currency, response.ToAccount.Currency, transfer.Amount)
-
-		require.NoError(t, conversionError)
-
 		require.NotEmpty(t, transfer)
 
 		require.Equal(t, transfer.FromAccountID, account1.ID)
 		require.Equal(t, transfer.ToAccountID, account2.ID)
-		require.Equal(t, transfer.Amount, amount)
+		require.True(t, transfer.Amount.Equal(amount))
+
+		// the applied rates and converted amounts are recorded on the transfer
+		fromAmount := transfer.FromAmount
+		toAmount := transfer.ToAmount
+		require.True(t, fromAmount.Equal(util.ConvertAmount(amount, transfer.FromRate, response.FromAccount.Currency)))
+		require.True(t, toAmount.Equal(util.ConvertAmount(amount, transfer.ToRate, response.ToAccount.Currency)))
+		sentPerTransfer, receivedPerTransfer = fromAmount, toAmount
 
 		require.NotZero(t, transfer.ID)
 		require.NotZero(t, transfer.CreatedAt)
@@ -84,7 +80,9 @@ func TestTransferTx(t *testing.T) {
 
 		require.Equal(t, fromEntry.AccountID, account1.ID)
 		require.NoError(t, err)
-		require.Equal(t, fromEntry.Amount, -fromAmount)
+		require.True(t, fromEntry.Amount.Equal(fromAmount.Neg()))
+		require.Equal(t, util.TransferEntry, fromEntry.Type)
+		require.Equal(t, transfer.ID, fromEntry.TransferID.Int64)
 
 		require.NotZero(t, fromEntry.ID)
 		require.NotZero(t, fromEntry.CreatedAt)
@@ -100,7 +98,9 @@ func TestTransferTx(t *testing.T) {
 		require.NoError(t, err)
 
 		require.Equal(t, toEntry.AccountID, account2.ID)
-		require.Equal(t, toEntry.Amount, toAmount)
+		require.True(t, toEntry.Amount.Equal(toAmount))
+		require.Equal(t, util.TransferEntry, toEntry.Type)
+		require.Equal(t, transfer.ID, toEntry.TransferID.Int64)
 
 		require.NotZero(t, toEntry.ID)
 		require.NotZero(t, toEntry.CreatedAt)
@@ -120,14 +120,11 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, toAcocunt)
 		require.Equal(t, toAcocunt.ID, account2.ID)
 
-		diff := account1.Balance - fromAcount.Balance
+		diff := account1.Balance.Sub(fromAcount.Balance)
 
-		require.True(t, diff > 0)
-
-		convertedDiff, err := util.Converter(response.FromAccount.Currency, "USD", diff)
-		require.NoError(t, err)
+		require.True(t, diff.IsPositive())
 
-		k := int(convertedDiff / amount)
+		k := int(diff.Div(fromAmount).IntPart())
 		require.True(t, k >= 1 && k <= n)
 		require.NotContains(t, existed, k)
 
@@ -142,18 +139,9 @@ func TestTransferTx(t *testing.T) {
 	updateAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
 	require.NoError(t, err)
 
-	// require.Equal(t, updateAccount1.Balance, account1.Balance-float64(n)*amount)
-
-	amountSent, err := util.Converter("USD", updateAccount1.Currency, (float64(n) * amount))
-
-	require.NoError(t, err)
-
-	require.Equal(t, fmt.Sprintf("%.2f", updateAccount1.Balance), fmt.Sprintf("%.2f", (account1.Balance-amountSent)))
-
-	amountReceived, err := util.Converter("USD", updateAccount2.Currency, (float64(n) * amount))
-	require.NoError(t, err)
-
-	require.Equal(t, fmt.Sprintf("%.2f", updateAccount2.Balance), fmt.Sprintf("%.2f", (account2.Balance+amountReceived)))
+	// each transfer is converted and rounded on its own, so compare against n rounded amounts
+	require.True(t, updateAccount1.Balance.Equal(account1.Balance.Sub(sentPerTransfer.Mul(decimal.NewFromInt(int64(n))))))
+	require.True(t, updateAccount2.Balance.Equal(account2.Balance.Add(receivedPerTransfer.Mul(decimal.NewFromInt(int64(n))))))
 }
 
 func TestTransferTxDeadlock(t *testing.T) {
@@ -182,7 +170,7 @@ func TestTransferTxDeadlock(t *testing.T) {
 			toAccountID = account1.ID
 		}
 
-		amount := float64(10.00)
+		amount := decimal.NewFromInt(10)
 
 		go func() {
 			_, err := store.TransferTx(context.Background(), TransferTxRequest{
@@ -209,7 +197,7 @@ func TestTransferTxDeadlock(t *testing.T) {
 	updateAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
 	require.NoError(t, err)
 
-	require.Equal(t, updateAccount1.Balance, account1.Balance)
+	require.True(t, updateAccount1.Balance.Equal(account1.Balance))
 
-	require.Equal(t, updateAccount2.Balance, account2.Balance)
+	require.True(t, updateAccount2.Balance.Equal(account2.Balance))
 }
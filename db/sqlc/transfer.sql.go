@@ -7,24 +7,129 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
 )
 
+const countCrossCurrencyTransfers = `-- name: CountCrossCurrencyTransfers :one
+SELECT count(*) FROM transfers t
+JOIN accounts f ON f.id = t.from_account_id
+JOIN accounts r ON r.id = t.to_account_id
+WHERE f.owner = $1
+AND f.currency <> r.currency
+AND t.created_at >= $2
+AND t.status IN ('pending', 'completed')
+`
+
+type CountCrossCurrencyTransfersParams struct {
+	Owner string    `json:"owner"`
+	Since time.Time `json:"since"`
+}
+
+// CountCrossCurrencyTransfers counts the owner's transfers since the given time
+// that moved money between accounts of different currencies
+func (q *Queries) CountCrossCurrencyTransfers(ctx context.Context, arg CountCrossCurrencyTransfersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countCrossCurrencyTransfers, arg.Owner, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPayeeTransfers = `-- name: CountPayeeTransfers :one
+SELECT count(*) FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = $1
+AND t.to_account_id = $2
+AND t.status = 'completed'
+`
+
+type CountPayeeTransfersParams struct {
+	Owner       string `json:"owner"`
+	ToAccountID int64  `json:"to_account_id"`
+}
+
+// CountPayeeTransfers counts the completed transfers the owner has made to the account
+func (q *Queries) CountPayeeTransfers(ctx context.Context, arg CountPayeeTransfersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countPayeeTransfers, arg.Owner, arg.ToAccountID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTransfers = `-- name: CountTransfers :one
+SELECT count(*) FROM transfers
+WHERE
+  (
+    ($1::varchar IN ('', 'sent') AND from_account_id IN (
+      SELECT id FROM accounts
+      WHERE ($2::varchar IS NULL OR owner = $2)
+        AND ($3::bigint IS NULL OR id = $3)
+    ))
+    OR
+    ($1 IN ('', 'received') AND to_account_id IN (
+      SELECT id FROM accounts
+      WHERE ($2::varchar IS NULL OR owner = $2)
+        AND ($3::bigint IS NULL OR id = $3)
+    ))
+  )
+  AND ($4::varchar IS NULL OR currency = $4)
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)
+`
+
+type CountTransfersParams struct {
+	Direction string             `json:"direction"`
+	Owner     pgtype.Text        `json:"owner"`
+	AccountID pgtype.Int8        `json:"account_id"`
+	Currency  pgtype.Text        `json:"currency"`
+	FromDate  pgtype.Timestamptz `json:"from_date"`
+	ToDate    pgtype.Timestamptz `json:"to_date"`
+}
+
+func (q *Queries) CountTransfers(ctx context.Context, arg CountTransfersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTransfers,
+		arg.Direction,
+		arg.Owner,
+		arg.AccountID,
+		arg.Currency,
+		arg.FromDate,
+		arg.ToDate,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
   amount,
-  currency
+  currency,
+  from_rate,
+  from_amount,
+  to_rate,
+  to_amount,
+  fee,
+  status
 ) VALUES (
-  $1, $2, $3, $4
-) RETURNING id, from_account_id, to_account_id, amount, currency, created_at
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING id, from_account_id, to_account_id, amount, currency, created_at, from_rate, from_amount, to_rate, to_amount, fee, status, failure_reason
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64   `json:"from_account_id"`
-	ToAccountID   int64   `json:"to_account_id"`
-	Amount        float64 `json:"amount"`
-	Currency      string  `json:"currency"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	FromRate      decimal.Decimal `json:"from_rate"`
+	FromAmount    decimal.Decimal `json:"from_amount"`
+	ToRate        decimal.Decimal `json:"to_rate"`
+	ToAmount      decimal.Decimal `json:"to_amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	Status        string          `json:"status"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfers, error) {
@@ -33,6 +138,12 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		arg.ToAccountID,
 		arg.Amount,
 		arg.Currency,
+		arg.FromRate,
+		arg.FromAmount,
+		arg.ToRate,
+		arg.ToAmount,
+		arg.Fee,
+		arg.Status,
 	)
 	var i Transfers
 	err := row.Scan(
@@ -42,12 +153,63 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.Amount,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.FromRate,
+		&i.FromAmount,
+		&i.ToRate,
+		&i.ToAmount,
+		&i.Fee,
+		&i.Status,
+		&i.FailureReason,
+	)
+	return i, err
+}
+
+const getOutgoingTransferTotals = `-- name: GetOutgoingTransferTotals :one
+SELECT
+  COALESCE(SUM(t.from_amount), 0)::numeric AS amount,
+  count(*) AS count
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = $1
+AND ($2::bigint IS NULL OR t.from_account_id = $2)
+AND ($3::varchar IS NULL OR a.currency = $3)
+AND t.created_at >= $4
+AND t.status IN ('pending', 'completed')
+AND NOT EXISTS (
+  SELECT 1 FROM transfer_reversals r
+  WHERE r.reversal_transfer_id = t.id
+)
+`
+
+type GetOutgoingTransferTotalsParams struct {
+	Owner     string      `json:"owner"`
+	AccountID pgtype.Int8 `json:"account_id"`
+	Currency  pgtype.Text `json:"currency"`
+	Since     time.Time   `json:"since"`
+}
+
+type GetOutgoingTransferTotalsRow struct {
+	Amount decimal.Decimal `json:"amount"`
+	Count  int64           `json:"count"`
+}
+
+// GetOutgoingTransferTotals adds up what the owner's transfers debited since
+// the given time, from one account or currency when set. Failed, cancelled and
+// reversing transfers do not count.
+func (q *Queries) GetOutgoingTransferTotals(ctx context.Context, arg GetOutgoingTransferTotalsParams) (GetOutgoingTransferTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getOutgoingTransferTotals,
+		arg.Owner,
+		arg.AccountID,
+		arg.Currency,
+		arg.Since,
 	)
+	var i GetOutgoingTransferTotalsRow
+	err := row.Scan(&i.Amount, &i.Count)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, currency, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, currency, created_at, from_rate, from_amount, to_rate, to_amount, fee, status, failure_reason FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -61,33 +223,118 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfers, error)
 		&i.Amount,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.FromRate,
+		&i.FromAmount,
+		&i.ToRate,
+		&i.ToAmount,
+		&i.Fee,
+		&i.Status,
+		&i.FailureReason,
+	)
+	return i, err
+}
+
+const getTransferAmountStats = `-- name: GetTransferAmountStats :one
+SELECT
+  count(*) AS count,
+  COALESCE(AVG(amount), 0)::numeric AS average
+FROM transfers
+WHERE from_account_id = $1
+AND currency = $2
+AND status = 'completed'
+`
+
+type GetTransferAmountStatsParams struct {
+	FromAccountID int64  `json:"from_account_id"`
+	Currency      string `json:"currency"`
+}
+
+type GetTransferAmountStatsRow struct {
+	Count   int64           `json:"count"`
+	Average decimal.Decimal `json:"average"`
+}
+
+// GetTransferAmountStats sums up the completed transfers made from the account
+// in the currency, for telling usual amounts from unusual ones
+func (q *Queries) GetTransferAmountStats(ctx context.Context, arg GetTransferAmountStatsParams) (GetTransferAmountStatsRow, error) {
+	row := q.db.QueryRow(ctx, getTransferAmountStats, arg.FromAccountID, arg.Currency)
+	var i GetTransferAmountStatsRow
+	err := row.Scan(&i.Count, &i.Average)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, currency, created_at, from_rate, from_amount, to_rate, to_amount, fee, status, failure_reason FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfers, error) {
+	row := q.db.QueryRow(ctx, getTransferForUpdate, id)
+	var i Transfers
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.FromRate,
+		&i.FromAmount,
+		&i.ToRate,
+		&i.ToAmount,
+		&i.Fee,
+		&i.Status,
+		&i.FailureReason,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, currency, created_at FROM transfers
-WHERE 
-    from_account_id = $1 OR
-    to_account_id = $2
+SELECT id, from_account_id, to_account_id, amount, currency, created_at, from_rate, from_amount, to_rate, to_amount, fee, status, failure_reason FROM transfers
+WHERE
+  (
+    ($1::varchar IN ('', 'sent') AND from_account_id IN (
+      SELECT id FROM accounts
+      WHERE ($2::varchar IS NULL OR owner = $2)
+        AND ($3::bigint IS NULL OR id = $3)
+    ))
+    OR
+    ($1 IN ('', 'received') AND to_account_id IN (
+      SELECT id FROM accounts
+      WHERE ($2::varchar IS NULL OR owner = $2)
+        AND ($3::bigint IS NULL OR id = $3)
+    ))
+  )
+  AND ($4::varchar IS NULL OR currency = $4)
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)
+  AND id > $7
 ORDER BY id
-LIMIT $3
-OFFSET $4
+LIMIT $8
 `
 
 type ListTransfersParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Limit         int32 `json:"limit"`
-	Offset        int32 `json:"offset"`
+	Direction string             `json:"direction"`
+	Owner     pgtype.Text        `json:"owner"`
+	AccountID pgtype.Int8        `json:"account_id"`
+	Currency  pgtype.Text        `json:"currency"`
+	FromDate  pgtype.Timestamptz `json:"from_date"`
+	ToDate    pgtype.Timestamptz `json:"to_date"`
+	AfterID   int64              `json:"after_id"`
+	Limit     int32              `json:"limit"`
 }
 
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfers, error) {
 	rows, err := q.db.Query(ctx, listTransfers,
-		arg.FromAccountID,
-		arg.ToAccountID,
+		arg.Direction,
+		arg.Owner,
+		arg.AccountID,
+		arg.Currency,
+		arg.FromDate,
+		arg.ToDate,
+		arg.AfterID,
 		arg.Limit,
-		arg.Offset,
 	)
 	if err != nil {
 		return nil, err
@@ -103,6 +350,62 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.Amount,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.FromRate,
+			&i.FromAmount,
+			&i.ToRate,
+			&i.ToAmount,
+			&i.Fee,
+			&i.Status,
+			&i.FailureReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnpairedTransfers = `-- name: ListUnpairedTransfers :many
+SELECT id, from_account_id, to_account_id, amount, currency, created_at, from_rate, from_amount, to_rate, to_amount, fee, status, failure_reason FROM transfers t
+WHERE t.status = 'completed' AND (
+  NOT EXISTS (
+    SELECT 1 FROM entries e
+    WHERE e.transfer_id = t.id AND e.account_id = t.from_account_id AND e.amount = -t.from_amount
+  )
+  OR NOT EXISTS (
+    SELECT 1 FROM entries e
+    WHERE e.transfer_id = t.id AND e.account_id = t.to_account_id AND e.amount = t.to_amount
+  )
+)
+ORDER BY t.id
+`
+
+func (q *Queries) ListUnpairedTransfers(ctx context.Context) ([]Transfers, error) {
+	rows, err := q.db.Query(ctx, listUnpairedTransfers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfers{}
+	for rows.Next() {
+		var i Transfers
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.FromRate,
+			&i.FromAmount,
+			&i.ToRate,
+			&i.ToAmount,
+			&i.Fee,
+			&i.Status,
+			&i.FailureReason,
 		); err != nil {
 			return nil, err
 		}
@@ -113,3 +416,37 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const updateTransferStatus = `-- name: UpdateTransferStatus :one
+UPDATE transfers
+SET status = $1, failure_reason = $2
+WHERE id = $3
+RETURNING id, from_account_id, to_account_id, amount, currency, created_at, from_rate, from_amount, to_rate, to_amount, fee, status, failure_reason
+`
+
+type UpdateTransferStatusParams struct {
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+	ID            int64  `json:"id"`
+}
+
+func (q *Queries) UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfers, error) {
+	row := q.db.QueryRow(ctx, updateTransferStatus, arg.Status, arg.FailureReason, arg.ID)
+	var i Transfers
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.FromRate,
+		&i.FromAmount,
+		&i.ToRate,
+		&i.ToAmount,
+		&i.Fee,
+		&i.Status,
+		&i.FailureReason,
+	)
+	return i, err
+}
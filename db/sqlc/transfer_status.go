@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+)
+
+// ErrInvalidTransferStatus is returned when a transfer's status does not allow
+// the change asked of it
+var ErrInvalidTransferStatus = errors.New("transfer status does not allow this")
+
+// transferTransitions lists the statuses each status can move to. Completed,
+// failed and cancelled transfers are final; completed ones are undone by a reversal.
+var transferTransitions = map[string][]string{
+	util.TransferPending: {util.TransferCompleted, util.TransferFailed, util.TransferCancelled},
+}
+
+func canChangeTransferStatus(from string, to string) bool {
+	for _, status := range transferTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+type UpdateTransferStatusTxRequest struct {
+	TransferID int64  `json:"transfer_id"`
+	Status     string `json:"status"`
+	// Reason is kept as the failure reason of failed and cancelled transfers
+	Reason    string `json:"reason"`
+	ChangedBy string `json:"changed_by"`
+	// Audit, when set, records the status change in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// UpdateTransferStatusTx moves a pending transfer on. Completing it moves the
+// money at the price recorded when it was created, provided the source account
+// still has enough, and captures the hold that reserved it; failing or
+// cancelling it keeps the reason and releases the hold.
+func (store *SQLStore) UpdateTransferStatusTx(ctx context.Context, arg UpdateTransferStatusTxRequest) (TransfersTxResponse, error) {
+	var response TransfersTxResponse
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+
+		response, err = changeTransferStatus(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("transfer/%d", before.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditTransferStatusChange, resource, before, response)
+	})
+
+	return response, err
+}
+
+// changeTransferStatus moves the pending transfer on within the caller's transaction
+func changeTransferStatus(ctx context.Context, q *Queries, arg UpdateTransferStatusTxRequest) (TransfersTxResponse, error) {
+	var response TransfersTxResponse
+
+	transfer, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+	if err != nil {
+		return response, err
+	}
+
+	if !canChangeTransferStatus(transfer.Status, arg.Status) {
+		return response, fmt.Errorf("%w: a %s transfer cannot become %s", ErrInvalidTransferStatus, transfer.Status, arg.Status)
+	}
+
+	// transfers left pending before their money was reserved have no hold
+	hold, err := q.GetTransferHoldForUpdate(ctx, pgtype.Int8{Int64: transfer.ID, Valid: true})
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return response, err
+	}
+
+	now := time.Now()
+
+	var fromAccount, toAccount Accounts
+	if arg.Status == util.TransferCompleted {
+		fromAccount, toAccount, err = lockAccountPair(ctx, q, transfer.FromAccountID, transfer.ToAccountID)
+		if err != nil {
+			return response, err
+		}
+
+		available, err := availableBalance(ctx, q, fromAccount, now)
+		if err != nil {
+			return response, err
+		}
+
+		// the transfer's own hold reserved its money for this
+		if hold.ExpiresAt.After(now) {
+			available = available.Add(hold.Amount)
+		}
+
+		if available.LessThan(transfer.FromAmount.Add(transfer.Fee)) {
+			return response, ErrInsufficientFunds
+		}
+	}
+
+	var failureReason string
+	if arg.Status == util.TransferFailed || arg.Status == util.TransferCancelled {
+		failureReason = arg.Reason
+	}
+
+	previous := transfer.Status
+	transfer, err = q.UpdateTransferStatus(ctx, UpdateTransferStatusParams{
+		Status:        arg.Status,
+		FailureReason: failureReason,
+		ID:            transfer.ID,
+	})
+	if err != nil {
+		return response, err
+	}
+
+	_, err = q.CreateTransferStatusChange(ctx, CreateTransferStatusChangeParams{
+		TransferID: transfer.ID,
+		FromStatus: previous,
+		ToStatus:   transfer.Status,
+		Reason:     arg.Reason,
+		ChangedBy:  arg.ChangedBy,
+	})
+	if err != nil {
+		return response, err
+	}
+
+	var settled *AccountHolds
+	if hold.ID != 0 {
+		hold, err = settleTransferHold(ctx, q, hold, arg.Status)
+		if err != nil {
+			return response, err
+		}
+		settled = &hold
+	}
+
+	if arg.Status != util.TransferCompleted {
+		response.Transfer = transfer
+		response.Hold = settled
+		return response, nil
+	}
+
+	response, err = postTransfer(ctx, q, transfer, fromAccount, toAccount)
+	response.Hold = settled
+	return response, err
+}
+
+// recordTransferCreated audits the status a transfer was created in
+func recordTransferCreated(ctx context.Context, q *Queries, transfer Transfers, changedBy string) error {
+	_, err := q.CreateTransferStatusChange(ctx, CreateTransferStatusChangeParams{
+		TransferID: transfer.ID,
+		ToStatus:   transfer.Status,
+		ChangedBy:  changedBy,
+	})
+	return err
+}
@@ -0,0 +1,250 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// createPendingTransfer records 10 EUR pending from a funded EUR account to a USD account at 1.1
+func createPendingTransfer(t *testing.T, store Store) (Transfers, Accounts, Accounts) {
+	fromAccount := createRandomAccountIn(t, "EUR")
+	toAccount := createRandomAccountIn(t, "USD")
+
+	_, err := store.DepositTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      "EUR",
+		Pending:       true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPending, result.Transfer.Status)
+	require.Empty(t, result.FromEntry)
+
+	// the money stays reserved while the transfer waits
+	require.NotNil(t, result.Hold)
+	require.Equal(t, util.HoldActive, result.Hold.Status)
+	require.Equal(t, fromAccount.ID, result.Hold.AccountID)
+	require.Equal(t, result.Transfer.ID, result.Hold.TransferID.Int64)
+	require.True(t, result.Transfer.FromAmount.Add(result.Transfer.Fee).Equal(result.Hold.Amount))
+
+	return result.Transfer, fromAccount, toAccount
+}
+
+// transferHold returns the hold that reserved the money of the transfer
+func transferHold(t *testing.T, transfer Transfers) AccountHolds {
+	holds, err := testQueries.ListAccountHolds(context.Background(), ListAccountHoldsParams{
+		AccountID: transfer.FromAccountID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+
+	for _, hold := range holds {
+		if hold.TransferID.Int64 == transfer.ID {
+			return hold
+		}
+	}
+
+	require.FailNow(t, "transfer has no hold")
+	return AccountHolds{}
+}
+
+func TestTransferTxPendingMovesNoMoney(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	transfer, fromAccount, toAccount := createPendingTransfer(t, store)
+
+	require.Empty(t, entriesSince(t, toAccount.ID, 0))
+
+	from, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(100).Equal(from.Balance))
+
+	changes, err := testQueries.ListTransferStatusChanges(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Empty(t, changes[0].FromStatus)
+	require.Equal(t, util.TransferPending, changes[0].ToStatus)
+
+	// pending transfers are not in the ledger yet, so it still balances
+	require.NoError(t, store.VerifyLedger(context.Background()))
+}
+
+func TestUpdateTransferStatusTxCompletes(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	transfer, _, _ := createPendingTransfer(t, store)
+	user := createRandomUser(t)
+
+	result, err := store.UpdateTransferStatusTx(context.Background(), UpdateTransferStatusTxRequest{
+		TransferID: transfer.ID,
+		Status:     util.TransferCompleted,
+		Reason:     "approved",
+		ChangedBy:  user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferCompleted, result.Transfer.Status)
+	require.Empty(t, result.Transfer.FailureReason)
+
+	// the money moves at the price recorded when the transfer was created
+	require.True(t, decimal.NewFromInt(90).Equal(result.FromAccount.Balance))
+	require.True(t, decimal.NewFromInt(11).Equal(result.ToAccount.Balance))
+	require.True(t, decimal.NewFromInt(11).Equal(result.ToEntry.Amount))
+
+	changes, err := testQueries.ListTransferStatusChanges(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, util.TransferPending, changes[1].FromStatus)
+	require.Equal(t, util.TransferCompleted, changes[1].ToStatus)
+	require.Equal(t, "approved", changes[1].Reason)
+	require.Equal(t, user.Username, changes[1].ChangedBy)
+
+	// the hold is spent by the transfer it reserved for
+	require.NotNil(t, result.Hold)
+	require.Equal(t, util.HoldCaptured, result.Hold.Status)
+	require.Equal(t, util.HoldCaptured, transferHold(t, transfer).Status)
+
+	// completed transfers are final
+	_, err = store.UpdateTransferStatusTx(context.Background(), UpdateTransferStatusTxRequest{
+		TransferID: transfer.ID,
+		Status:     util.TransferCancelled,
+	})
+	require.ErrorIs(t, err, ErrInvalidTransferStatus)
+
+	require.NoError(t, store.VerifyLedger(context.Background()))
+}
+
+func TestUpdateTransferStatusTxFails(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	transfer, fromAccount, _ := createPendingTransfer(t, store)
+
+	result, err := store.UpdateTransferStatusTx(context.Background(), UpdateTransferStatusTxRequest{
+		TransferID: transfer.ID,
+		Status:     util.TransferFailed,
+		Reason:     "rejected on review",
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferFailed, result.Transfer.Status)
+	require.Equal(t, "rejected on review", result.Transfer.FailureReason)
+
+	stored, err := testQueries.GetTransfer(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.TransferFailed, stored.Status)
+	require.Equal(t, "rejected on review", stored.FailureReason)
+
+	from, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(100).Equal(from.Balance))
+
+	// the reserved money is free again
+	require.Equal(t, util.HoldReleased, transferHold(t, transfer).Status)
+	_, err = store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	// failed transfers can neither complete nor be reversed
+	_, err = store.UpdateTransferStatusTx(context.Background(), UpdateTransferStatusTxRequest{
+		TransferID: transfer.ID,
+		Status:     util.TransferCompleted,
+	})
+	require.ErrorIs(t, err, ErrInvalidTransferStatus)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxRequest{
+		TransferID:  transfer.ID,
+		InitiatedBy: createRandomUser(t).Username,
+	})
+	require.ErrorIs(t, err, ErrInvalidTransferStatus)
+}
+
+func TestTransferTxPendingReservesFunds(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	transfer, fromAccount, toAccount := createPendingTransfer(t, store)
+
+	// the 10 EUR waiting on the transfer cannot be withdrawn, held or sent elsewhere
+	_, err := store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(95),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.TransferTx(context.Background(), TransferTxRequest{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        decimal.NewFromInt(91),
+		Currency:      "EUR",
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(90),
+	})
+	require.NoError(t, err)
+
+	// what is left is exactly what the transfer reserved
+	result, err := store.UpdateTransferStatusTx(context.Background(), UpdateTransferStatusTxRequest{
+		TransferID: transfer.ID,
+		Status:     util.TransferCompleted,
+	})
+	require.NoError(t, err)
+	require.True(t, result.FromAccount.Balance.IsZero())
+
+	// the hold belongs to the transfer, not to the hold endpoints
+	_, err = store.ReleaseHoldTx(context.Background(), ReleaseHoldTxRequest{HoldID: result.Hold.ID})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestTransferHoldIsSettledWithTheTransfer(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	transfer, _, toAccount := createPendingTransfer(t, store)
+	hold := transferHold(t, transfer)
+
+	_, err := store.ReleaseHoldTx(context.Background(), ReleaseHoldTxRequest{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrTransferHold)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxRequest{
+		HoldID:      hold.ID,
+		ToAccountID: toAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrTransferHold)
+
+	require.Equal(t, util.HoldActive, transferHold(t, transfer).Status)
+}
+
+func TestUpdateTransferStatusTxInsufficientFunds(t *testing.T) {
+	store := NewStoreWithRates(pool, fixedRate(decimal.RequireFromString("1.1")))
+	transfer, fromAccount, _ := createPendingTransfer(t, store)
+
+	// a transfer whose hold expired no longer reserves its money
+	hold := transferHold(t, transfer)
+	_, err := pool.Exec(context.Background(),
+		"UPDATE account_holds SET expires_at = now() - interval '1 minute' WHERE id = $1", hold.ID)
+	require.NoError(t, err)
+
+	_, err = store.WithdrawTx(context.Background(), AccountTxRequest{
+		AccountID: fromAccount.ID,
+		Amount:    decimal.NewFromInt(95),
+	})
+	require.NoError(t, err)
+
+	_, err = store.UpdateTransferStatusTx(context.Background(), UpdateTransferStatusTxRequest{
+		TransferID: transfer.ID,
+		Status:     util.TransferCompleted,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the transfer stays pending until the account is funded again
+	stored, err := testQueries.GetTransfer(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPending, stored.Status)
+}
@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -15,7 +17,12 @@ func createRandomTransfer(t *testing.T, account1, account2 Accounts) Transfers {
 		ToAccountID:   account2.ID,
 		Amount:        util.RandomBalance(account1.Currency),
 		Currency:      util.RandomCurrency(),
+		FromRate:      decimal.NewFromInt(1),
+		ToRate:        decimal.NewFromInt(1),
+		Status:        util.TransferCompleted,
 	}
+	arg.FromAmount = arg.Amount
+	arg.ToAmount = arg.Amount
 
 	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
 
@@ -24,7 +31,10 @@ func createRandomTransfer(t *testing.T, account1, account2 Accounts) Transfers {
 
 	require.Equal(t, arg.FromAccountID, transfer.FromAccountID)
 	require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
-	require.Equal(t, arg.Amount, transfer.Amount)
+	require.True(t, arg.Amount.Equal(transfer.Amount))
+	require.True(t, arg.FromAmount.Equal(transfer.FromAmount))
+	require.True(t, arg.ToAmount.Equal(transfer.ToAmount))
+	require.Equal(t, arg.Status, transfer.Status)
 
 	require.NotZero(t, transfer.ID)
 	require.NotZero(t, transfer.CreatedAt)
@@ -51,7 +61,7 @@ func TestGetTransfer(t *testing.T) {
 	require.Equal(t, transfer1.ID, transfer2.ID)
 	require.Equal(t, transfer1.FromAccountID, transfer2.FromAccountID)
 	require.Equal(t, transfer1.ToAccountID, transfer2.ToAccountID)
-	require.Equal(t, transfer1.Amount, transfer2.Amount)
+	require.True(t, transfer1.Amount.Equal(transfer2.Amount))
 
 	require.WithinDuration(t, transfer1.CreatedAt, transfer2.CreatedAt, time.Second)
 }
@@ -66,12 +76,15 @@ func TestListTransfer(t *testing.T) {
 	}
 
 	arg := ListTransfersParams{
-		FromAccountID: account1.ID,
-		ToAccountID:   account1.ID,
-		Limit:         5,
-		Offset:        5,
+		AccountID: pgtype.Int8{Int64: account1.ID, Valid: true},
+		Limit:     5,
 	}
 
+	page1, err := testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page1, 5)
+
+	arg.AfterID = page1[len(page1)-1].ID
 	transfers, err := testQueries.ListTransfers(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, transfers, 5)
@@ -79,5 +92,26 @@ func TestListTransfer(t *testing.T) {
 	for _, transfer := range transfers {
 		require.NotEmpty(t, transfer)
 		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
+		require.Greater(t, transfer.ID, arg.AfterID)
+	}
+
+	count, err := testQueries.CountTransfers(context.Background(), CountTransfersParams{
+		AccountID: pgtype.Int8{Int64: account1.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), count)
+
+	arg = ListTransfersParams{
+		Direction: "sent",
+		Owner:     pgtype.Text{String: account1.Owner, Valid: true},
+		Limit:     10,
+	}
+
+	transfers, err = testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 5)
+
+	for _, transfer := range transfers {
+		require.Equal(t, account1.ID, transfer.FromAccountID)
 	}
 }
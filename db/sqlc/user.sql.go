@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -17,7 +18,7 @@ INSERT INTO users (
   email
 ) VALUES (
   $1, $2, $3, $4
-) RETURNING username, password, full_name, email, password_changed_at, created_at
+) RETURNING username, password, full_name, email, password_changed_at, created_at, tokens_valid_after, role, tier
 `
 
 type CreateUserParams struct {
@@ -42,12 +43,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (Users,
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.Tier,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, password, full_name, email, password_changed_at, created_at FROM users
+SELECT username, password, full_name, email, password_changed_at, created_at, tokens_valid_after, role, tier FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -61,6 +65,119 @@ func (q *Queries) GetUser(ctx context.Context, username string) (Users, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT username, password, full_name, email, password_changed_at, created_at, tokens_valid_after, role, tier FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, username string) (Users, error) {
+	row := q.db.QueryRow(ctx, getUserForUpdate, username)
+	var i Users
+	err := row.Scan(
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :one
+UPDATE users
+SET tokens_valid_after = $1
+WHERE username = $2
+RETURNING username, password, full_name, email, password_changed_at, created_at, tokens_valid_after, role, tier
+`
+
+type RevokeUserTokensParams struct {
+	TokensValidAfter time.Time `json:"tokens_valid_after"`
+	Username         string    `json:"username"`
+}
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) (Users, error) {
+	row := q.db.QueryRow(ctx, revokeUserTokens, arg.TokensValidAfter, arg.Username)
+	var i Users
+	err := row.Scan(
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $1
+WHERE username = $2
+RETURNING username, password, full_name, email, password_changed_at, created_at, tokens_valid_after, role, tier
+`
+
+type UpdateUserRoleParams struct {
+	Role     string `json:"role"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (Users, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.Role, arg.Username)
+	var i Users
+	err := row.Scan(
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const updateUserTier = `-- name: UpdateUserTier :one
+UPDATE users
+SET tier = $1
+WHERE username = $2
+RETURNING username, password, full_name, email, password_changed_at, created_at, tokens_valid_after, role, tier
+`
+
+type UpdateUserTierParams struct {
+	Tier     string `json:"tier"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) UpdateUserTier(ctx context.Context, arg UpdateUserTierParams) (Users, error) {
+	row := q.db.QueryRow(ctx, updateUserTier, arg.Tier, arg.Username)
+	var i Users
+	err := row.Scan(
+		&i.Username,
+		&i.Password,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.Tier,
 	)
 	return i, err
 }
@@ -33,6 +33,8 @@ func createRandomUser(t *testing.T) Users {
 	require.Equal(t, user.FullName, arg.FullName)
 
 	require.True(t, user.PasswordChangedAt.IsZero())
+	require.Equal(t, util.DepositorRole, user.Role)
+	require.Equal(t, util.StandardTier, user.Tier)
 
 	require.NotZero(t, user.CreatedAt)
 
@@ -55,3 +57,44 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, userFound.CreatedAt, user.CreatedAt, time.Second)
 	require.WithinDuration(t, userFound.PasswordChangedAt, user.PasswordChangedAt, time.Second)
 }
+
+func TestUpdateUserRole(t *testing.T) {
+	user := createRandomUser(t)
+
+	updatedUser, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Role:     util.BankerRole,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.BankerRole, updatedUser.Role)
+
+	_, err = testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Role:     "superuser",
+		Username: user.Username,
+	})
+	require.Error(t, err)
+}
+
+func TestUpdateUserRoleTxRevokesTokens(t *testing.T) {
+	store := NewStore(pool)
+	user := createRandomUser(t)
+
+	updatedUser, err := store.UpdateUserRoleTx(context.Background(), UpdateUserRoleTxRequest{
+		UpdateUserRoleParams: UpdateUserRoleParams{Role: util.AuditorRole, Username: user.Username},
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.AuditorRole, updatedUser.Role)
+	require.True(t, updatedUser.TokensValidAfter.After(user.TokensValidAfter))
+	require.WithinDuration(t, time.Now(), updatedUser.TokensValidAfter, time.Minute)
+
+	// a role that cannot be saved revokes nothing either
+	_, err = store.UpdateUserRoleTx(context.Background(), UpdateUserRoleTxRequest{
+		UpdateUserRoleParams: UpdateUserRoleParams{Role: "superuser", Username: user.Username},
+	})
+	require.Error(t, err)
+
+	stored, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, util.AuditorRole, stored.Role)
+	require.True(t, updatedUser.TokensValidAfter.Equal(stored.TokensValidAfter))
+}
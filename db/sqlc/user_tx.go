@@ -0,0 +1,228 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rouclec/simplebank/util"
+)
+
+// UserProfile is what the audit log and domain events keep of a user, leaving
+// out the password hash
+type UserProfile struct {
+	Username  string    `json:"username"`
+	FullName  string    `json:"full_name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Tier      string    `json:"tier"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newUserProfile(user Users) UserProfile {
+	return UserProfile{
+		Username:  user.Username,
+		FullName:  user.FullName,
+		Email:     user.Email,
+		Role:      user.Role,
+		Tier:      user.Tier,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// auditedSession is what the audit log keeps of a session, leaving out its refresh token
+type auditedSession struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func newAuditedSession(session Sessions) auditedSession {
+	return auditedSession{
+		ID:        session.ID,
+		Username:  session.Username,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		IsBlocked: session.IsBlocked,
+		ExpiresAt: session.ExpiresAt,
+	}
+}
+
+// revokedUserTokens is what the audit log keeps of a user logging out everywhere
+type revokedUserTokens struct {
+	Username         string    `json:"username"`
+	TokensValidAfter time.Time `json:"tokens_valid_after"`
+}
+
+type CreateUserTxRequest struct {
+	CreateUserParams
+	// Audit, when set, records the signup in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// CreateUserTx signs a user up
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxRequest) (Users, error) {
+	var user Users
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		profile := newUserProfile(user)
+
+		err = publishEvent(ctx, q, util.UserAggregate, user.Username, util.EventUserCreated, UserEvent{User: profile})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, arg.Audit, util.AuditSignup, "user/"+user.Username, nil, profile)
+	})
+
+	return user, err
+}
+
+type CreateSessionTxRequest struct {
+	CreateSessionParams
+	// Audit, when set, records the login in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// CreateSessionTx opens the session of a user logging in
+func (store *SQLStore) CreateSessionTx(ctx context.Context, arg CreateSessionTxRequest) (Sessions, error) {
+	var session Sessions
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		session, err = q.CreateSession(ctx, arg.CreateSessionParams)
+		if err != nil {
+			return err
+		}
+
+		after := newAuditedSession(session)
+		return recordAudit(ctx, q, arg.Audit, util.AuditLogin, "session/"+session.ID.String(), nil, after)
+	})
+
+	return session, err
+}
+
+type BlockSessionTxRequest struct {
+	ID uuid.UUID `json:"id"`
+	// Audit, when set, records the logout in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// BlockSessionTx blocks the session of a user logging out, so its refresh
+// token cannot be used again
+func (store *SQLStore) BlockSessionTx(ctx context.Context, arg BlockSessionTxRequest) (Sessions, error) {
+	var session Sessions
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetSession(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		session, err = q.BlockSession(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		resource := "session/" + session.ID.String()
+		return recordAudit(ctx, q, arg.Audit, util.AuditLogout, resource, newAuditedSession(before), newAuditedSession(session))
+	})
+
+	return session, err
+}
+
+type RevokeUserTokensTxRequest struct {
+	RevokeUserTokensParams
+	// Audit, when set, records the revocation in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// RevokeUserTokensTx revokes every token issued to the user before TokensValidAfter
+func (store *SQLStore) RevokeUserTokensTx(ctx context.Context, arg RevokeUserTokensTxRequest) (Users, error) {
+	var user Users
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.RevokeUserTokens(ctx, arg.RevokeUserTokensParams)
+		if err != nil {
+			return err
+		}
+
+		after := revokedUserTokens{Username: user.Username, TokensValidAfter: user.TokensValidAfter}
+		return recordAudit(ctx, q, arg.Audit, util.AuditLogoutAll, "user/"+user.Username, nil, after)
+	})
+
+	return user, err
+}
+
+type UpdateUserRoleTxRequest struct {
+	UpdateUserRoleParams
+	// Audit, when set, records the change in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// UpdateUserRoleTx changes the role of a user. Tokens carry the role, so every
+// token issued to the user until now is revoked along with the change.
+func (store *SQLStore) UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleTxRequest) (Users, error) {
+	var user Users
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.UpdateUserRole(ctx, arg.UpdateUserRoleParams)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.RevokeUserTokens(ctx, RevokeUserTokensParams{
+			TokensValidAfter: time.Now(),
+			Username:         arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, arg.Audit, util.AuditRoleChange, "user/"+user.Username, newUserProfile(before), newUserProfile(user))
+	})
+
+	return user, err
+}
+
+type UpdateUserTierTxRequest struct {
+	UpdateUserTierParams
+	// Audit, when set, records the change in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// UpdateUserTierTx changes the tier, and so the transfer limits, of a user
+func (store *SQLStore) UpdateUserTierTx(ctx context.Context, arg UpdateUserTierTxRequest) (Users, error) {
+	var user Users
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserTier(ctx, arg.UpdateUserTierParams)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, arg.Audit, util.AuditTierChange, "user/"+user.Username, newUserProfile(before), newUserProfile(user))
+	})
+
+	return user, err
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+)
+
+// auditedWebhook is what the audit log keeps of a webhook endpoint, leaving out its secret
+type auditedWebhook struct {
+	ID         int64     `json:"id"`
+	Owner      string    `json:"owner"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newAuditedWebhook(endpoint WebhookEndpoints) auditedWebhook {
+	return auditedWebhook{
+		ID:         endpoint.ID,
+		Owner:      endpoint.Owner,
+		Url:        endpoint.Url,
+		EventTypes: endpoint.EventTypes,
+		Active:     endpoint.Active,
+		CreatedAt:  endpoint.CreatedAt,
+	}
+}
+
+type CreateWebhookEndpointTxRequest struct {
+	CreateWebhookEndpointParams
+	// Audit, when set, records the registration in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// CreateWebhookEndpointTx registers an endpoint its owner's events are posted to
+func (store *SQLStore) CreateWebhookEndpointTx(ctx context.Context, arg CreateWebhookEndpointTxRequest) (WebhookEndpoints, error) {
+	var endpoint WebhookEndpoints
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		endpoint, err = q.CreateWebhookEndpoint(ctx, arg.CreateWebhookEndpointParams)
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("webhook/%d", endpoint.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditWebhookCreate, resource, nil, newAuditedWebhook(endpoint))
+	})
+
+	return endpoint, err
+}
+
+type DisableWebhookEndpointTxRequest struct {
+	ID int64 `json:"id"`
+	// Audit, when set, records the change in the audit log
+	Audit *AuditContext `json:"audit"`
+}
+
+// DisableWebhookEndpointTx stops posting events to an endpoint
+func (store *SQLStore) DisableWebhookEndpointTx(ctx context.Context, arg DisableWebhookEndpointTxRequest) (WebhookEndpoints, error) {
+	var endpoint WebhookEndpoints
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetWebhookEndpoint(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		endpoint, err = q.DisableWebhookEndpoint(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		resource := fmt.Sprintf("webhook/%d", endpoint.ID)
+		return recordAudit(ctx, q, arg.Audit, util.AuditWebhookDisable, resource, newAuditedWebhook(before), newAuditedWebhook(endpoint))
+	})
+
+	return endpoint, err
+}
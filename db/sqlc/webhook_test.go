@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhook(t *testing.T, owner string, eventTypes ...string) WebhookEndpoints {
+	endpoint, err := testQueries.CreateWebhookEndpoint(context.Background(), CreateWebhookEndpointParams{
+		Owner:      owner,
+		Url:        "https://example.com/" + util.RandomString(8),
+		Secret:     "whsec_" + util.RandomString(32),
+		EventTypes: eventTypes,
+	})
+	require.NoError(t, err)
+	require.True(t, endpoint.Active)
+	require.Equal(t, eventTypes, endpoint.EventTypes)
+
+	return endpoint
+}
+
+func TestCreateWebhookDeliveries(t *testing.T) {
+	store := NewStore(pool)
+	user := createRandomUser(t)
+
+	received := createRandomWebhook(t, user.Username, util.EventTransferReceived, util.EventAccountCreated)
+	// endpoints not subscribed to the event, or disabled, are skipped
+	createRandomWebhook(t, user.Username, util.EventTransferSent)
+	disabled := createRandomWebhook(t, user.Username, util.EventAccountCreated)
+	_, err := testQueries.DisableWebhookEndpoint(context.Background(), disabled.ID)
+	require.NoError(t, err)
+
+	// someone else's endpoint never hears of the user's events
+	createRandomWebhook(t, createRandomUser(t).Username, util.EventAccountCreated)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountTxRequest{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  decimal.Zero,
+			Currency: "USD",
+		},
+	})
+	require.NoError(t, err)
+
+	events := accountOutboxEvents(t, account)
+	require.Len(t, events, 1)
+
+	arg := CreateWebhookDeliveriesParams{
+		EventID:   events[0].ID,
+		EventType: events[0].EventType,
+		Payload:   []byte(`{"id": 1}`),
+		Owner:     user.Username,
+	}
+	deliveries, err := testQueries.CreateWebhookDeliveries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, received.ID, deliveries[0].EndpointID)
+	require.Equal(t, util.WebhookDeliveryPending, deliveries[0].Status)
+	require.Equal(t, `{"id": 1}`, string(deliveries[0].Payload))
+
+	// relaying the event again queues nothing more
+	deliveries, err = testQueries.CreateWebhookDeliveries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+
+}
+
+func TestRecordWebhookDeliveryAttempt(t *testing.T) {
+	store := NewStore(pool)
+	user := createRandomUser(t)
+	endpoint := createRandomWebhook(t, user.Username, util.EventAccountCreated)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountTxRequest{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  decimal.Zero,
+			Currency: "USD",
+		},
+	})
+	require.NoError(t, err)
+	event := accountOutboxEvents(t, account)[0]
+
+	deliveries, err := testQueries.CreateWebhookDeliveries(context.Background(), CreateWebhookDeliveriesParams{
+		EventID:   event.ID,
+		EventType: event.EventType,
+		Payload:   []byte(`{}`),
+		Owner:     user.Username,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	retryAt := time.Now().Add(time.Minute)
+	delivery, err := testQueries.RecordWebhookDeliveryAttempt(context.Background(), RecordWebhookDeliveryAttemptParams{
+		Status:         util.WebhookDeliveryPending,
+		ResponseStatus: 503,
+		LastError:      "endpoint returned 503 Service Unavailable",
+		NextAttemptAt:  retryAt,
+		ID:             deliveries[0].ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), delivery.Attempts)
+	require.WithinDuration(t, retryAt, delivery.NextAttemptAt, time.Second)
+	require.False(t, delivery.DeliveredAt.Valid)
+
+	delivery, err = testQueries.RecordWebhookDeliveryAttempt(context.Background(), RecordWebhookDeliveryAttemptParams{
+		Status:         util.WebhookDeliverySucceeded,
+		ResponseStatus: 200,
+		NextAttemptAt:  retryAt,
+		DeliveredAt:    pgtype.Timestamptz{Time: retryAt, Valid: true},
+		ID:             deliveries[0].ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), delivery.Attempts)
+	require.Equal(t, util.WebhookDeliverySucceeded, delivery.Status)
+
+	log, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		EndpointID: endpoint.ID,
+		Status:     pgtype.Text{String: util.WebhookDeliverySucceeded, Valid: true},
+		Limit:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, log, 1)
+
+	count, err := testQueries.CountWebhookDeliveries(context.Background(), CountWebhookDeliveriesParams{
+		EndpointID: endpoint.ID,
+		Status:     pgtype.Text{String: util.WebhookDeliveryFailed, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
@@ -0,0 +1,51 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+// ErrStaleRates is returned when the rates have not been refreshed recently enough to move money with
+var ErrStaleRates = errors.New("exchange rates are stale")
+
+// Rates holds the units of each currency per USD.
+// It is a static db.RateProvider that applies the same rates at any time.
+type Rates map[string]decimal.Decimal
+
+// ExchangeRateAt returns how many units of toCurrency one unit of fromCurrency buys
+func (rates Rates) ExchangeRateAt(ctx context.Context, fromCurrency string, toCurrency string, at time.Time) (decimal.Decimal, error) {
+	if fromCurrency == toCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+
+	fromRate, ok := rates[fromCurrency]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no %s exchange rate available", fromCurrency)
+	}
+
+	toRate, ok := rates[toCurrency]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no %s exchange rate available", toCurrency)
+	}
+
+	return util.CrossRate(fromRate, toRate), nil
+}
+
+// validate checks that every rate is positive and that USD is the base
+func (rates Rates) validate() error {
+	if usd, ok := rates["USD"]; ok && !usd.Equal(decimal.NewFromInt(1)) {
+		return fmt.Errorf("USD rate must be 1, got %s", usd)
+	}
+
+	for currency, rate := range rates {
+		if !rate.IsPositive() {
+			return fmt.Errorf("invalid %s exchange rate %s", currency, rate)
+		}
+	}
+	return nil
+}
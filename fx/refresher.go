@@ -0,0 +1,239 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/shopspring/decimal"
+)
+
+// RateStore persists the rates a Refresher accepts, so the exchange rate history stays complete
+type RateStore interface {
+	GetExchangeRate(ctx context.Context, arg db.GetExchangeRateParams) (db.ExchangeRates, error)
+	CreateExchangeRate(ctx context.Context, arg db.CreateExchangeRateParams) (db.ExchangeRates, error)
+}
+
+// RefresherConfig controls how often rates are pulled and which ones are trusted
+type RefresherConfig struct {
+	// Interval between two refreshes
+	Interval time.Duration
+	// MaxAge after the last successful refresh beyond which rates are refused
+	MaxAge time.Duration
+	// MaxJump is the largest relative change accepted between two refreshes, e.g. 0.1 for 10%; zero disables the check
+	MaxJump decimal.Decimal
+	// ConfirmJumps is how many refreshes in a row must agree on a rate beyond
+	// MaxJump before it is taken for a genuine move and accepted, 3 when unset
+	ConfirmJumps int
+}
+
+// pendingJump is a rate that jumped beyond MaxJump, and how many refreshes in a
+// row have agreed on it since
+type pendingJump struct {
+	rate     decimal.Decimal
+	readings int
+}
+
+// Refresher pulls rates from a Source on an interval and serves the latest accepted ones.
+// It is a db.RateProvider that refuses to convert once its rates are older than MaxAge.
+type Refresher struct {
+	source Source
+	store  RateStore
+	config RefresherConfig
+	now    func() time.Time
+
+	mu          sync.RWMutex
+	rates       Rates
+	lastRefresh time.Time
+	pending     map[string]pendingJump
+}
+
+// NewRefresher creates a refresher for source; store may be nil when accepted rates need not be persisted
+func NewRefresher(source Source, store RateStore, config RefresherConfig) *Refresher {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.MaxAge <= 0 {
+		config.MaxAge = 3 * config.Interval
+	}
+	if config.ConfirmJumps <= 0 {
+		config.ConfirmJumps = 3
+	}
+
+	return &Refresher{
+		source: source,
+		store:  store,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Refresh fetches the rates once. The whole batch is rejected if any rate jumped
+// beyond MaxJump, in which case the previous rates keep ageing, until
+// ConfirmJumps refreshes in a row agree on the new rate.
+func (refresher *Refresher) Refresh(ctx context.Context) error {
+	rates, err := refresher.source.FetchRates(ctx)
+	if err != nil {
+		return err
+	}
+
+	previous, err := refresher.previousRates(ctx, rates)
+	if err != nil {
+		return err
+	}
+
+	if err := refresher.checkJumps(previous, rates); err != nil {
+		return err
+	}
+
+	now := refresher.now()
+
+	if refresher.store != nil {
+		for currency, rate := range rates {
+			if old, ok := previous[currency]; ok && old.Equal(rate) {
+				continue
+			}
+
+			_, err := refresher.store.CreateExchangeRate(ctx, db.CreateExchangeRateParams{
+				Currency:      currency,
+				Rate:          rate,
+				EffectiveFrom: now,
+			})
+			if err != nil {
+				return fmt.Errorf("error saving %s exchange rate: %w", currency, err)
+			}
+		}
+	}
+
+	refresher.mu.Lock()
+	defer refresher.mu.Unlock()
+
+	refresher.rates = rates
+	refresher.lastRefresh = now
+	refresher.pending = nil
+	return nil
+}
+
+// previousRates returns the rates the new ones are compared against: the last
+// accepted batch, or the persisted history on the first refresh
+func (refresher *Refresher) previousRates(ctx context.Context, rates Rates) (Rates, error) {
+	refresher.mu.RLock()
+	previous := refresher.rates
+	refresher.mu.RUnlock()
+
+	if previous != nil || refresher.store == nil {
+		return previous, nil
+	}
+
+	previous = Rates{}
+	for currency := range rates {
+		rate, err := refresher.store.GetExchangeRate(ctx, db.GetExchangeRateParams{
+			Currency: currency,
+			At:       refresher.now(),
+		})
+		if errors.Is(err, db.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s exchange rate: %w", currency, err)
+		}
+		previous[currency] = rate.Rate
+	}
+	return previous, nil
+}
+
+// checkJumps fails when a rate moved beyond MaxJump from the previous rates.
+// A jump is only let through once it has been read ConfirmJumps times in a row,
+// each reading within MaxJump of the one before, so a genuine move becomes the
+// new baseline rather than stopping every later refresh.
+func (refresher *Refresher) checkJumps(previous Rates, rates Rates) error {
+	if !refresher.config.MaxJump.IsPositive() {
+		return nil
+	}
+
+	refresher.mu.Lock()
+	defer refresher.mu.Unlock()
+
+	pending := make(map[string]pendingJump)
+	var errs []error
+
+	for currency, rate := range rates {
+		old, ok := previous[currency]
+		if !ok || !old.IsPositive() || !refresher.jumped(old, rate) {
+			continue
+		}
+
+		jump := pendingJump{rate: rate, readings: 1}
+		if last, ok := refresher.pending[currency]; ok && last.rate.IsPositive() && !refresher.jumped(last.rate, rate) {
+			jump.readings = last.readings + 1
+		}
+		pending[currency] = jump
+
+		if jump.readings < refresher.config.ConfirmJumps {
+			errs = append(errs, fmt.Errorf("%s exchange rate jumped from %s to %s, beyond the %s threshold (%d of %d readings)",
+				currency, old, rate, refresher.config.MaxJump, jump.readings, refresher.config.ConfirmJumps))
+			continue
+		}
+
+		log.Printf("accepting %s exchange rate move from %s to %s after %d readings", currency, old, rate, jump.readings)
+	}
+
+	refresher.pending = pending
+	return errors.Join(errs...)
+}
+
+// jumped reports whether rate moved beyond MaxJump from old
+func (refresher *Refresher) jumped(old decimal.Decimal, rate decimal.Decimal) bool {
+	return rate.Sub(old).Abs().Div(old).GreaterThan(refresher.config.MaxJump)
+}
+
+// Run refreshes the rates every Interval until ctx is cancelled
+func (refresher *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(refresher.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := refresher.Refresh(ctx); err != nil {
+				log.Println("error refreshing exchange rates: ", err)
+			}
+		}
+	}
+}
+
+// LastRefresh returns when rates were last accepted, or the zero time if they never were
+func (refresher *Refresher) LastRefresh() time.Time {
+	refresher.mu.RLock()
+	defer refresher.mu.RUnlock()
+
+	return refresher.lastRefresh
+}
+
+// Stale reports whether the rates are too old to be used
+func (refresher *Refresher) Stale() bool {
+	lastRefresh := refresher.LastRefresh()
+	return lastRefresh.IsZero() || refresher.now().Sub(lastRefresh) > refresher.config.MaxAge
+}
+
+// ExchangeRateAt converts with the latest accepted rates, or fails with ErrStaleRates
+func (refresher *Refresher) ExchangeRateAt(ctx context.Context, fromCurrency string, toCurrency string, at time.Time) (decimal.Decimal, error) {
+	if fromCurrency == toCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+
+	if refresher.Stale() {
+		return decimal.Zero, fmt.Errorf("%w: last refreshed at %s", ErrStaleRates, refresher.LastRefresh().Format(time.RFC3339))
+	}
+
+	refresher.mu.RLock()
+	defer refresher.mu.RUnlock()
+
+	return refresher.rates.ExchangeRateAt(ctx, fromCurrency, toCurrency, at)
+}
@@ -0,0 +1,148 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource returns whatever rates or error the test last gave it
+type fakeSource struct {
+	rates Rates
+	err   error
+}
+
+func (source *fakeSource) FetchRates(ctx context.Context) (Rates, error) {
+	return source.rates, source.err
+}
+
+func newTestRefresher(source Source) (*Refresher, *time.Time) {
+	now := time.Now()
+	refresher := NewRefresher(source, nil, RefresherConfig{
+		Interval: time.Minute,
+		MaxAge:   10 * time.Minute,
+		MaxJump:  decimal.RequireFromString("0.1"),
+	})
+	refresher.now = func() time.Time { return now }
+	return refresher, &now
+}
+
+func TestRefresherRefresh(t *testing.T) {
+	source := &fakeSource{rates: Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("1.1")}}
+	refresher, now := newTestRefresher(source)
+
+	// nothing fetched yet
+	require.True(t, refresher.LastRefresh().IsZero())
+	_, err := refresher.ExchangeRateAt(context.Background(), "USD", "EUR", *now)
+	require.ErrorIs(t, err, ErrStaleRates)
+
+	require.NoError(t, refresher.Refresh(context.Background()))
+	require.Equal(t, *now, refresher.LastRefresh())
+
+	rate, err := refresher.ExchangeRateAt(context.Background(), "USD", "EUR", *now)
+	require.NoError(t, err)
+	require.Equal(t, "1.1", rate.String())
+
+	// a 5% move is accepted
+	*now = now.Add(time.Minute)
+	source.rates = Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("1.155")}
+	require.NoError(t, refresher.Refresh(context.Background()))
+	require.Equal(t, *now, refresher.LastRefresh())
+}
+
+func TestRefresherRejectsJumps(t *testing.T) {
+	source := &fakeSource{rates: Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("1.1")}}
+	refresher, now := newTestRefresher(source)
+	require.NoError(t, refresher.Refresh(context.Background()))
+	lastRefresh := refresher.LastRefresh()
+
+	*now = now.Add(time.Minute)
+	source.rates = Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("2.2")}
+	require.Error(t, refresher.Refresh(context.Background()))
+
+	// the previous rates are kept and keep ageing
+	require.Equal(t, lastRefresh, refresher.LastRefresh())
+	rate, err := refresher.ExchangeRateAt(context.Background(), "USD", "EUR", *now)
+	require.NoError(t, err)
+	require.Equal(t, "1.1", rate.String())
+}
+
+func TestRefresherAcceptsConfirmedMove(t *testing.T) {
+	source := &fakeSource{rates: Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("1.1")}}
+	refresher, now := newTestRefresher(source)
+	require.NoError(t, refresher.Refresh(context.Background()))
+
+	// a genuine 30% move, read again on the following refreshes
+	source.rates = Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("1.43")}
+	for i := 0; i < 2; i++ {
+		*now = now.Add(time.Minute)
+		require.ErrorContains(t, refresher.Refresh(context.Background()), "EUR exchange rate jumped")
+	}
+
+	*now = now.Add(time.Minute)
+	source.rates = Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("1.44")}
+	require.NoError(t, refresher.Refresh(context.Background()))
+	require.Equal(t, *now, refresher.LastRefresh())
+
+	rate, err := refresher.ExchangeRateAt(context.Background(), "USD", "EUR", *now)
+	require.NoError(t, err)
+	require.Equal(t, "1.44", rate.String())
+
+	// the move is the new baseline, so small changes from it are accepted again
+	*now = now.Add(time.Minute)
+	source.rates = Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("1.45")}
+	require.NoError(t, refresher.Refresh(context.Background()))
+	require.False(t, refresher.Stale())
+}
+
+func TestRefresherRejectsUnconfirmedJumps(t *testing.T) {
+	source := &fakeSource{rates: Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("1.1")}}
+	refresher, now := newTestRefresher(source)
+	require.NoError(t, refresher.Refresh(context.Background()))
+	lastRefresh := refresher.LastRefresh()
+
+	// a feed flapping between bad readings never agrees with itself
+	for _, eur := range []string{"2.2", "0.5", "2.2", "0.5"} {
+		*now = now.Add(time.Minute)
+		source.rates = Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString(eur)}
+		require.Error(t, refresher.Refresh(context.Background()))
+	}
+	require.Equal(t, lastRefresh, refresher.LastRefresh())
+
+	// a reading back in line with the baseline starts the count over
+	for _, eur := range []string{"2.2", "2.2", "1.1", "2.2", "2.2"} {
+		source.rates = Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString(eur)}
+		if eur == "1.1" {
+			require.NoError(t, refresher.Refresh(context.Background()))
+			continue
+		}
+		require.Error(t, refresher.Refresh(context.Background()))
+	}
+
+	rate, err := refresher.ExchangeRateAt(context.Background(), "USD", "EUR", *now)
+	require.NoError(t, err)
+	require.Equal(t, "1.1", rate.String())
+}
+
+func TestRefresherStale(t *testing.T) {
+	source := &fakeSource{rates: Rates{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("1.1")}}
+	refresher, now := newTestRefresher(source)
+	require.NoError(t, refresher.Refresh(context.Background()))
+
+	*now = now.Add(11 * time.Minute)
+	source.err = errors.New("feed unavailable")
+	require.Error(t, refresher.Refresh(context.Background()))
+
+	require.True(t, refresher.Stale())
+	_, err := refresher.ExchangeRateAt(context.Background(), "USD", "EUR", *now)
+	require.ErrorIs(t, err, ErrStaleRates)
+
+	// same-currency transfers need no rate
+	rate, err := refresher.ExchangeRateAt(context.Background(), "EUR", "EUR", *now)
+	require.NoError(t, err)
+	require.True(t, rate.Equal(decimal.NewFromInt(1)))
+}
@@ -0,0 +1,122 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+// Source fetches the latest exchange rates
+type Source interface {
+	FetchRates(ctx context.Context) (Rates, error)
+}
+
+// feed is the JSON document served by rate feeds and read from rate files,
+// e.g. {"base": "USD", "rates": {"EUR": "1.1", "XAF": "607.29"}}
+type feed struct {
+	Base  string                     `json:"base"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+// toRates rebases the feed onto USD
+func (f feed) toRates() (Rates, error) {
+	base := strings.ToUpper(f.Base)
+	if base == "" {
+		base = "USD"
+	}
+
+	rates := Rates{base: decimal.NewFromInt(1)}
+	for currency, rate := range f.Rates {
+		rates[strings.ToUpper(currency)] = rate
+	}
+
+	usd, ok := rates["USD"]
+	if !ok || !usd.IsPositive() {
+		return nil, fmt.Errorf("rates based on %s have no usable USD rate", base)
+	}
+
+	if !usd.Equal(decimal.NewFromInt(1)) {
+		for currency, rate := range rates {
+			rates[currency] = rate.DivRound(usd, util.RatePrecision)
+		}
+	}
+
+	if err := rates.validate(); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// FileSource reads rates from a JSON file on disk
+type FileSource struct {
+	Path string
+}
+
+func (source FileSource) FetchRates(ctx context.Context) (Rates, error) {
+	data, err := os.ReadFile(source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rates file: %w", err)
+	}
+
+	var f feed
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("error parsing rates file %s: %w", source.Path, err)
+	}
+	return f.toRates()
+}
+
+// LoadRates reads a static set of rates from a JSON file
+func LoadRates(path string) (Rates, error) {
+	return FileSource{Path: path}.FetchRates(context.Background())
+}
+
+// HTTPSource fetches rates from an HTTP feed
+type HTTPSource struct {
+	URL string
+	// Client fetches the feed, one giving up after 10 seconds when nil
+	Client *http.Client
+}
+
+func (source HTTPSource) FetchRates(ctx context.Context) (Rates, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	client := source.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rates: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching rates: %s returned %s", source.URL, response.Status)
+	}
+
+	var f feed
+	if err := json.NewDecoder(response.Body).Decode(&f); err != nil {
+		return nil, fmt.Errorf("error parsing rates from %s: %w", source.URL, err)
+	}
+	return f.toRates()
+}
+
+// NewSource returns an HTTPSource for http(s) URLs and a FileSource otherwise
+func NewSource(location string) Source {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return HTTPSource{URL: location}
+	}
+	return FileSource{Path: location}
+}
@@ -0,0 +1,119 @@
+package fx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatesExchangeRateAt(t *testing.T) {
+	rates := Rates{
+		"USD": decimal.NewFromInt(1),
+		"EUR": decimal.RequireFromString("1.1"),
+	}
+
+	rate, err := rates.ExchangeRateAt(context.Background(), "USD", "EUR", time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "1.1", rate.String())
+
+	rate, err = rates.ExchangeRateAt(context.Background(), "XAF", "XAF", time.Now())
+	require.NoError(t, err)
+	require.True(t, rate.Equal(decimal.NewFromInt(1)))
+
+	_, err = rates.ExchangeRateAt(context.Background(), "USD", "XAF", time.Now())
+	require.Error(t, err)
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte(`{"base": "USD", "rates": {"EUR": "1.1", "XAF": 607.29}}`), 0o600)
+	require.NoError(t, err)
+
+	rates, err := LoadRates(path)
+	require.NoError(t, err)
+	require.Len(t, rates, 3)
+	require.Equal(t, "1", rates["USD"].String())
+	require.Equal(t, "607.29", rates["XAF"].String())
+
+	_, err = LoadRates(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestHTTPSource(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		body          string
+		checkResponse func(t *testing.T, rates Rates, err error)
+	}{
+		{
+			name:   "OK",
+			status: http.StatusOK,
+			body:   `{"base": "USD", "rates": {"EUR": "1.1", "CAD": "1.35"}}`,
+			checkResponse: func(t *testing.T, rates Rates, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "1.35", rates["CAD"].String())
+			},
+		},
+		{
+			name:   "RebasedOnUSD",
+			status: http.StatusOK,
+			body:   `{"base": "EUR", "rates": {"USD": "0.5", "CAD": "1.5"}}`,
+			checkResponse: func(t *testing.T, rates Rates, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "1", rates["USD"].String())
+				require.Equal(t, "2", rates["EUR"].String())
+				require.Equal(t, "3", rates["CAD"].String())
+			},
+		},
+		{
+			name:   "NegativeRate",
+			status: http.StatusOK,
+			body:   `{"base": "USD", "rates": {"EUR": "-1.1"}}`,
+			checkResponse: func(t *testing.T, rates Rates, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:   "ServerError",
+			status: http.StatusInternalServerError,
+			body:   `{}`,
+			checkResponse: func(t *testing.T, rates Rates, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:   "InvalidBody",
+			status: http.StatusOK,
+			body:   `not json`,
+			checkResponse: func(t *testing.T, rates Rates, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			source := NewSource(server.URL)
+			require.IsType(t, HTTPSource{}, source)
+
+			rates, err := source.FetchRates(context.Background())
+			tc.checkResponse(t, rates, err)
+		})
+	}
+}
@@ -2,13 +2,22 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib" //Must ADD!! for code to be able to communicate with database
 	"github.com/rouclec/simplebank/api"
 	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/fx"
+	"github.com/rouclec/simplebank/outbox"
+	"github.com/rouclec/simplebank/scheduler"
+	"github.com/rouclec/simplebank/screening"
 	"github.com/rouclec/simplebank/util"
+	"github.com/rouclec/simplebank/webhook"
+	"github.com/shopspring/decimal"
 )
 
 func main() {
@@ -23,7 +32,62 @@ func main() {
 		log.Fatal("Error connecting to database: ", err)
 	}
 
-	store := db.NewStore(pool)
+	// every transfer is screened in TransferTx, whichever path asks for it
+	storeOptions := db.StoreOptions{
+		Screener: screening.ForStore(screening.NewRules(db.New(pool), screening.RulesConfig{})),
+	}
+	store := db.NewStoreWithOptions(pool, storeOptions)
+
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		os.Exit(reconcile(store))
+	}
+
+	if config.RatesSource != "" {
+		refresher := fx.NewRefresher(fx.NewSource(config.RatesSource), store, fx.RefresherConfig{
+			Interval:     config.RatesRefreshInterval,
+			MaxAge:       config.RatesMaxAge,
+			MaxJump:      decimal.NewFromFloat(config.RatesMaxJump),
+			ConfirmJumps: config.RatesConfirmJumps,
+		})
+
+		// a feed that hangs must not keep the server from starting
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := refresher.Refresh(ctx); err != nil {
+			log.Println("error loading exchange rates: ", err)
+		}
+		cancel()
+		go refresher.Run(context.Background())
+
+		storeOptions.Rates = refresher
+		store = db.NewStoreWithOptions(pool, storeOptions)
+	}
+
+	executor := scheduler.NewExecutor(store, scheduler.ExecutorConfig{
+		Interval:    config.ScheduledTransfersInterval,
+		MaxAttempts: config.ScheduledTransfersMaxAttempts,
+		RetryDelay:  config.ScheduledTransfersRetryDelay,
+	})
+	go executor.Run(context.Background())
+
+	sink, err := outbox.NewSink(config.OutboxSink)
+	if err != nil {
+		log.Fatal("error creating outbox sink: ", err)
+	}
+
+	// events are also queued for the webhook endpoints subscribed to them
+	relay := outbox.NewRelay(store, outbox.Sinks{sink, webhook.NewSubscriptions(store)}, outbox.RelayConfig{
+		Interval: config.OutboxRelayInterval,
+	})
+	go relay.Run(context.Background())
+
+	dispatcher := webhook.NewDispatcher(store, webhook.DispatcherConfig{
+		Interval:    config.WebhookDeliveryInterval,
+		MaxAttempts: config.WebhookMaxAttempts,
+	})
+	go dispatcher.Run(context.Background())
+
+	go pruneRevokedTokens(context.Background(), store, config.RevokedTokensPruneInterval)
+
 	server, err := api.NewServer(config, store)
 
 	if err != nil {
@@ -36,3 +100,56 @@ func main() {
 		log.Fatal("Error starting server: ", err)
 	}
 }
+
+// pruneRevokedTokens deletes the revocations of tokens that have expired
+// anyway, every interval until ctx is cancelled
+func pruneRevokedTokens(ctx context.Context, store db.Store, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.DeleteExpiredRevokedTokens(ctx); err != nil {
+				log.Println("error pruning revoked tokens: ", err)
+			}
+		}
+	}
+}
+
+// reconcile prints every problem the ledger reconciliation finds and returns
+// the exit status: 0 when the ledger is clean, 1 when it is not
+func reconcile(store db.Store) int {
+	report, err := store.Reconcile(context.Background())
+	if err != nil {
+		log.Fatal("error reconciling ledger: ", err)
+	}
+
+	for _, account := range report.BalanceMismatches {
+		fmt.Printf("account %d: balance %s %s, entries sum to %s\n", account.ID, account.Balance, account.Currency, account.EntriesTotal)
+	}
+	for _, entry := range report.OrphanedEntries {
+		fmt.Printf("entry %d on account %d: %s entry not matching transfer %d\n", entry.ID, entry.AccountID, entry.Type, entry.TransferID.Int64)
+	}
+	for _, transfer := range report.UnpairedTransfers {
+		fmt.Printf("transfer %d from account %d to account %d: missing its debit or credit entry\n", transfer.ID, transfer.FromAccountID, transfer.ToAccountID)
+	}
+	for _, imbalance := range report.LedgerImbalances {
+		fmt.Printf("ledger %s: entries sum to %s\n", imbalance.Currency, imbalance.Total)
+	}
+
+	if !report.OK() {
+		problems := len(report.BalanceMismatches) + len(report.OrphanedEntries) + len(report.UnpairedTransfers) + len(report.LedgerImbalances)
+		fmt.Printf("reconciliation found %d problems\n", problems)
+		return 1
+	}
+
+	fmt.Println("ledger reconciled")
+	return 0
+}
@@ -0,0 +1,150 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+)
+
+// NATSSink publishes every event to a NATS-compatible broker, on the subject
+// Subject.<event type>, e.g. simplebank.transfer.sent. It speaks the plain
+// text protocol and follows each publish with a ping, so an event counts as
+// delivered only once the broker has read it.
+type NATSSink struct {
+	// Address of the broker, as host:port
+	Address string
+	// Subject events are published under, simplebank when empty
+	Subject string
+	// Timeout of connecting and of each publish, 10 seconds when zero
+	Timeout time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (sink *NATSSink) Deliver(ctx context.Context, event db.OutboxEvents) error {
+	data, err := json.Marshal(NewMessage(event))
+	if err != nil {
+		return err
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if err := sink.publish(ctx, sink.subject(event.EventType), data); err != nil {
+		// start over on a new connection next time
+		sink.close()
+		return fmt.Errorf("error publishing event to %s: %w", sink.Address, err)
+	}
+	return nil
+}
+
+// publish sends one message and waits for the broker to answer the ping after it
+func (sink *NATSSink) publish(ctx context.Context, subject string, data []byte) error {
+	if sink.conn == nil {
+		if err := sink.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := sink.conn.SetDeadline(sink.deadline(ctx)); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(sink.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(data), data)
+	if err != nil {
+		return err
+	}
+
+	for {
+		line, err := sink.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := sink.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// +OK and INFO updates need no answer
+	}
+}
+
+// connect opens the connection, reading the broker's INFO and introducing the sink
+func (sink *NATSSink) connect(ctx context.Context) error {
+	dialer := net.Dialer{Deadline: sink.deadline(ctx)}
+	conn, err := dialer.DialContext(ctx, "tcp", sink.Address)
+	if err != nil {
+		return err
+	}
+	sink.conn = conn
+	sink.reader = bufio.NewReader(conn)
+
+	if err := conn.SetDeadline(sink.deadline(ctx)); err != nil {
+		return err
+	}
+
+	line, err := sink.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO") {
+		return fmt.Errorf("unexpected greeting %q", line)
+	}
+
+	_, err = conn.Write([]byte(`CONNECT {"verbose":false,"pedantic":false,"name":"simplebank-outbox"}` + "\r\n"))
+	return err
+}
+
+func (sink *NATSSink) readLine() (string, error) {
+	line, err := sink.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (sink *NATSSink) close() {
+	if sink.conn != nil {
+		sink.conn.Close()
+		sink.conn = nil
+		sink.reader = nil
+	}
+}
+
+func (sink *NATSSink) subject(eventType string) string {
+	prefix := sink.Subject
+	if prefix == "" {
+		prefix = "simplebank"
+	}
+	return prefix + "." + eventType
+}
+
+// deadline is the earlier of ctx's deadline and the sink's timeout from now
+func (sink *NATSSink) deadline(ctx context.Context) time.Time {
+	timeout := sink.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		return ctxDeadline
+	}
+	return deadline
+}
@@ -0,0 +1,155 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rouclec/simplebank/db/sqlc"
+)
+
+// EventStore holds the outbox a Relay delivers
+type EventStore interface {
+	ClaimOutboxEvents(ctx context.Context, arg db.ClaimOutboxEventsParams) ([]db.OutboxEvents, error)
+	MarkOutboxEventDelivered(ctx context.Context, id int64) (db.OutboxEvents, error)
+	RetryOutboxEvent(ctx context.Context, arg db.RetryOutboxEventParams) (db.OutboxEvents, error)
+}
+
+// RelayConfig controls how often the outbox is read and how failed deliveries are retried
+type RelayConfig struct {
+	// Interval between two looks for undelivered events
+	Interval time.Duration
+	// BatchSize is how many events are claimed at once
+	BatchSize int32
+	// Lease is how long a claimed event is kept from other replicas; it must
+	// outlast delivering a whole batch
+	Lease time.Duration
+	// RetryDelay before the first retry, doubled for each one after
+	RetryDelay time.Duration
+	// MaxRetryDelay caps the delay between two retries
+	MaxRetryDelay time.Duration
+}
+
+// Relay delivers the events written to the outbox to a sink. An event is marked
+// delivered only once the sink has taken it, and is retried until then, so
+// every event is delivered at least once. The events of an aggregate are
+// delivered one after the other, in the order they were written, even with
+// several replicas running a relay.
+type Relay struct {
+	store  EventStore
+	sink   Sink
+	config RelayConfig
+	now    func() time.Time
+}
+
+// NewRelay creates a relay delivering the events in store to sink
+func NewRelay(store EventStore, sink Sink, config RelayConfig) *Relay {
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.Lease <= 0 {
+		config.Lease = time.Minute
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = 5 * time.Second
+	}
+	if config.MaxRetryDelay <= 0 {
+		config.MaxRetryDelay = 10 * time.Minute
+	}
+
+	return &Relay{
+		store:  store,
+		sink:   sink,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// RelayDue delivers the events due now and returns how many were delivered.
+// Delivering an event makes the next one of its aggregate due, so batches are
+// claimed until one delivers nothing.
+func (relay *Relay) RelayDue(ctx context.Context) (int, error) {
+	delivered := 0
+
+	for {
+		now := relay.now()
+		events, err := relay.store.ClaimOutboxEvents(ctx, db.ClaimOutboxEventsParams{
+			LockedUntil: pgtype.Timestamptz{Time: now.Add(relay.config.Lease), Valid: true},
+			Now:         now,
+			Limit:       relay.config.BatchSize,
+		})
+		if err != nil {
+			return delivered, fmt.Errorf("error claiming outbox events: %w", err)
+		}
+
+		batchDelivered := 0
+		for _, event := range events {
+			ok, err := relay.deliver(ctx, event)
+			if err != nil {
+				log.Println("error relaying outbox event: ", err)
+			}
+			if ok {
+				batchDelivered++
+			}
+		}
+		delivered += batchDelivered
+
+		if batchDelivered == 0 || ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+	}
+}
+
+// deliver hands event to the sink and records the outcome, scheduling a retry
+// when the sink fails. It reports whether the event was delivered.
+func (relay *Relay) deliver(ctx context.Context, event db.OutboxEvents) (bool, error) {
+	sinkErr := relay.sink.Deliver(ctx, event)
+	if sinkErr == nil {
+		// should marking fail, the lease runs out and the event is delivered again
+		if _, err := relay.store.MarkOutboxEventDelivered(ctx, event.ID); err != nil {
+			return true, fmt.Errorf("outbox event %d was delivered, and marking it failed: %w", event.ID, err)
+		}
+		return true, nil
+	}
+
+	_, err := relay.store.RetryOutboxEvent(ctx, db.RetryOutboxEventParams{
+		LastError:     sinkErr.Error(),
+		NextAttemptAt: relay.now().Add(relay.retryDelay(event.Attempts)),
+		ID:            event.ID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("outbox event %d failed with %v, and recording it failed: %w", event.ID, sinkErr, err)
+	}
+	return false, fmt.Errorf("outbox event %d failed: %w", event.ID, sinkErr)
+}
+
+// retryDelay is how long to wait after the delivery following attempts failed ones
+func (relay *Relay) retryDelay(attempts int32) time.Duration {
+	delay := relay.config.RetryDelay
+	for i := int32(0); i < attempts && delay < relay.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, relay.config.MaxRetryDelay)
+}
+
+// Run delivers the due events every Interval until ctx is cancelled
+func (relay *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := relay.RelayDue(ctx); err != nil {
+				log.Println("error relaying outbox events: ", err)
+			}
+		}
+	}
+}
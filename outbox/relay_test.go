@@ -0,0 +1,188 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore hands out the head event of each aggregate like the outbox query
+// does, releasing an aggregate's next event once the one before is delivered
+type fakeStore struct {
+	events    []db.OutboxEvents
+	claims    []db.ClaimOutboxEventsParams
+	delivered []int64
+	retries   []db.RetryOutboxEventParams
+}
+
+func (store *fakeStore) ClaimOutboxEvents(ctx context.Context, arg db.ClaimOutboxEventsParams) ([]db.OutboxEvents, error) {
+	store.claims = append(store.claims, arg)
+
+	var claimed []db.OutboxEvents
+	heads := map[string]bool{}
+	for i := range store.events {
+		event := &store.events[i]
+		if event.DeliveredAt.Valid {
+			continue
+		}
+
+		aggregate := event.AggregateType + "/" + event.AggregateID
+		if heads[aggregate] {
+			continue
+		}
+		heads[aggregate] = true
+
+		if !event.NextAttemptAt.After(arg.Now) && (!event.LockedUntil.Valid || !event.LockedUntil.Time.After(arg.Now)) && len(claimed) < int(arg.Limit) {
+			event.LockedUntil = arg.LockedUntil
+			claimed = append(claimed, *event)
+		}
+	}
+	return claimed, nil
+}
+
+func (store *fakeStore) MarkOutboxEventDelivered(ctx context.Context, id int64) (db.OutboxEvents, error) {
+	store.delivered = append(store.delivered, id)
+
+	event := store.find(id)
+	event.DeliveredAt.Valid = true
+	event.LockedUntil.Valid = false
+	return *event, nil
+}
+
+func (store *fakeStore) RetryOutboxEvent(ctx context.Context, arg db.RetryOutboxEventParams) (db.OutboxEvents, error) {
+	store.retries = append(store.retries, arg)
+
+	event := store.find(arg.ID)
+	event.Attempts++
+	event.LastError = arg.LastError
+	event.NextAttemptAt = arg.NextAttemptAt
+	event.LockedUntil.Valid = false
+	return *event, nil
+}
+
+func (store *fakeStore) find(id int64) *db.OutboxEvents {
+	for i := range store.events {
+		if store.events[i].ID == id {
+			return &store.events[i]
+		}
+	}
+	panic("no such event")
+}
+
+// fakeSink records what it is given and fails the events it is told to
+type fakeSink struct {
+	delivered []int64
+	fail      map[int64]error
+}
+
+func (sink *fakeSink) Deliver(ctx context.Context, event db.OutboxEvents) error {
+	if err := sink.fail[event.ID]; err != nil {
+		return err
+	}
+	sink.delivered = append(sink.delivered, event.ID)
+	return nil
+}
+
+func newTestRelay(store EventStore, sink Sink) (*Relay, time.Time) {
+	now := time.Now()
+	relay := NewRelay(store, sink, RelayConfig{
+		BatchSize:     2,
+		Lease:         time.Minute,
+		RetryDelay:    time.Second,
+		MaxRetryDelay: 5 * time.Second,
+	})
+	relay.now = func() time.Time { return now }
+	return relay, now
+}
+
+func outboxEvent(id int64, aggregateID string, attempts int32) db.OutboxEvents {
+	return db.OutboxEvents{
+		ID:            id,
+		AggregateType: "account",
+		AggregateID:   aggregateID,
+		EventType:     "transfer.sent",
+		Payload:       []byte(`{}`),
+		Attempts:      attempts,
+		NextAttemptAt: time.Now().Add(-time.Minute),
+	}
+}
+
+func TestRelayDeliversInAggregateOrder(t *testing.T) {
+	store := &fakeStore{events: []db.OutboxEvents{
+		outboxEvent(1, "1", 0),
+		outboxEvent(2, "2", 0),
+		outboxEvent(3, "1", 0),
+		outboxEvent(4, "1", 0),
+		outboxEvent(5, "2", 0),
+	}}
+	sink := &fakeSink{}
+	relay, now := newTestRelay(store, sink)
+
+	delivered, err := relay.RelayDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 5, delivered)
+
+	// each batch carries one event per aggregate, and a batch delivering
+	// something is followed by another
+	require.Equal(t, []int64{1, 2, 3, 5, 4}, sink.delivered)
+	require.Equal(t, sink.delivered, store.delivered)
+	require.Len(t, store.claims, 4)
+	require.Equal(t, now, store.claims[0].Now)
+	require.Equal(t, now.Add(time.Minute), store.claims[0].LockedUntil.Time)
+	require.Empty(t, store.retries)
+}
+
+func TestRelayRetriesFailedEvents(t *testing.T) {
+	store := &fakeStore{events: []db.OutboxEvents{
+		outboxEvent(1, "1", 0),
+		outboxEvent(2, "1", 0),
+		outboxEvent(3, "2", 3),
+	}}
+	sink := &fakeSink{fail: map[int64]error{
+		1: errors.New("connection refused"),
+		3: errors.New("connection refused"),
+	}}
+	relay, now := newTestRelay(store, sink)
+
+	delivered, err := relay.RelayDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, delivered)
+
+	// the event after a failed one waits for it
+	require.Empty(t, sink.delivered)
+	require.Len(t, store.retries, 2)
+
+	require.Equal(t, int64(1), store.retries[0].ID)
+	require.Equal(t, "connection refused", store.retries[0].LastError)
+	require.Equal(t, now.Add(time.Second), store.retries[0].NextAttemptAt)
+
+	// the delay doubles with each attempt, up to MaxRetryDelay
+	require.Equal(t, int64(3), store.retries[1].ID)
+	require.Equal(t, now.Add(5*time.Second), store.retries[1].NextAttemptAt)
+
+	// once the failed event goes through, the one after it follows
+	delete(sink.fail, 1)
+	relay.now = func() time.Time { return now.Add(2 * time.Second) }
+
+	delivered, err = relay.RelayDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, delivered)
+	require.Equal(t, []int64{1, 2}, sink.delivered)
+}
+
+func TestRelayRetryDelay(t *testing.T) {
+	relay := NewRelay(&fakeStore{}, &fakeSink{}, RelayConfig{
+		RetryDelay:    time.Second,
+		MaxRetryDelay: time.Minute,
+	})
+
+	require.Equal(t, time.Second, relay.retryDelay(0))
+	require.Equal(t, 2*time.Second, relay.retryDelay(1))
+	require.Equal(t, 32*time.Second, relay.retryDelay(5))
+	require.Equal(t, time.Minute, relay.retryDelay(6))
+	require.Equal(t, time.Minute, relay.retryDelay(1000))
+}
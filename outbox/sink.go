@@ -0,0 +1,127 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+)
+
+// Sink delivers outbox events downstream. Events are retried until their
+// sink takes them, so a sink can see an event more than once; consumers tell
+// the copies apart by the message ID.
+type Sink interface {
+	Deliver(ctx context.Context, event db.OutboxEvents) error
+}
+
+// Message is an outbox event as sinks deliver it
+type Message struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// NewMessage turns an outbox event into the message delivered for it
+func NewMessage(event db.OutboxEvents) Message {
+	return Message{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Payload:       event.Payload,
+		CreatedAt:     event.CreatedAt,
+	}
+}
+
+// LogSink writes every event to a logger, one JSON message per line
+type LogSink struct {
+	Logger *log.Logger
+}
+
+func (sink LogSink) Deliver(ctx context.Context, event db.OutboxEvents) error {
+	data, err := json.Marshal(NewMessage(event))
+	if err != nil {
+		return err
+	}
+
+	return sink.Logger.Output(2, string(data))
+}
+
+// WebhookSink posts every event as JSON to a URL, which must answer with a 2xx status
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (sink WebhookSink) Deliver(ctx context.Context, event db.OutboxEvents) error {
+	data, err := json.Marshal(NewMessage(event))
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	request.Header.Set("X-Event-Type", event.EventType)
+
+	client := sink.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error posting event: %w", err)
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("error posting event: %s returned %s", sink.URL, response.Status)
+	}
+	return nil
+}
+
+// NewSink returns the sink for location: a WebhookSink for http(s) URLs, a
+// NATSSink for nats:// URLs, and a LogSink writing to stdout when location is
+// empty or "stdout"
+func NewSink(location string) (Sink, error) {
+	switch {
+	case location == "" || location == "stdout":
+		return LogSink{Logger: log.New(os.Stdout, "", log.LstdFlags)}, nil
+	case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+		return WebhookSink{URL: location}, nil
+	case strings.HasPrefix(location, "nats://"):
+		return &NATSSink{Address: strings.TrimPrefix(location, "nats://")}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported outbox sink %q", location)
+}
+
+// Sinks delivers every event to each of its sinks in turn. An event one of
+// them fails is retried on all of them, so each still sees it at least once.
+type Sinks []Sink
+
+func (sinks Sinks) Deliver(ctx context.Context, event db.OutboxEvents) error {
+	for _, sink := range sinks {
+		if err := sink.Deliver(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package outbox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent() db.OutboxEvents {
+	return db.OutboxEvents{
+		ID:            42,
+		AggregateType: "account",
+		AggregateID:   "7",
+		EventType:     "transfer.received",
+		Payload:       []byte(`{"transfer":{"id":1}}`),
+		CreatedAt:     time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestLogSink(t *testing.T) {
+	var out bytes.Buffer
+	sink := LogSink{Logger: log.New(&out, "", 0)}
+
+	err := sink.Deliver(context.Background(), testEvent())
+	require.NoError(t, err)
+
+	var message Message
+	require.NoError(t, json.Unmarshal(out.Bytes(), &message))
+	require.Equal(t, NewMessage(testEvent()), message)
+}
+
+func TestWebhookSink(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		checkResponse func(t *testing.T, err error)
+	}{
+		{
+			name:   "OK",
+			status: http.StatusNoContent,
+			checkResponse: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:   "ServerError",
+			status: http.StatusBadGateway,
+			checkResponse: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "502")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var received Message
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				require.Equal(t, "42", r.Header.Get("X-Event-ID"))
+				require.Equal(t, "transfer.received", r.Header.Get("X-Event-Type"))
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			err := WebhookSink{URL: server.URL}.Deliver(context.Background(), testEvent())
+			tc.checkResponse(t, err)
+			require.Equal(t, NewMessage(testEvent()), received)
+		})
+	}
+}
+
+// fakeBroker speaks enough of the NATS protocol to take publishes, answering
+// the first ping of each connection with reply
+type fakeBroker struct {
+	listener  net.Listener
+	reply     string
+	published chan string
+}
+
+func newFakeBroker(t *testing.T, reply string) *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	broker := &fakeBroker{listener: listener, reply: reply, published: make(chan string, 10)}
+	go broker.serve()
+	return broker
+}
+
+func (broker *fakeBroker) serve() {
+	for {
+		conn, err := broker.listener.Accept()
+		if err != nil {
+			return
+		}
+		go broker.handle(conn)
+	}
+}
+
+func (broker *fakeBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+
+	reply := broker.reply
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "PUB "):
+			var subject string
+			var size int
+			fmt.Sscanf(line, "PUB %s %d", &subject, &size)
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			broker.published <- subject + " " + string(payload[:size])
+		case line == "PING":
+			fmt.Fprintf(conn, "%s\r\n", reply)
+			reply = "PONG"
+		}
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	broker := newFakeBroker(t, "PONG")
+	sink, err := NewSink("nats://" + broker.listener.Addr().String())
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		err := sink.Deliver(context.Background(), testEvent())
+		require.NoError(t, err)
+
+		published := <-broker.published
+		subject, data, _ := strings.Cut(published, " ")
+		require.Equal(t, "simplebank.transfer.received", subject)
+
+		var message Message
+		require.NoError(t, json.Unmarshal([]byte(data), &message))
+		require.Equal(t, NewMessage(testEvent()), message)
+	}
+}
+
+func TestNATSSinkError(t *testing.T) {
+	broker := newFakeBroker(t, "-ERR 'Permissions Violation'")
+	sink := &NATSSink{Address: broker.listener.Addr().String(), Timeout: time.Second}
+
+	err := sink.Deliver(context.Background(), testEvent())
+	require.ErrorContains(t, err, "Permissions Violation")
+	<-broker.published
+
+	// the sink reconnects after a failure
+	err = sink.Deliver(context.Background(), testEvent())
+	require.ErrorContains(t, err, "Permissions Violation")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	err = (&NATSSink{Address: address, Timeout: time.Second}).Deliver(context.Background(), testEvent())
+	require.Error(t, err)
+}
+
+func TestNewSink(t *testing.T) {
+	sink, err := NewSink("")
+	require.NoError(t, err)
+	require.IsType(t, LogSink{}, sink)
+
+	sink, err = NewSink("https://example.com/events")
+	require.NoError(t, err)
+	require.Equal(t, WebhookSink{URL: "https://example.com/events"}, sink)
+
+	_, err = NewSink("kafka://localhost:9092")
+	require.Error(t, err)
+}
+
+func TestSinks(t *testing.T) {
+	first := &fakeSink{}
+	second := &fakeSink{fail: map[int64]error{42: errors.New("connection refused")}}
+	third := &fakeSink{}
+
+	err := Sinks{first, second, third}.Deliver(context.Background(), testEvent())
+	require.ErrorContains(t, err, "connection refused")
+	require.Equal(t, []int64{42}, first.delivered)
+	require.Empty(t, third.delivered)
+
+	delete(second.fail, 42)
+	err = Sinks{first, second, third}.Deliver(context.Background(), testEvent())
+	require.NoError(t, err)
+	require.Equal(t, []int64{42, 42}, first.delivered)
+	require.Equal(t, []int64{42}, third.delivered)
+}
@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rouclec/simplebank/db/sqlc"
+)
+
+// TransferStore runs the scheduled transfers an Executor claims
+type TransferStore interface {
+	ClaimDueScheduledTransfers(ctx context.Context, arg db.ClaimDueScheduledTransfersParams) ([]db.ScheduledTransfers, error)
+	TransferTx(ctx context.Context, arg db.TransferTxRequest) (db.TransfersTxResponse, error)
+	FailScheduledTransferTx(ctx context.Context, arg db.FailScheduledTransferTxRequest) (db.ScheduledTransferTxResponse, error)
+}
+
+// ExecutorConfig controls how often due transfers are run and how failures are retried
+type ExecutorConfig struct {
+	// Interval between two looks for due transfers
+	Interval time.Duration
+	// BatchSize is how many due transfers are claimed at once
+	BatchSize int32
+	// Lease is how long a claimed transfer is kept from other replicas; it must
+	// outlast running a whole batch
+	Lease time.Duration
+	// MaxAttempts at an occurrence before it is given up
+	MaxAttempts int32
+	// RetryDelay before the first retry, doubled for each one after
+	RetryDelay time.Duration
+}
+
+// Executor runs the scheduled transfers that are due. Any number of replicas can
+// run one: each due transfer is claimed by a single replica, and the transfer
+// records its occurrence as run, so it moves money at most once.
+type Executor struct {
+	store  TransferStore
+	config ExecutorConfig
+	now    func() time.Time
+}
+
+// NewExecutor creates an executor running the transfers scheduled in store
+func NewExecutor(store TransferStore, config ExecutorConfig) *Executor {
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 20
+	}
+	if config.Lease <= 0 {
+		config.Lease = 5 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = 5 * time.Minute
+	}
+
+	return &Executor{
+		store:  store,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// RunDue runs the transfers due now, batch after batch, and returns how many
+// it attempted
+func (executor *Executor) RunDue(ctx context.Context) (int, error) {
+	attempted := 0
+
+	for {
+		now := executor.now()
+		schedules, err := executor.store.ClaimDueScheduledTransfers(ctx, db.ClaimDueScheduledTransfersParams{
+			LockedUntil: pgtype.Timestamptz{Time: now.Add(executor.config.Lease), Valid: true},
+			Now:         now,
+			Limit:       executor.config.BatchSize,
+		})
+		if err != nil {
+			return attempted, fmt.Errorf("error claiming scheduled transfers: %w", err)
+		}
+
+		for _, schedule := range schedules {
+			if err := executor.execute(ctx, schedule); err != nil {
+				log.Println("error running scheduled transfer: ", err)
+			}
+		}
+		attempted += len(schedules)
+
+		if len(schedules) < int(executor.config.BatchSize) || ctx.Err() != nil {
+			return attempted, ctx.Err()
+		}
+	}
+}
+
+// execute runs the due occurrence of schedule, recording it as failed or to be
+// retried when the transfer does not go through
+func (executor *Executor) execute(ctx context.Context, schedule db.ScheduledTransfers) error {
+	_, err := executor.store.TransferTx(ctx, db.TransferTxRequest{
+		FromAccountID:       schedule.FromAccountID,
+		ToAccountID:         schedule.ToAccountID,
+		Amount:              schedule.Amount,
+		Currency:            schedule.Currency,
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        schedule.DueAt,
+	})
+	if err == nil || errors.Is(err, db.ErrScheduleNotDue) {
+		// the occurrence ran, here or on another replica, or was cancelled
+		return nil
+	}
+
+	arg := db.FailScheduledTransferTxRequest{
+		ScheduledTransferID: schedule.ID,
+		ScheduledFor:        schedule.DueAt,
+		Error:               err.Error(),
+	}
+	if attempt := schedule.Attempts + 1; transient(err) && attempt < executor.config.MaxAttempts {
+		arg.RetryAt = executor.now().Add(executor.config.RetryDelay << (attempt - 1))
+	}
+
+	_, failErr := executor.store.FailScheduledTransferTx(ctx, arg)
+	if failErr != nil && !errors.Is(failErr, db.ErrScheduleNotDue) {
+		return fmt.Errorf("scheduled transfer %d failed with %v, and recording it failed: %w", schedule.ID, err, failErr)
+	}
+	return nil
+}
+
+// transient reports whether running the transfer again may succeed; transfers
+// refused on their merits fail their occurrence right away
+func transient(err error) bool {
+	switch {
+	case errors.Is(err, db.ErrInsufficientFunds),
+		errors.Is(err, db.ErrRecordNotFound),
+		errors.Is(err, db.ErrTransferBlocked),
+		errors.Is(err, db.ErrSystemAccount),
+		errors.Is(err, db.ErrAmountPrecision),
+		errors.Is(err, db.ErrUnbalancedJournal):
+		return false
+	}
+
+	return db.ErrorCode(err) != db.ForeignKeyViolation
+}
+
+// Run runs the due transfers every Interval until ctx is cancelled
+func (executor *Executor) Run(ctx context.Context) {
+	ticker := time.NewTicker(executor.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := executor.RunDue(ctx); err != nil {
+				log.Println("error running scheduled transfers: ", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore hands out the due schedules once and fails transfers with the
+// error the test gave it
+type fakeStore struct {
+	due         []db.ScheduledTransfers
+	transferErr error
+	claims      []db.ClaimDueScheduledTransfersParams
+	transfers   []db.TransferTxRequest
+	failures    []db.FailScheduledTransferTxRequest
+}
+
+func (store *fakeStore) ClaimDueScheduledTransfers(ctx context.Context, arg db.ClaimDueScheduledTransfersParams) ([]db.ScheduledTransfers, error) {
+	store.claims = append(store.claims, arg)
+
+	n := min(int(arg.Limit), len(store.due))
+	claimed := store.due[:n]
+	store.due = store.due[n:]
+	return claimed, nil
+}
+
+func (store *fakeStore) TransferTx(ctx context.Context, arg db.TransferTxRequest) (db.TransfersTxResponse, error) {
+	store.transfers = append(store.transfers, arg)
+	return db.TransfersTxResponse{}, store.transferErr
+}
+
+func (store *fakeStore) FailScheduledTransferTx(ctx context.Context, arg db.FailScheduledTransferTxRequest) (db.ScheduledTransferTxResponse, error) {
+	store.failures = append(store.failures, arg)
+	return db.ScheduledTransferTxResponse{}, nil
+}
+
+func newTestExecutor(store TransferStore) (*Executor, time.Time) {
+	now := time.Now()
+	executor := NewExecutor(store, ExecutorConfig{
+		BatchSize:   2,
+		Lease:       time.Minute,
+		MaxAttempts: 3,
+		RetryDelay:  time.Minute,
+	})
+	executor.now = func() time.Time { return now }
+	return executor, now
+}
+
+func dueSchedule(id int64, attempts int32) db.ScheduledTransfers {
+	return db.ScheduledTransfers{
+		ID:            id,
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        decimal.NewFromInt(10),
+		Currency:      "USD",
+		DueAt:         time.Now().Add(-time.Minute).Truncate(time.Microsecond),
+		Attempts:      attempts,
+	}
+}
+
+func TestExecutorRunsDueTransfers(t *testing.T) {
+	store := &fakeStore{due: []db.ScheduledTransfers{dueSchedule(1, 0), dueSchedule(2, 0), dueSchedule(3, 0)}}
+	executor, now := newTestExecutor(store)
+
+	attempted, err := executor.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, attempted)
+
+	// claimed in batches until one comes back short
+	require.Len(t, store.claims, 2)
+	require.Equal(t, now, store.claims[0].Now)
+	require.Equal(t, now.Add(time.Minute), store.claims[0].LockedUntil.Time)
+
+	require.Len(t, store.transfers, 3)
+	for i, transfer := range store.transfers {
+		require.Equal(t, int64(i+1), transfer.ScheduledTransferID)
+		require.False(t, transfer.ScheduledFor.IsZero())
+		require.Equal(t, "10", transfer.Amount.String())
+	}
+	require.Empty(t, store.failures)
+}
+
+func TestExecutorRetriesTransientFailures(t *testing.T) {
+	store := &fakeStore{
+		due:         []db.ScheduledTransfers{dueSchedule(1, 1)},
+		transferErr: errors.New("connection reset"),
+	}
+	executor, now := newTestExecutor(store)
+
+	_, err := executor.RunDue(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, store.failures, 1)
+	require.Equal(t, "connection reset", store.failures[0].Error)
+	// the second attempt failed, so the delay has doubled
+	require.Equal(t, now.Add(2*time.Minute), store.failures[0].RetryAt)
+}
+
+func TestExecutorGivesUp(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule db.ScheduledTransfers
+		err      error
+	}{
+		{name: "InsufficientFunds", schedule: dueSchedule(1, 0), err: db.ErrInsufficientFunds},
+		{name: "AccountGone", schedule: dueSchedule(1, 0), err: db.ErrRecordNotFound},
+		{name: "BlockedByScreening", schedule: dueSchedule(1, 0), err: fmt.Errorf("%w: new payee soon after a password change", db.ErrTransferBlocked)},
+		{name: "TooPrecise", schedule: dueSchedule(1, 0), err: fmt.Errorf("%w: 1.5 XAF", db.ErrAmountPrecision)},
+		{name: "OutOfAttempts", schedule: dueSchedule(1, 2), err: errors.New("connection reset")},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeStore{due: []db.ScheduledTransfers{tc.schedule}, transferErr: tc.err}
+			executor, _ := newTestExecutor(store)
+
+			_, err := executor.RunDue(context.Background())
+			require.NoError(t, err)
+
+			require.Len(t, store.failures, 1)
+			require.True(t, store.failures[0].RetryAt.IsZero())
+			require.Equal(t, tc.schedule.DueAt, store.failures[0].ScheduledFor)
+		})
+	}
+}
+
+func TestExecutorSkipsOccurrencesNoLongerDue(t *testing.T) {
+	store := &fakeStore{
+		due:         []db.ScheduledTransfers{dueSchedule(1, 0)},
+		transferErr: db.ErrScheduleNotDue,
+	}
+	executor, _ := newTestExecutor(store)
+
+	attempted, err := executor.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, attempted)
+	require.Empty(t, store.failures)
+}
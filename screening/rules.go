@@ -0,0 +1,163 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+// RulesStore holds the history the rules judge a transfer against
+type RulesStore interface {
+	GetUser(ctx context.Context, username string) (db.Users, error)
+	CountPayeeTransfers(ctx context.Context, arg db.CountPayeeTransfersParams) (int64, error)
+	GetTransferAmountStats(ctx context.Context, arg db.GetTransferAmountStatsParams) (db.GetTransferAmountStatsRow, error)
+	CountCrossCurrencyTransfers(ctx context.Context, arg db.CountCrossCurrencyTransfersParams) (int64, error)
+}
+
+// RulesConfig tunes the rules
+type RulesConfig struct {
+	// PasswordChangeWindow after a password change during which paying a new payee is blocked
+	PasswordChangeWindow time.Duration
+	// UnusualAmountFactor is how many times its average transfer an account must
+	// send for the amount to be reviewed
+	UnusualAmountFactor decimal.Decimal
+	// MinHistory is how many transfers an account must have made in a currency
+	// before its amounts in that currency are judged
+	MinHistory int64
+	// MaxCrossCurrencyTransfers a user can make within CrossCurrencyWindow before
+	// the next one is reviewed
+	MaxCrossCurrencyTransfers int64
+	CrossCurrencyWindow       time.Duration
+}
+
+// Rules is the built-in TransferScreener. It blocks paying a new payee soon
+// after a password change, the usual pattern of a taken over account, and
+// sends unusually large amounts and rapid hops between currencies to review.
+type Rules struct {
+	store  RulesStore
+	config RulesConfig
+	now    func() time.Time
+}
+
+// NewRules creates the rules engine judging transfers against the history in store
+func NewRules(store RulesStore, config RulesConfig) *Rules {
+	if config.PasswordChangeWindow <= 0 {
+		config.PasswordChangeWindow = 24 * time.Hour
+	}
+	if !config.UnusualAmountFactor.IsPositive() {
+		config.UnusualAmountFactor = decimal.NewFromInt(5)
+	}
+	if config.MinHistory <= 0 {
+		config.MinHistory = 5
+	}
+	if config.MaxCrossCurrencyTransfers <= 0 {
+		config.MaxCrossCurrencyTransfers = 3
+	}
+	if config.CrossCurrencyWindow <= 0 {
+		config.CrossCurrencyWindow = time.Hour
+	}
+
+	return &Rules{
+		store:  store,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// ScreenTransfer runs every rule and settles on the strictest verdict
+func (rules *Rules) ScreenTransfer(ctx context.Context, transfer Transfer) (Decision, error) {
+	decision := Decision{Verdict: Allow}
+
+	checks := []func(ctx context.Context, transfer Transfer, decision *Decision) error{
+		rules.newPayeeAfterPasswordChange,
+		rules.unusualAmount,
+		rules.crossCurrencyHops,
+	}
+
+	for _, check := range checks {
+		if err := check(ctx, transfer, &decision); err != nil {
+			return decision, err
+		}
+	}
+
+	return decision, nil
+}
+
+// newPayeeAfterPasswordChange blocks the first transfer to someone else's
+// account when the sender's password changed within PasswordChangeWindow
+func (rules *Rules) newPayeeAfterPasswordChange(ctx context.Context, transfer Transfer, decision *Decision) error {
+	if transfer.ToAccount.Owner == transfer.Username {
+		return nil
+	}
+
+	user, err := rules.store.GetUser(ctx, transfer.Username)
+	if err != nil {
+		return fmt.Errorf("error getting sender: %w", err)
+	}
+
+	if user.PasswordChangedAt.Before(rules.now().Add(-rules.config.PasswordChangeWindow)) {
+		return nil
+	}
+
+	paid, err := rules.store.CountPayeeTransfers(ctx, db.CountPayeeTransfersParams{
+		Owner:       transfer.Username,
+		ToAccountID: transfer.ToAccount.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error counting transfers to payee: %w", err)
+	}
+
+	if paid == 0 {
+		decision.flag(Block, "new payee soon after a password change")
+	}
+	return nil
+}
+
+// unusualAmount reviews transfers of more than UnusualAmountFactor times the
+// account's average transfer in the currency
+func (rules *Rules) unusualAmount(ctx context.Context, transfer Transfer, decision *Decision) error {
+	stats, err := rules.store.GetTransferAmountStats(ctx, db.GetTransferAmountStatsParams{
+		FromAccountID: transfer.FromAccount.ID,
+		Currency:      transfer.Currency,
+	})
+	if err != nil {
+		return fmt.Errorf("error getting transfer history: %w", err)
+	}
+
+	if stats.Count < rules.config.MinHistory {
+		return nil
+	}
+
+	if transfer.Amount.GreaterThan(stats.Average.Mul(rules.config.UnusualAmountFactor)) {
+		reason := fmt.Sprintf("amount is over %s times the account's average transfer of %s %s",
+			rules.config.UnusualAmountFactor, util.RoundToCurrency(stats.Average, transfer.Currency), transfer.Currency)
+		decision.flag(Review, reason)
+	}
+	return nil
+}
+
+// crossCurrencyHops reviews a cross-currency transfer once the sender has made
+// MaxCrossCurrencyTransfers of them within CrossCurrencyWindow
+func (rules *Rules) crossCurrencyHops(ctx context.Context, transfer Transfer, decision *Decision) error {
+	if transfer.FromAccount.Currency == transfer.ToAccount.Currency {
+		return nil
+	}
+
+	hops, err := rules.store.CountCrossCurrencyTransfers(ctx, db.CountCrossCurrencyTransfersParams{
+		Owner: transfer.Username,
+		Since: rules.now().Add(-rules.config.CrossCurrencyWindow),
+	})
+	if err != nil {
+		return fmt.Errorf("error counting cross-currency transfers: %w", err)
+	}
+
+	if hops >= rules.config.MaxCrossCurrencyTransfers {
+		reason := fmt.Sprintf("%d cross-currency transfers within %s", hops+1, rules.config.CrossCurrencyWindow)
+		decision.flag(Review, reason)
+	}
+	return nil
+}
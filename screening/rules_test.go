@@ -0,0 +1,146 @@
+package screening
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore answers the rules with the history the test gave it
+type fakeStore struct {
+	passwordChangedAt time.Time
+	payeeTransfers    int64
+	stats             db.GetTransferAmountStatsRow
+	crossCurrency     int64
+}
+
+func (store *fakeStore) GetUser(ctx context.Context, username string) (db.Users, error) {
+	return db.Users{Username: username, PasswordChangedAt: store.passwordChangedAt}, nil
+}
+
+func (store *fakeStore) CountPayeeTransfers(ctx context.Context, arg db.CountPayeeTransfersParams) (int64, error) {
+	return store.payeeTransfers, nil
+}
+
+func (store *fakeStore) GetTransferAmountStats(ctx context.Context, arg db.GetTransferAmountStatsParams) (db.GetTransferAmountStatsRow, error) {
+	return store.stats, nil
+}
+
+func (store *fakeStore) CountCrossCurrencyTransfers(ctx context.Context, arg db.CountCrossCurrencyTransfersParams) (int64, error) {
+	return store.crossCurrency, nil
+}
+
+func TestRulesScreenTransfer(t *testing.T) {
+	now := time.Now()
+
+	transfer := Transfer{
+		Username:    "sender",
+		FromAccount: db.Accounts{ID: 1, Owner: "sender", Currency: "USD"},
+		ToAccount:   db.Accounts{ID: 2, Owner: "payee", Currency: "USD"},
+		Amount:      decimal.NewFromInt(100),
+		Currency:    "USD",
+	}
+
+	crossCurrency := transfer
+	crossCurrency.ToAccount.Currency = "EUR"
+
+	ownAccount := transfer
+	ownAccount.ToAccount.Owner = "sender"
+
+	history := db.GetTransferAmountStatsRow{Count: 10, Average: decimal.NewFromInt(50)}
+
+	testCases := []struct {
+		name     string
+		store    fakeStore
+		transfer Transfer
+		verdict  Verdict
+		reasons  int
+	}{
+		{
+			name:     "Allow",
+			store:    fakeStore{stats: history},
+			transfer: transfer,
+			verdict:  Allow,
+		},
+		{
+			name:     "NewPayeeAfterPasswordChange",
+			store:    fakeStore{passwordChangedAt: now.Add(-time.Hour)},
+			transfer: transfer,
+			verdict:  Block,
+			reasons:  1,
+		},
+		{
+			name:     "KnownPayeeAfterPasswordChange",
+			store:    fakeStore{passwordChangedAt: now.Add(-time.Hour), payeeTransfers: 2},
+			transfer: transfer,
+			verdict:  Allow,
+		},
+		{
+			name:     "OwnAccountAfterPasswordChange",
+			store:    fakeStore{passwordChangedAt: now.Add(-time.Hour)},
+			transfer: ownAccount,
+			verdict:  Allow,
+		},
+		{
+			name:     "OldPasswordChange",
+			store:    fakeStore{passwordChangedAt: now.Add(-48 * time.Hour)},
+			transfer: transfer,
+			verdict:  Allow,
+		},
+		{
+			name:     "UnusualAmount",
+			store:    fakeStore{stats: db.GetTransferAmountStatsRow{Count: 10, Average: decimal.NewFromInt(10)}},
+			transfer: transfer,
+			verdict:  Review,
+			reasons:  1,
+		},
+		{
+			name:     "TooLittleHistory",
+			store:    fakeStore{stats: db.GetTransferAmountStatsRow{Count: 4, Average: decimal.NewFromInt(10)}},
+			transfer: transfer,
+			verdict:  Allow,
+		},
+		{
+			name:     "RapidCrossCurrencyHops",
+			store:    fakeStore{crossCurrency: 3},
+			transfer: crossCurrency,
+			verdict:  Review,
+			reasons:  1,
+		},
+		{
+			name:     "FewCrossCurrencyTransfers",
+			store:    fakeStore{crossCurrency: 2},
+			transfer: crossCurrency,
+			verdict:  Allow,
+		},
+		{
+			name: "StrictestVerdictWins",
+			store: fakeStore{
+				passwordChangedAt: now.Add(-time.Hour),
+				stats:             db.GetTransferAmountStatsRow{Count: 10, Average: decimal.NewFromInt(10)},
+				crossCurrency:     5,
+			},
+			transfer: crossCurrency,
+			verdict:  Block,
+			reasons:  3,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			rules := NewRules(&tc.store, RulesConfig{})
+			rules.now = func() time.Time { return now }
+
+			decision, err := rules.ScreenTransfer(context.Background(), tc.transfer)
+			require.NoError(t, err)
+			require.Equal(t, tc.verdict, decision.Verdict)
+			require.Len(t, decision.Reasons, tc.reasons)
+		})
+	}
+}
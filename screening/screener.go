@@ -0,0 +1,96 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/shopspring/decimal"
+)
+
+// Verdict is what screening decides about a transfer
+type Verdict string
+
+const (
+	// Allow lets the transfer go through
+	Allow Verdict = "allow"
+	// Review records the transfer pending until a banker approves or rejects it
+	Review Verdict = "review"
+	// Block refuses the transfer outright
+	Block Verdict = "block"
+)
+
+// severity orders verdicts so the strictest one found wins
+var severity = map[Verdict]int{Allow: 0, Review: 1, Block: 2}
+
+// Transfer is the transfer being screened
+type Transfer struct {
+	// Username of the sender, who owns FromAccount
+	Username    string
+	FromAccount db.Accounts
+	ToAccount   db.Accounts
+	Amount      decimal.Decimal
+	Currency    string
+}
+
+// Decision is the verdict on a transfer with what led to it
+type Decision struct {
+	Verdict Verdict
+	// Reasons the transfer was not simply allowed, empty when it was
+	Reasons []string
+}
+
+// flag raises the decision to verdict, unless it is already stricter, and keeps the reason
+func (decision *Decision) flag(verdict Verdict, reason string) {
+	if severity[verdict] > severity[decision.Verdict] {
+		decision.Verdict = verdict
+	}
+	decision.Reasons = append(decision.Reasons, reason)
+}
+
+// TransferScreener decides whether a transfer may go through before any money moves
+type TransferScreener interface {
+	ScreenTransfer(ctx context.Context, transfer Transfer) (Decision, error)
+}
+
+// AllowAll is a TransferScreener that lets every transfer through
+type AllowAll struct{}
+
+// ScreenTransfer allows the transfer
+func (AllowAll) ScreenTransfer(ctx context.Context, transfer Transfer) (Decision, error) {
+	return Decision{Verdict: Allow}, nil
+}
+
+// storeScreener is a db.TransferScreener judging transfers with a TransferScreener
+type storeScreener struct {
+	screener TransferScreener
+}
+
+// ForStore plugs screener into the store, so TransferTx screens every transfer
+// whichever path asked for it: a customer, a hold capture or a schedule
+func ForStore(screener TransferScreener) db.TransferScreener {
+	return storeScreener{screener: screener}
+}
+
+// ScreenTransferTx implements db.TransferScreener
+func (store storeScreener) ScreenTransferTx(ctx context.Context, fromAccount db.Accounts, toAccount db.Accounts, amount decimal.Decimal, currency string) ([]string, error) {
+	decision, err := store.screener.ScreenTransfer(ctx, Transfer{
+		Username:    fromAccount.Owner,
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		Amount:      amount,
+		Currency:    currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch decision.Verdict {
+	case Block:
+		return nil, fmt.Errorf("%w: %s", db.ErrTransferBlocked, strings.Join(decision.Reasons, "; "))
+	case Review:
+		return decision.Reasons, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,88 @@
+package screening
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// stubScreener decides every transfer the same way and keeps the last one it saw
+type stubScreener struct {
+	decision Decision
+	err      error
+	screened Transfer
+}
+
+func (screener *stubScreener) ScreenTransfer(ctx context.Context, transfer Transfer) (Decision, error) {
+	screener.screened = transfer
+	return screener.decision, screener.err
+}
+
+func TestForStore(t *testing.T) {
+	fromAccount := db.Accounts{ID: 1, Owner: "alice", Currency: "USD"}
+	toAccount := db.Accounts{ID: 2, Owner: "bob", Currency: "USD"}
+	amount := decimal.NewFromInt(10)
+
+	testCases := []struct {
+		name     string
+		screener *stubScreener
+		check    func(t *testing.T, reasons []string, err error)
+	}{
+		{
+			name:     "Allow",
+			screener: &stubScreener{decision: Decision{Verdict: Allow}},
+			check: func(t *testing.T, reasons []string, err error) {
+				require.NoError(t, err)
+				require.Empty(t, reasons)
+			},
+		},
+		{
+			name: "Review",
+			screener: &stubScreener{decision: Decision{
+				Verdict: Review,
+				Reasons: []string{"unusual amount", "rapid hops"},
+			}},
+			check: func(t *testing.T, reasons []string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{"unusual amount", "rapid hops"}, reasons)
+			},
+		},
+		{
+			name: "Block",
+			screener: &stubScreener{decision: Decision{
+				Verdict: Block,
+				Reasons: []string{"new payee soon after a password change"},
+			}},
+			check: func(t *testing.T, reasons []string, err error) {
+				require.ErrorIs(t, err, db.ErrTransferBlocked)
+				require.ErrorContains(t, err, "new payee soon after a password change")
+				require.Empty(t, reasons)
+			},
+		},
+		{
+			name:     "Error",
+			screener: &stubScreener{err: sql.ErrConnDone},
+			check: func(t *testing.T, reasons []string, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			reasons, err := ForStore(tc.screener).ScreenTransferTx(context.Background(), fromAccount, toAccount, amount, "USD")
+			tc.check(t, reasons, err)
+
+			// the sender is whoever owns the account the money leaves
+			require.Equal(t, fromAccount.Owner, tc.screener.screened.Username)
+			require.Equal(t, toAccount, tc.screener.screened.ToAccount)
+			require.True(t, amount.Equal(tc.screener.screened.Amount))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+)
+
+// csvHeader is the column layout of CSV statements. Accounting tools import
+// statements by column position, so columns may only ever be appended.
+var csvHeader = []string{
+	"date",
+	"entry_id",
+	"type",
+	"transfer_id",
+	"description",
+	"amount",
+	"balance",
+	"currency",
+}
+
+type csvEncoder struct {
+	w        *csv.Writer
+	currency string
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (encoder *csvEncoder) Begin(statement Statement) error {
+	encoder.currency = statement.Currency
+	return encoder.w.Write(csvHeader)
+}
+
+func (encoder *csvEncoder) WriteLine(line Line) error {
+	transferID := ""
+	if line.TransferID != 0 {
+		transferID = strconv.FormatInt(line.TransferID, 10)
+	}
+
+	return encoder.w.Write([]string{
+		line.Date.UTC().Format(time.RFC3339),
+		strconv.FormatInt(line.EntryID, 10),
+		line.Type,
+		transferID,
+		line.Description(),
+		util.FormatAmount(line.Amount, encoder.currency),
+		util.FormatAmount(line.Balance, encoder.currency),
+		encoder.currency,
+	})
+}
+
+func (encoder *csvEncoder) End() error {
+	encoder.w.Flush()
+	return encoder.w.Error()
+}
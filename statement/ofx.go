@@ -0,0 +1,109 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+)
+
+// ofxBankID identifies the bank in OFX statements
+const ofxBankID = "SIMPLEBANK"
+
+// ofxTime formats t the way OFX expects dates
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+// ofxEscape escapes s for use as element text
+func ofxEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// ofxTransactionType maps entry types onto OFX transaction types
+func ofxTransactionType(line Line) string {
+	switch line.Type {
+	case util.TransferEntry:
+		return "XFER"
+	case util.DepositEntry:
+		return "DEP"
+	case util.FeeEntry:
+		return "FEE"
+	}
+	if line.Amount.IsNegative() {
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// ofxEncoder writes OFX 2.2 bank statements
+type ofxEncoder struct {
+	w         io.Writer
+	statement Statement
+	err       error
+}
+
+func newOFXEncoder(w io.Writer) *ofxEncoder {
+	return &ofxEncoder{w: w}
+}
+
+func (encoder *ofxEncoder) printf(format string, args ...interface{}) {
+	if encoder.err != nil {
+		return
+	}
+	_, encoder.err = fmt.Fprintf(encoder.w, format, args...)
+}
+
+func (encoder *ofxEncoder) Begin(statement Statement) error {
+	encoder.statement = statement
+
+	encoder.printf("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n")
+	encoder.printf("<?OFX OFXHEADER=\"200\" VERSION=\"220\" SECURITY=\"NONE\" OLDFILEUID=\"NONE\" NEWFILEUID=\"NONE\"?>\n")
+	encoder.printf("<OFX>\n")
+	encoder.printf("<SIGNONMSGSRSV1><SONRS>\n")
+	encoder.printf("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	encoder.printf("<DTSERVER>%s</DTSERVER>\n", ofxTime(statement.GeneratedAt))
+	encoder.printf("<LANGUAGE>ENG</LANGUAGE>\n")
+	encoder.printf("</SONRS></SIGNONMSGSRSV1>\n")
+	encoder.printf("<BANKMSGSRSV1><STMTTRNRS>\n")
+	encoder.printf("<TRNUID>%d</TRNUID>\n", statement.AccountID)
+	encoder.printf("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	encoder.printf("<STMTRS>\n")
+	encoder.printf("<CURDEF>%s</CURDEF>\n", statement.Currency)
+	encoder.printf("<BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%d</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n", ofxBankID, statement.AccountID)
+	encoder.printf("<BANKTRANLIST>\n")
+	encoder.printf("<DTSTART>%s</DTSTART>\n", ofxTime(statement.From))
+	encoder.printf("<DTEND>%s</DTEND>\n", ofxTime(statement.To))
+
+	return encoder.err
+}
+
+func (encoder *ofxEncoder) WriteLine(line Line) error {
+	encoder.printf("<STMTTRN>")
+	encoder.printf("<TRNTYPE>%s</TRNTYPE>", ofxTransactionType(line))
+	encoder.printf("<DTPOSTED>%s</DTPOSTED>", ofxTime(line.Date))
+	encoder.printf("<TRNAMT>%s</TRNAMT>", util.FormatAmount(line.Amount, encoder.statement.Currency))
+	encoder.printf("<FITID>%d</FITID>", line.EntryID)
+	encoder.printf("<NAME>%s</NAME>", ofxEscape(line.Description()))
+	encoder.printf("</STMTTRN>\n")
+
+	return encoder.err
+}
+
+func (encoder *ofxEncoder) End() error {
+	encoder.printf("</BANKTRANLIST>\n")
+	encoder.printf("<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n",
+		util.FormatAmount(encoder.statement.ClosingBalance, encoder.statement.Currency),
+		ofxTime(encoder.statement.To),
+	)
+	encoder.printf("</STMTRS>\n")
+	encoder.printf("</STMTTRNRS></BANKMSGSRSV1>\n")
+	encoder.printf("</OFX>\n")
+
+	return encoder.err
+}
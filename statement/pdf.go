@@ -0,0 +1,176 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rouclec/simplebank/util"
+)
+
+// Layout of PDF statements: A4 pages of monospaced text
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 40
+	pdfFontSize     = 8
+	pdfLeading      = 11
+	pdfLinesPerPage = 66
+)
+
+// Objects written before any page
+const (
+	pdfCatalogObject = 1
+	pdfPagesObject   = 2
+	pdfFontObject    = 3
+)
+
+var pdfEscaper = strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+
+// countingWriter tracks the offset of what is written to w, which the PDF
+// cross-reference table needs
+type countingWriter struct {
+	w      io.Writer
+	offset int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.offset += int64(n)
+	return n, err
+}
+
+// pdfEncoder writes each page as soon as it is full, so the statement never
+// has to be held in memory
+type pdfEncoder struct {
+	w         *countingWriter
+	statement Statement
+	offsets   map[int]int64
+	nextID    int
+	pages     []int
+	lines     []string
+	err       error
+}
+
+func newPDFEncoder(w io.Writer) *pdfEncoder {
+	return &pdfEncoder{
+		w:       &countingWriter{w: w},
+		offsets: map[int]int64{},
+		nextID:  pdfFontObject + 1,
+	}
+}
+
+func (encoder *pdfEncoder) printf(format string, args ...interface{}) {
+	if encoder.err != nil {
+		return
+	}
+	_, encoder.err = fmt.Fprintf(encoder.w, format, args...)
+}
+
+func (encoder *pdfEncoder) writeObject(id int, body string) {
+	encoder.offsets[id] = encoder.w.offset
+	encoder.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (encoder *pdfEncoder) columns() string {
+	return fmt.Sprintf("%-16s  %10s  %-28s  %18s  %18s", "Date", "Entry", "Description", "Amount", "Balance")
+}
+
+func (encoder *pdfEncoder) Begin(statement Statement) error {
+	encoder.statement = statement
+
+	encoder.printf("%%PDF-1.4\n")
+	encoder.writeObject(pdfCatalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObject))
+	encoder.writeObject(pdfFontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	encoder.lines = append(encoder.lines,
+		fmt.Sprintf("Statement of account %d (%s)", statement.AccountID, statement.Currency),
+		fmt.Sprintf("Owner: %s", statement.Owner),
+		fmt.Sprintf("Period: %s to %s",
+			statement.From.UTC().Format("2006-01-02 15:04"),
+			statement.To.UTC().Format("2006-01-02 15:04"),
+		),
+		fmt.Sprintf("Generated: %s UTC", statement.GeneratedAt.UTC().Format("2006-01-02 15:04")),
+		"",
+		fmt.Sprintf("Opening balance: %s %s", util.FormatAmount(statement.OpeningBalance, statement.Currency), statement.Currency),
+		"",
+		encoder.columns(),
+	)
+
+	return encoder.err
+}
+
+func (encoder *pdfEncoder) WriteLine(line Line) error {
+	if len(encoder.lines) >= pdfLinesPerPage {
+		encoder.flushPage()
+		encoder.lines = append(encoder.lines, encoder.columns())
+	}
+
+	currency := encoder.statement.Currency
+	encoder.lines = append(encoder.lines, fmt.Sprintf("%-16s  %10d  %-28s  %18s  %18s",
+		line.Date.UTC().Format("2006-01-02 15:04"),
+		line.EntryID,
+		line.Description(),
+		util.FormatAmount(line.Amount, currency),
+		util.FormatAmount(line.Balance, currency),
+	))
+
+	return encoder.err
+}
+
+func (encoder *pdfEncoder) End() error {
+	if len(encoder.lines)+2 > pdfLinesPerPage {
+		encoder.flushPage()
+	}
+	encoder.lines = append(encoder.lines,
+		"",
+		fmt.Sprintf("Closing balance: %s %s",
+			util.FormatAmount(encoder.statement.ClosingBalance, encoder.statement.Currency),
+			encoder.statement.Currency,
+		),
+	)
+	encoder.flushPage()
+
+	kids := make([]string, len(encoder.pages))
+	for i, id := range encoder.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	encoder.writeObject(pdfPagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "), len(encoder.pages)))
+
+	xref := encoder.w.offset
+	encoder.printf("xref\n0 %d\n", encoder.nextID)
+	encoder.printf("0000000000 65535 f \n")
+	for id := 1; id < encoder.nextID; id++ {
+		encoder.printf("%010d 00000 n \n", encoder.offsets[id])
+	}
+	encoder.printf("trailer\n<< /Size %d /Root %d 0 R >>\n", encoder.nextID, pdfCatalogObject)
+	encoder.printf("startxref\n%d\n%%%%EOF\n", xref)
+
+	return encoder.err
+}
+
+// flushPage writes the buffered lines out as the next page
+func (encoder *pdfEncoder) flushPage() {
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+	for _, line := range encoder.lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", pdfEscaper.Replace(line))
+	}
+	fmt.Fprintf(&content, "ET\n")
+	fmt.Fprintf(&content, "BT /F1 %d Tf %d %d Td (Page %d) Tj ET\n", pdfFontSize, pdfMargin, pdfMargin/2, len(encoder.pages)+1)
+
+	contentID := encoder.nextID
+	pageID := encoder.nextID + 1
+	encoder.nextID += 2
+
+	encoder.writeObject(contentID, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	encoder.writeObject(pageID, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesObject, pdfPageWidth, pdfPageHeight, pdfFontObject, contentID,
+	))
+
+	encoder.pages = append(encoder.pages, pageID)
+	encoder.lines = encoder.lines[:0]
+}
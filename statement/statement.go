@@ -0,0 +1,100 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+)
+
+// Supported statement formats
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+	FormatPDF = "pdf"
+)
+
+// Statement describes the account and period a statement covers
+type Statement struct {
+	AccountID      int64
+	Owner          string
+	Currency       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance decimal.Decimal
+	ClosingBalance decimal.Decimal
+	GeneratedAt    time.Time
+}
+
+// Line is one entry of the account within the statement period
+type Line struct {
+	EntryID int64
+	Date    time.Time
+	Type    string
+	// TransferID is zero for entries that did not come from a transfer
+	TransferID int64
+	Amount     decimal.Decimal
+	// Balance is the account balance once the entry was applied
+	Balance decimal.Decimal
+}
+
+// Description is a human readable label for the line
+func (line Line) Description() string {
+	switch line.Type {
+	case util.TransferEntry:
+		return fmt.Sprintf("Transfer #%d", line.TransferID)
+	case util.DepositEntry:
+		return "Deposit"
+	case util.WithdrawalEntry:
+		return "Withdrawal"
+	case util.FeeEntry:
+		return fmt.Sprintf("Fee for transfer #%d", line.TransferID)
+	}
+	return line.Type
+}
+
+// Encoder writes a statement as it is produced: Begin once, WriteLine for every
+// entry in order, then End
+type Encoder interface {
+	Begin(statement Statement) error
+	WriteLine(line Line) error
+	End() error
+}
+
+// NewEncoder returns an encoder writing format to w
+func NewEncoder(format string, w io.Writer) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVEncoder(w), nil
+	case FormatOFX:
+		return newOFXEncoder(w), nil
+	case FormatPDF:
+		return newPDFEncoder(w), nil
+	}
+	return nil, fmt.Errorf("unsupported statement format %q", format)
+}
+
+// ContentType returns the MIME type of format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatPDF:
+		return "application/pdf"
+	}
+	return "application/octet-stream"
+}
+
+// FileName returns the name statements of the account over the period are downloaded as
+func FileName(statement Statement, format string) string {
+	return fmt.Sprintf("statement-%d-%s-%s.%s",
+		statement.AccountID,
+		statement.From.UTC().Format("20060102"),
+		statement.To.UTC().Format("20060102"),
+		format,
+	)
+}
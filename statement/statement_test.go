@@ -0,0 +1,176 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rouclec/simplebank/util"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func testStatement(currency string) Statement {
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return Statement{
+		AccountID:      42,
+		Owner:          "alice",
+		Currency:       currency,
+		From:           from,
+		To:             from.AddDate(0, 1, 0),
+		OpeningBalance: decimal.RequireFromString("100"),
+		ClosingBalance: decimal.RequireFromString("75.5"),
+		GeneratedAt:    from.AddDate(0, 2, 0),
+	}
+}
+
+func testLines(n int) []Line {
+	lines := make([]Line, n)
+	balance := decimal.RequireFromString("100")
+	for i := range lines {
+		amount := decimal.RequireFromString("-0.5")
+		line := Line{
+			EntryID: int64(i + 1),
+			Date:    time.Date(2024, time.January, 2, 10, i%60, 0, 0, time.UTC),
+			Type:    util.DepositEntry,
+			Amount:  amount.Neg(),
+		}
+		if i%2 == 0 {
+			line.Type = util.TransferEntry
+			line.TransferID = int64(100 + i)
+			line.Amount = amount
+		}
+		balance = balance.Add(line.Amount)
+		line.Balance = balance
+		lines[i] = line
+	}
+	return lines
+}
+
+func encode(t *testing.T, format string, statement Statement, lines []Line) []byte {
+	var buf bytes.Buffer
+	encoder, err := NewEncoder(format, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, encoder.Begin(statement))
+	for _, line := range lines {
+		require.NoError(t, encoder.WriteLine(line))
+	}
+	require.NoError(t, encoder.End())
+
+	return buf.Bytes()
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := NewEncoder("xls", &bytes.Buffer{})
+	require.Error(t, err)
+}
+
+func TestCSV(t *testing.T) {
+	output := encode(t, FormatCSV, testStatement("USD"), testLines(2))
+
+	records, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		csvHeader,
+		{"2024-01-02T10:00:00Z", "1", "transfer", "100", "Transfer #100", "-0.50", "99.50", "USD"},
+		{"2024-01-02T10:01:00Z", "2", "deposit", "", "Deposit", "0.50", "100.00", "USD"},
+	}, records)
+}
+
+func TestCSVCurrencyFormatting(t *testing.T) {
+	lines := []Line{{EntryID: 1, Type: util.DepositEntry, Amount: decimal.RequireFromString("1250"), Balance: decimal.RequireFromString("1350")}}
+	output := encode(t, FormatCSV, testStatement("XAF"), lines)
+
+	records, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "1250", records[1][5])
+	require.Equal(t, "1350", records[1][6])
+}
+
+func TestOFX(t *testing.T) {
+	statement := testStatement("EUR")
+	output := encode(t, FormatOFX, statement, testLines(3))
+
+	// the body after the processing instructions must be well formed XML
+	var document struct {
+		XMLName xml.Name `xml:"OFX"`
+		Rs      struct {
+			Currency     string `xml:"CURDEF"`
+			AccountID    string `xml:"BANKACCTFROM>ACCTID"`
+			Start        string `xml:"BANKTRANLIST>DTSTART"`
+			Transactions []struct {
+				Type   string `xml:"TRNTYPE"`
+				Amount string `xml:"TRNAMT"`
+				FITID  string `xml:"FITID"`
+				Name   string `xml:"NAME"`
+			} `xml:"BANKTRANLIST>STMTTRN"`
+			Balance string `xml:"LEDGERBAL>BALAMT"`
+		} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS"`
+	}
+	require.NoError(t, xml.Unmarshal(output, &document))
+
+	require.Equal(t, "EUR", document.Rs.Currency)
+	require.Equal(t, "42", document.Rs.AccountID)
+	require.Equal(t, "20240101000000.000[0:GMT]", document.Rs.Start)
+	require.Equal(t, "75.50", document.Rs.Balance)
+	require.Len(t, document.Rs.Transactions, 3)
+	require.Equal(t, "XFER", document.Rs.Transactions[0].Type)
+	require.Equal(t, "-0.50", document.Rs.Transactions[0].Amount)
+	require.Equal(t, "1", document.Rs.Transactions[0].FITID)
+	require.Equal(t, "Transfer #100", document.Rs.Transactions[0].Name)
+	require.Equal(t, "DEP", document.Rs.Transactions[1].Type)
+}
+
+func TestPDF(t *testing.T) {
+	testCases := []struct {
+		name  string
+		lines int
+		pages int
+	}{
+		{name: "Empty", lines: 0, pages: 1},
+		{name: "OnePage", lines: 10, pages: 1},
+		{name: "SeveralPages", lines: 150, pages: 3},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			output := encode(t, FormatPDF, testStatement("USD"), testLines(tc.lines))
+			document := string(output)
+
+			require.True(t, strings.HasPrefix(document, "%PDF-1.4\n"))
+			require.True(t, strings.HasSuffix(document, "%%EOF\n"))
+			require.Contains(t, document, fmt.Sprintf("/Count %d", tc.pages))
+			require.Contains(t, document, "(Opening balance: 100.00 USD)")
+			require.Contains(t, document, "(Closing balance: 75.50 USD)")
+
+			requireValidXref(t, output)
+		})
+	}
+}
+
+// requireValidXref checks every cross-reference entry points at its object
+func requireValidXref(t *testing.T, document []byte) {
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(document)
+	require.NotNil(t, startxref)
+	offset, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(document[offset:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(document[offset:], -1)
+	require.NotEmpty(t, entries)
+	for i, entry := range entries {
+		objectOffset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(document[objectOffset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))))
+	}
+}
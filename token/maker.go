@@ -6,11 +6,9 @@ import (
 
 // Maker is an interface for managing tokens
 type Maker interface {
-	// CreateToken creates a new token for a specific username and duration
-	// CreateToken(username string, role string, duration time.Duration) (string, *Payload, error)
+	// CreateToken creates a new token for a specific username, role, type and duration
+	CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error)
 
-	CreateToken(username string, duration time.Duration) (string, *Payload, error)
-
-	// VerifyToken checks if the token is valid or not
-	VerifyToken(token string) (*Payload, error)
+	// VerifyToken checks if the token is valid and of the expected type
+	VerifyToken(token string, tokenType TokenType) (*Payload, error)
 }
@@ -14,8 +14,8 @@ type PasetoMaker struct {
 }
 
 // CreateToken implements Maker.
-func (p *PasetoMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, duration)
+func (p *PasetoMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
 
 	if err != nil {
 		return "", payload, err
@@ -26,7 +26,7 @@ func (p *PasetoMaker) CreateToken(username string, duration time.Duration) (stri
 }
 
 // VerifyToken implements Maker.
-func (p *PasetoMaker) VerifyToken(token string) (*Payload, error) {
+func (p *PasetoMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
 	payload := &Payload{}
 
 	err := p.paseto.Decrypt(token, p.symetricKey, payload, nil)
@@ -41,6 +41,10 @@ func (p *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, err
 	}
 
+	if payload.Type != tokenType {
+		return nil, ErrInvalidToken
+	}
+
 	return payload, err
 }
 
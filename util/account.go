@@ -0,0 +1,16 @@
+package util
+
+// Kinds of account the ledger posts to
+const (
+	// CustomerAccount is an account opened by a user
+	CustomerAccount = "customer"
+	// CashAccount is the bank's side of money paid in and out
+	CashAccount = "cash"
+	// FXClearingAccount books the bank's side of currency conversions
+	FXClearingAccount = "fx_clearing"
+	// FeesIncomeAccount collects the fees charged to customers
+	FeesIncomeAccount = "fees_income"
+)
+
+// SystemOwner is the user owning the bank's system accounts
+const SystemOwner = "system"
@@ -0,0 +1,58 @@
+package util
+
+// Actions recorded in the audit log
+const (
+	// AuditSignup records a user signing up
+	AuditSignup = "user.signup"
+	// AuditLogin records a user logging in, opening a session
+	AuditLogin = "user.login"
+	// AuditLogout records a user logging out, blocking their session
+	AuditLogout = "user.logout"
+	// AuditLogoutAll records a user revoking every token issued to them
+	AuditLogoutAll = "user.logout_all"
+	// AuditRoleChange records a banker changing a user's role
+	AuditRoleChange = "user.role_change"
+	// AuditTierChange records a banker changing a user's tier
+	AuditTierChange = "user.tier_change"
+	// AuditAccountCreate records an account being opened
+	AuditAccountCreate = "account.create"
+	// AuditDeposit records money paid into an account
+	AuditDeposit = "account.deposit"
+	// AuditWithdrawal records money taken out of an account
+	AuditWithdrawal = "account.withdrawal"
+	// AuditTransferCreate records a transfer being made
+	AuditTransferCreate = "transfer.create"
+	// AuditTransferApprove records a banker approving a transfer held for review
+	AuditTransferApprove = "transfer.approve"
+	// AuditTransferReject records a banker rejecting a transfer held for review
+	AuditTransferReject = "transfer.reject"
+	// AuditTransferStatusChange records a pending transfer being completed,
+	// failed or cancelled other than by review
+	AuditTransferStatusChange = "transfer.status_change"
+	// AuditQuoteCreate records a transfer being priced for later execution
+	AuditQuoteCreate = "transfer_quote.create"
+	// AuditScheduleCreate records a scheduled transfer being set up
+	AuditScheduleCreate = "scheduled_transfer.create"
+	// AuditScheduleCancel records a scheduled transfer being cancelled
+	AuditScheduleCancel = "scheduled_transfer.cancel"
+	// AuditTransferReverse records a transfer being refunded, in whole or in part
+	AuditTransferReverse = "transfer.reverse"
+	// AuditHoldPlace records money being held on an account
+	AuditHoldPlace = "hold.place"
+	// AuditHoldCapture records a hold being spent by a transfer
+	AuditHoldCapture = "hold.capture"
+	// AuditHoldRelease records a hold being given up
+	AuditHoldRelease = "hold.release"
+	// AuditFeeScheduleCreate records a banker adding a fee schedule
+	AuditFeeScheduleCreate = "fee_schedule.create"
+	// AuditFeeScheduleDelete records a banker removing a fee schedule
+	AuditFeeScheduleDelete = "fee_schedule.delete"
+	// AuditTransferLimitCreate records a banker setting a transfer limit
+	AuditTransferLimitCreate = "transfer_limit.create"
+	// AuditTransferLimitDelete records a banker removing a transfer limit
+	AuditTransferLimitDelete = "transfer_limit.delete"
+	// AuditWebhookCreate records a webhook endpoint being registered
+	AuditWebhookCreate = "webhook.create"
+	// AuditWebhookDisable records a webhook endpoint being disabled
+	AuditWebhookDisable = "webhook.disable"
+)
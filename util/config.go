@@ -9,13 +9,36 @@ import (
 // Config stores all configuration of the application.
 // The values are read by viper from a config file or environment variable.
 type Config struct {
-	Environment         string        `mapstructure:"ENVIRONMENT"`
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	MigrationURL        string        `mapstructure:"MIGRATION_URL"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	Domain              string        `mapstructure:"DOMAIN"`
+	Environment          string        `mapstructure:"ENVIRONMENT"`
+	DBSource             string        `mapstructure:"DB_SOURCE"`
+	MigrationURL         string        `mapstructure:"MIGRATION_URL"`
+	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
+	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	Domain               string        `mapstructure:"DOMAIN"`
+	// RatesSource is a file path or http(s) URL of an exchange rate feed; when empty the exchange_rates table is used as is
+	RatesSource          string        `mapstructure:"RATES_SOURCE"`
+	RatesRefreshInterval time.Duration `mapstructure:"RATES_REFRESH_INTERVAL"`
+	RatesMaxAge          time.Duration `mapstructure:"RATES_MAX_AGE"`
+	RatesMaxJump         float64       `mapstructure:"RATES_MAX_JUMP"`
+	// RatesConfirmJumps is how many refreshes in a row must agree on a rate beyond RatesMaxJump before it is accepted, 3 when unset
+	RatesConfirmJumps int `mapstructure:"RATES_CONFIRM_JUMPS"`
+	// QuoteDuration is how long a transfer quote can be executed for, 30 seconds when unset
+	QuoteDuration time.Duration `mapstructure:"QUOTE_DURATION"`
+	// ScheduledTransfersInterval is how often due scheduled transfers are run, every minute when unset
+	ScheduledTransfersInterval    time.Duration `mapstructure:"SCHEDULED_TRANSFERS_INTERVAL"`
+	ScheduledTransfersMaxAttempts int32         `mapstructure:"SCHEDULED_TRANSFERS_MAX_ATTEMPTS"`
+	ScheduledTransfersRetryDelay  time.Duration `mapstructure:"SCHEDULED_TRANSFERS_RETRY_DELAY"`
+	// OutboxSink is where domain events are delivered: an http(s) webhook URL, a
+	// nats://host:port broker, or stdout when empty
+	OutboxSink          string        `mapstructure:"OUTBOX_SINK"`
+	OutboxRelayInterval time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"`
+	// WebhookDeliveryInterval is how often queued webhook deliveries are posted, every 5 seconds when unset
+	WebhookDeliveryInterval time.Duration `mapstructure:"WEBHOOK_DELIVERY_INTERVAL"`
+	WebhookMaxAttempts      int32         `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	// RevokedTokensPruneInterval is how often revocations of expired tokens are deleted, every hour when unset
+	RevokedTokensPruneInterval time.Duration `mapstructure:"REVOKED_TOKENS_PRUNE_INTERVAL"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -1,50 +1,58 @@
 package util
 
 import (
-	"fmt"
-	"strconv"
+	"github.com/shopspring/decimal"
 )
 
-
-var currencies = map[string]string{
-	"EUR": "EUR",
-	"XAF": "XAF",
-	"CAD": "CAD",
-	"USD": "USD",
+// currencies maps every supported currency to the number of decimal places
+// of its minor unit
+var currencies = map[string]int32{
+	"EUR": 2,
+	"XAF": 0,
+	"CAD": 2,
+	"USD": 2,
 }
 
-var rates = map[string]CurrencyRate{
-	"EUR": {Rate: 1.1},    // Euros per USD
-	"XAF": {Rate: 607.29}, // West African Francs per USD
-	"CAD": {Rate: 1.35},   // Canadian dollar per USD
-	"USD": {Rate: 1},      // USD per USD
-}
+// RatePrecision is the number of decimal places kept on exchange rates applied to transfers
+const RatePrecision = 8
 
 func IsSupportedCurrency(currency string) bool {
 	_, ok := currencies[currency]
 	return ok
 }
 
-func Converter(fromCurrency string, toCurrency string, amount float64) (float64, error) {
-
-	if _, ok := rates[fromCurrency]; !ok {
-		return 0, fmt.Errorf("unsupported currency: %s", fromCurrency)
-	}
-	if _, ok := rates[toCurrency]; !ok {
-		return 0, fmt.Errorf("unsupported currency: %s", toCurrency)
+// CurrencyDecimals returns the number of decimal places of the currency's minor unit
+func CurrencyDecimals(currency string) int32 {
+	if decimals, ok := currencies[currency]; ok {
+		return decimals
 	}
+	return 2
+}
 
-	if fromCurrency == toCurrency {
-		return amount, nil
-	}
+// RoundToCurrency rounds amount to the minor unit of the given currency
+func RoundToCurrency(amount decimal.Decimal, currency string) decimal.Decimal {
+	return amount.Round(CurrencyDecimals(currency))
+}
 
-	// Convert to USD first
-	usdAmount := float64(amount) / rates[fromCurrency].Rate
+// FitsCurrency reports whether amount has no more decimal places than the
+// minor unit of the given currency, so 1.50 fits USD but not XAF
+func FitsCurrency(amount decimal.Decimal, currency string) bool {
+	return amount.Equal(RoundToCurrency(amount, currency))
+}
 
-	// Convert from USD to target currency
-	convertedAmount := usdAmount * rates[toCurrency].Rate
-	roundedAmount := fmt.Sprintf("%.2f", convertedAmount)
-	parsedAmount, _ := strconv.ParseFloat(roundedAmount, 64)
+// CrossRate returns how many units of the target currency one unit of the source
+// currency buys, given both currencies' rates per USD
+func CrossRate(fromRate decimal.Decimal, toRate decimal.Decimal) decimal.Decimal {
+	return toRate.DivRound(fromRate, RatePrecision)
+}
+
+// ConvertAmount applies rate to amount and rounds the result to the minor unit of currency
+func ConvertAmount(amount decimal.Decimal, rate decimal.Decimal, currency string) decimal.Decimal {
+	return RoundToCurrency(amount.Mul(rate), currency)
+}
 
-	return parsedAmount, nil
+// FormatAmount renders amount with exactly the number of decimal places of the
+// currency's minor unit, e.g. "12.50" for USD and "1250" for XAF
+func FormatAmount(amount decimal.Decimal, currency string) string {
+	return amount.StringFixed(CurrencyDecimals(currency))
 }
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrossRate(t *testing.T) {
+	usd := decimal.RequireFromString("1")
+	eur := decimal.RequireFromString("1.1")
+	cad := decimal.RequireFromString("1.35")
+
+	require.Equal(t, "1.1", CrossRate(usd, eur).String())
+	require.Equal(t, "0.90909091", CrossRate(eur, usd).String())
+	require.Equal(t, "1.22727273", CrossRate(eur, cad).String())
+	require.Equal(t, "1", CrossRate(cad, cad).String())
+}
+
+func TestConvertAmount(t *testing.T) {
+	testCases := []struct {
+		name     string
+		amount   string
+		rate     string
+		currency string
+		expected string
+	}{
+		{name: "SameCurrency", amount: "10.10", rate: "1", currency: "USD", expected: "10.10"},
+		{name: "USDToEUR", amount: "0.10", rate: "1.1", currency: "EUR", expected: "0.11"},
+		{name: "EURToUSD", amount: "11", rate: "0.90909091", currency: "USD", expected: "10"},
+		{name: "USDToXAFRoundsToWholeFrancs", amount: "10", rate: "607.29", currency: "XAF", expected: "6073"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			converted := ConvertAmount(decimal.RequireFromString(tc.amount), decimal.RequireFromString(tc.rate), tc.currency)
+			require.True(t, converted.Equal(decimal.RequireFromString(tc.expected)), "got %s", converted)
+		})
+	}
+}
+
+func TestConvertAmountNoDrift(t *testing.T) {
+	// 0.1 added a thousand times drifts away from 100 with float64
+	total := decimal.Zero
+	for i := 0; i < 1000; i++ {
+		total = total.Add(ConvertAmount(decimal.RequireFromString("0.1"), decimal.NewFromInt(1), "USD"))
+	}
+
+	require.True(t, total.Equal(decimal.NewFromInt(100)))
+}
+
+func TestRoundToCurrency(t *testing.T) {
+	require.Equal(t, "10.01", RoundToCurrency(decimal.RequireFromString("10.005"), "USD").String())
+	require.Equal(t, "11", RoundToCurrency(decimal.RequireFromString("10.5"), "XAF").String())
+}
+
+func TestFitsCurrency(t *testing.T) {
+	require.True(t, FitsCurrency(decimal.RequireFromString("1.50"), "USD"))
+	require.False(t, FitsCurrency(decimal.RequireFromString("1.505"), "USD"))
+	require.True(t, FitsCurrency(decimal.RequireFromString("1500"), "XAF"))
+	require.True(t, FitsCurrency(decimal.RequireFromString("1.00"), "XAF"))
+	require.False(t, FitsCurrency(decimal.RequireFromString("1.50"), "XAF"))
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "12.50", FormatAmount(decimal.RequireFromString("12.5"), "USD"))
+	require.Equal(t, "-0.10", FormatAmount(decimal.RequireFromString("-0.1"), "EUR"))
+	require.Equal(t, "1250", FormatAmount(decimal.RequireFromString("1250"), "XAF"))
+	require.Equal(t, "1251", FormatAmount(decimal.RequireFromString("1250.5"), "XAF"))
+}
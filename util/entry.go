@@ -0,0 +1,13 @@
+package util
+
+// Kinds of movement an account entry can record
+const (
+	// TransferEntry is one side of a transfer between two accounts
+	TransferEntry = "transfer"
+	// DepositEntry credits money paid into an account
+	DepositEntry = "deposit"
+	// WithdrawalEntry debits money paid out of an account
+	WithdrawalEntry = "withdrawal"
+	// FeeEntry debits the fee charged for a transfer
+	FeeEntry = "fee"
+)
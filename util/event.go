@@ -0,0 +1,28 @@
+package util
+
+// Domain events written to the outbox for downstream systems
+const (
+	// EventUserCreated is published when a user signs up
+	EventUserCreated = "user.created"
+	// EventAccountCreated is published when an account is opened
+	EventAccountCreated = "account.created"
+	// EventTransferSent is published on the source account when a transfer's money moves
+	EventTransferSent = "transfer.sent"
+	// EventTransferReceived is published on the destination account when a transfer's money moves
+	EventTransferReceived = "transfer.received"
+)
+
+// Aggregates the domain events are ordered by
+const (
+	UserAggregate    = "user"
+	AccountAggregate = "account"
+)
+
+// IsSubscribableEvent returns true if webhook endpoints can subscribe to the event
+func IsSubscribableEvent(eventType string) bool {
+	switch eventType {
+	case EventAccountCreated, EventTransferSent, EventTransferReceived:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package util
+
+// Kinds of fee schedule charged on transfers
+const (
+	// FlatFee charges a fixed amount on every transfer
+	FlatFee = "flat"
+	// PercentageFee charges a fraction of the debited amount
+	PercentageFee = "percentage"
+	// TieredFee charges a fixed amount plus a fraction when the debited amount falls within its band
+	TieredFee = "tiered"
+	// FXMarkupFee charges a fraction of the debited amount on cross-currency transfers only
+	FXMarkupFee = "fx_markup"
+)
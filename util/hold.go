@@ -0,0 +1,11 @@
+package util
+
+// Statuses of a hold on an account
+const (
+	// HoldActive holds reserve their amount until they expire
+	HoldActive = "active"
+	// HoldCaptured holds were turned into a transfer
+	HoldCaptured = "captured"
+	// HoldReleased holds were given up without moving any money
+	HoldReleased = "released"
+)
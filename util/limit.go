@@ -0,0 +1,25 @@
+package util
+
+import "time"
+
+// Kinds of transfer limit
+const (
+	// DailyLimit caps what is debited in a UTC day
+	DailyLimit = "daily"
+	// MonthlyLimit caps what is debited in a UTC month
+	MonthlyLimit = "monthly"
+	// SingleLimit caps what a single transfer debits
+	SingleLimit = "single"
+	// VelocityLimit caps how many transfers are made in a window of minutes
+	VelocityLimit = "velocity"
+)
+
+// LimitPeriodStart returns when the period of a daily or monthly limit that
+// now falls in started, in UTC
+func LimitPeriodStart(kind string, now time.Time) time.Time {
+	year, month, day := now.UTC().Date()
+	if kind == MonthlyLimit {
+		day = 1
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
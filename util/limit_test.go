@@ -0,0 +1,15 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitPeriodStart(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 1, 30, 0, 0, time.FixedZone("EAT", 3*60*60))
+
+	require.Equal(t, time.Date(2024, time.March, 14, 0, 0, 0, 0, time.UTC), LimitPeriodStart(DailyLimit, now))
+	require.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), LimitPeriodStart(MonthlyLimit, now))
+}
@@ -5,12 +5,14 @@ import (
 	"math/rand"
 	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 const alphabet = "abcdefghijklmnopqrstuvwxyz"
 
 type CurrencyRate struct {
-	Rate float64 `json:"rate"`
+	Rate decimal.Decimal `json:"rate"`
 }
 
 func init() {
@@ -40,15 +42,17 @@ func RandomOwner() string {
 	return RandomString(6)
 }
 
-func RandomBalance(currency string) float64 {
+func RandomBalance(currency string) decimal.Decimal {
 	amounts := map[string]CurrencyRate{
-		"EUR": {Rate: 1.10},   // Euros per USD
-		"XAF": {Rate: 607.29}, // West African Francs per USD
-		"CAD": {Rate: 1.35},   // Canadian dollar per USD
-		"USD": {Rate: 1.00},   // USD per USD
+		"EUR": {Rate: decimal.NewFromInt(1)},   // Euros per USD
+		"XAF": {Rate: decimal.NewFromInt(607)}, // West African Francs per USD
+		"CAD": {Rate: decimal.NewFromInt(1)},   // Canadian dollar per USD
+		"USD": {Rate: decimal.NewFromInt(1)},   // USD per USD
 	}
 
-	return float64(RandomInt(int64(amounts[currency].Rate)*100*10, int64(amounts[currency].Rate)*100*100))
+	rate := amounts[currency].Rate.IntPart()
+
+	return decimal.NewFromInt(RandomInt(rate*100*10, rate*100*100))
 }
 
 func RandomCurrency() string {
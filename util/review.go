@@ -0,0 +1,11 @@
+package util
+
+// Statuses of the manual review of a screened transfer
+const (
+	// ReviewPending reviews await a banker, their transfer is held pending
+	ReviewPending = "pending"
+	// ReviewApproved reviews completed their transfer
+	ReviewApproved = "approved"
+	// ReviewRejected reviews failed their transfer
+	ReviewRejected = "rejected"
+)
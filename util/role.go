@@ -0,0 +1,20 @@
+package util
+
+// Roles a user can hold, carried in their tokens
+const (
+	// DepositorRole is the role of every customer signing up
+	DepositorRole = "depositor"
+	// BankerRole is the bank staff role with administrative access
+	BankerRole = "banker"
+	// AuditorRole can read across customers but not change anything
+	AuditorRole = "auditor"
+)
+
+// IsSupportedRole returns true if the role is one of the known roles
+func IsSupportedRole(role string) bool {
+	switch role {
+	case DepositorRole, BankerRole, AuditorRole:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package util
+
+import "time"
+
+// How often a scheduled transfer runs
+const (
+	// OnceFrequency runs a single future transfer
+	OnceFrequency = "once"
+	// WeeklyFrequency runs every seven days from the first run
+	WeeklyFrequency = "weekly"
+	// MonthlyFrequency runs every month on the schedule's day of month
+	MonthlyFrequency = "monthly"
+)
+
+// Lifecycle of a scheduled transfer
+const (
+	// ScheduleActive schedules have an occurrence waiting to run
+	ScheduleActive = "active"
+	// ScheduleCompleted schedules have no occurrence left
+	ScheduleCompleted = "completed"
+	// ScheduleCancelled schedules were stopped by their owner
+	ScheduleCancelled = "cancelled"
+	// ScheduleFailed schedules gave up on their last occurrence
+	ScheduleFailed = "failed"
+)
+
+// Outcomes of an attempt at running a scheduled transfer
+const (
+	// RunSucceeded attempts moved the money
+	RunSucceeded = "succeeded"
+	// RunRetrying attempts failed and will be tried again
+	RunRetrying = "retrying"
+	// RunFailed attempts failed for good
+	RunFailed = "failed"
+)
+
+// NextRun returns the occurrence following due, or false when the frequency
+// does not recur. Monthly runs fall on dayOfMonth, or on the last day of months
+// too short for it. Dates are worked out in UTC.
+func NextRun(frequency string, dayOfMonth int32, due time.Time) (time.Time, bool) {
+	due = due.UTC()
+
+	switch frequency {
+	case WeeklyFrequency:
+		return due.AddDate(0, 0, 7), true
+	case MonthlyFrequency:
+		next := time.Date(due.Year(), due.Month()+1, 1, due.Hour(), due.Minute(), due.Second(), due.Nanosecond(), time.UTC)
+		lastDay := int32(time.Date(next.Year(), next.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day())
+
+		day := dayOfMonth
+		if day <= 0 {
+			day = int32(due.Day())
+		}
+		if day > lastDay {
+			day = lastDay
+		}
+		return next.AddDate(0, 0, int(day)-1), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextRun(t *testing.T) {
+	testCases := []struct {
+		name       string
+		frequency  string
+		dayOfMonth int32
+		due        string
+		expected   string
+	}{
+		{name: "Weekly", frequency: WeeklyFrequency, due: "2024-02-26T09:30:00Z", expected: "2024-03-04T09:30:00Z"},
+		{name: "Monthly", frequency: MonthlyFrequency, dayOfMonth: 15, due: "2024-01-15T09:30:00Z", expected: "2024-02-15T09:30:00Z"},
+		{name: "MonthlyClampsToShorterMonth", frequency: MonthlyFrequency, dayOfMonth: 31, due: "2024-01-31T09:30:00Z", expected: "2024-02-29T09:30:00Z"},
+		{name: "MonthlyReturnsToDayAfterShortMonth", frequency: MonthlyFrequency, dayOfMonth: 31, due: "2024-02-29T09:30:00Z", expected: "2024-03-31T09:30:00Z"},
+		{name: "MonthlyAcrossYearEnd", frequency: MonthlyFrequency, dayOfMonth: 5, due: "2024-12-05T09:30:00Z", expected: "2025-01-05T09:30:00Z"},
+		{name: "MonthlyDefaultsToDueDay", frequency: MonthlyFrequency, due: "2024-04-10T09:30:00Z", expected: "2024-05-10T09:30:00Z"},
+		{name: "MonthlyInUTC", frequency: MonthlyFrequency, dayOfMonth: 1, due: "2024-03-01T01:00:00+02:00", expected: "2024-03-01T23:00:00Z"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			due, err := time.Parse(time.RFC3339, tc.due)
+			require.NoError(t, err)
+
+			next, ok := NextRun(tc.frequency, tc.dayOfMonth, due)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, next.Format(time.RFC3339))
+		})
+	}
+}
+
+func TestNextRunOnce(t *testing.T) {
+	_, ok := NextRun(OnceFrequency, 0, time.Now())
+	require.False(t, ok)
+}
@@ -0,0 +1,18 @@
+package util
+
+// Tiers a user can be on, picking the transfer limits that apply to them
+const (
+	// StandardTier is the tier of every customer signing up
+	StandardTier = "standard"
+	// PremiumTier customers usually get higher limits
+	PremiumTier = "premium"
+)
+
+// IsSupportedTier returns true if the tier is one of the known tiers
+func IsSupportedTier(tier string) bool {
+	switch tier {
+	case StandardTier, PremiumTier:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package util
+
+// Lifecycle of a transfer
+const (
+	// TransferPending transfers are priced but move no money until completed
+	TransferPending = "pending"
+	// TransferCompleted transfers have moved the money
+	TransferCompleted = "completed"
+	// TransferFailed transfers were refused, e.g. on review
+	TransferFailed = "failed"
+	// TransferCancelled transfers were withdrawn by their sender
+	TransferCancelled = "cancelled"
+)
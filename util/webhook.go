@@ -0,0 +1,11 @@
+package util
+
+// Statuses of the delivery of an event to a webhook endpoint
+const (
+	// WebhookDeliveryPending deliveries are waiting for their next attempt
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliverySucceeded deliveries were accepted by the endpoint
+	WebhookDeliverySucceeded = "succeeded"
+	// WebhookDeliveryFailed deliveries ran out of attempts, or their endpoint was disabled
+	WebhookDeliveryFailed = "failed"
+)
@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrInsecureURL is returned for endpoint URLs that do not use https
+	ErrInsecureURL = errors.New("webhook url must use https")
+	// ErrForbiddenAddress is returned for endpoints on the bank's own network:
+	// loopback, private, link-local and unspecified addresses
+	ErrForbiddenAddress = errors.New("webhook url must resolve to a public address")
+)
+
+// Resolver looks up the addresses of a host, as net.DefaultResolver does
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network string, host string) ([]netip.Addr, error)
+}
+
+// PublicAddress reports whether addr can be posted to, being neither
+// loopback, private, link-local, multicast nor unspecified
+func PublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// CheckURL checks that rawURL is an https URL whose host only resolves to
+// public addresses. Deliveries are checked again when dialing, since the host
+// may resolve elsewhere by then.
+func CheckURL(ctx context.Context, resolver Resolver, rawURL string) error {
+	endpoint, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if endpoint.Scheme != "https" {
+		return ErrInsecureURL
+	}
+
+	host := endpoint.Hostname()
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		addrs, err = resolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return fmt.Errorf("error resolving %s: %w", host, err)
+		}
+	}
+
+	for _, addr := range addrs {
+		if !PublicAddress(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, host, addr)
+		}
+	}
+	return nil
+}
+
+// newClient creates the client deliveries are posted with. It only connects to
+// the addresses permit allows, checked on the address actually dialed so a
+// host rebound to an internal address after registration is refused too, and
+// it never follows redirects, which could point anywhere.
+func newClient(timeout time.Duration, permit func(addr netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+
+			if !permit(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// a proxy would dial the endpoint for us, out of reach of the check
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver resolves hosts from a fixed table
+type fakeResolver map[string][]string
+
+func (resolver fakeResolver) LookupNetIP(ctx context.Context, network string, host string) ([]netip.Addr, error) {
+	addrs, ok := resolver[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+
+	resolved := []netip.Addr{}
+	for _, addr := range addrs {
+		resolved = append(resolved, netip.MustParseAddr(addr))
+	}
+	return resolved, nil
+}
+
+func TestCheckURL(t *testing.T) {
+	resolver := fakeResolver{
+		"hooks.example.com": {"93.184.215.14", "2606:2800:21f:cb07:6820:80da:af6b:8b2c"},
+		"localhost":         {"127.0.0.1", "::1"},
+		"intranet.example":  {"93.184.215.14", "10.1.2.3"},
+	}
+
+	testCases := []struct {
+		name string
+		url  string
+		err  error
+	}{
+		{name: "PublicHost", url: "https://hooks.example.com/events"},
+		{name: "PublicIPv4", url: "https://93.184.215.14:8443/events"},
+		{name: "PublicIPv6", url: "https://[2606:2800:21f:cb07:6820:80da:af6b:8b2c]/events"},
+		{name: "HTTP", url: "http://hooks.example.com/events", err: ErrInsecureURL},
+		{name: "Localhost", url: "https://localhost/events", err: ErrForbiddenAddress},
+		{name: "LoopbackIPv4", url: "https://127.0.0.1/events", err: ErrForbiddenAddress},
+		{name: "LoopbackIPv6", url: "https://[::1]/events", err: ErrForbiddenAddress},
+		{name: "MappedLoopback", url: "https://[::ffff:127.0.0.1]/events", err: ErrForbiddenAddress},
+		{name: "Private10", url: "https://10.0.0.1/events", err: ErrForbiddenAddress},
+		{name: "Private172", url: "https://172.16.5.4/events", err: ErrForbiddenAddress},
+		{name: "Private192", url: "https://192.168.1.1/events", err: ErrForbiddenAddress},
+		{name: "PrivateIPv6", url: "https://[fd00::1]/events", err: ErrForbiddenAddress},
+		{name: "LinkLocalMetadata", url: "https://169.254.169.254/latest/meta-data", err: ErrForbiddenAddress},
+		{name: "LinkLocalIPv6", url: "https://[fe80::1]/events", err: ErrForbiddenAddress},
+		{name: "UnspecifiedIPv4", url: "https://0.0.0.0/events", err: ErrForbiddenAddress},
+		{name: "UnspecifiedIPv6", url: "https://[::]/events", err: ErrForbiddenAddress},
+		{name: "AnyAddressPrivate", url: "https://intranet.example/events", err: ErrForbiddenAddress},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckURL(context.Background(), resolver, tc.url)
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestCheckURLUnresolvable(t *testing.T) {
+	err := CheckURL(context.Background(), fakeResolver{}, "https://nowhere.example/events")
+	require.Error(t, err)
+}
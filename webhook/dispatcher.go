@@ -0,0 +1,214 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/util"
+)
+
+// DeliveryStore holds the webhook deliveries a Dispatcher posts
+type DeliveryStore interface {
+	ClaimDueWebhookDeliveries(ctx context.Context, arg db.ClaimDueWebhookDeliveriesParams) ([]db.WebhookDeliveries, error)
+	GetWebhookEndpoint(ctx context.Context, id int64) (db.WebhookEndpoints, error)
+	RecordWebhookDeliveryAttempt(ctx context.Context, arg db.RecordWebhookDeliveryAttemptParams) (db.WebhookDeliveries, error)
+}
+
+// DispatcherConfig controls how often deliveries are posted and how failures are retried
+type DispatcherConfig struct {
+	// Interval between two looks for due deliveries
+	Interval time.Duration
+	// BatchSize is how many due deliveries are claimed at once
+	BatchSize int32
+	// Timeout of each post to an endpoint
+	Timeout time.Duration
+	// Lease is how long a claimed delivery is kept from other replicas; it must
+	// outlast posting a whole batch
+	Lease time.Duration
+	// MaxAttempts at a delivery before it is given up
+	MaxAttempts int32
+	// RetryDelay before the first retry, doubled for each one after
+	RetryDelay time.Duration
+	// MaxRetryDelay caps the delay between two retries
+	MaxRetryDelay time.Duration
+}
+
+// errEndpointDisabled fails the deliveries left pending when their endpoint is disabled
+var errEndpointDisabled = errors.New("endpoint disabled")
+
+// Dispatcher posts the queued webhook deliveries to their endpoints, signed
+// with each endpoint's secret. Deliveries an endpoint does not accept with a
+// 2xx status, redirects included, are retried with exponential backoff until
+// MaxAttempts. Endpoints are never reached on a non-public address.
+type Dispatcher struct {
+	store  DeliveryStore
+	client *http.Client
+	config DispatcherConfig
+	now    func() time.Time
+}
+
+// NewDispatcher creates a dispatcher posting the deliveries queued in store
+func NewDispatcher(store DeliveryStore, config DispatcherConfig) *Dispatcher {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 20
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.Lease <= 0 {
+		config.Lease = 5 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = 30 * time.Second
+	}
+	if config.MaxRetryDelay <= 0 {
+		config.MaxRetryDelay = time.Hour
+	}
+
+	return &Dispatcher{
+		store:  store,
+		client: newClient(config.Timeout, PublicAddress),
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// DispatchDue posts the deliveries due now, batch after batch, and returns how
+// many it attempted
+func (dispatcher *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	attempted := 0
+
+	for {
+		now := dispatcher.now()
+		deliveries, err := dispatcher.store.ClaimDueWebhookDeliveries(ctx, db.ClaimDueWebhookDeliveriesParams{
+			LockedUntil: pgtype.Timestamptz{Time: now.Add(dispatcher.config.Lease), Valid: true},
+			Now:         now,
+			Limit:       dispatcher.config.BatchSize,
+		})
+		if err != nil {
+			return attempted, fmt.Errorf("error claiming webhook deliveries: %w", err)
+		}
+
+		endpoints := map[int64]db.WebhookEndpoints{}
+		for _, delivery := range deliveries {
+			if err := dispatcher.dispatch(ctx, delivery, endpoints); err != nil {
+				log.Println("error dispatching webhook delivery: ", err)
+			}
+		}
+		attempted += len(deliveries)
+
+		if len(deliveries) < int(dispatcher.config.BatchSize) || ctx.Err() != nil {
+			return attempted, ctx.Err()
+		}
+	}
+}
+
+// dispatch posts delivery to its endpoint, looked up once per batch, and
+// records the outcome of the attempt
+func (dispatcher *Dispatcher) dispatch(ctx context.Context, delivery db.WebhookDeliveries, endpoints map[int64]db.WebhookEndpoints) error {
+	endpoint, ok := endpoints[delivery.EndpointID]
+	if !ok {
+		var err error
+		endpoint, err = dispatcher.store.GetWebhookEndpoint(ctx, delivery.EndpointID)
+		if err != nil {
+			return fmt.Errorf("error loading endpoint of webhook delivery %d: %w", delivery.ID, err)
+		}
+		endpoints[endpoint.ID] = endpoint
+	}
+
+	var status int
+	err := errEndpointDisabled
+	if endpoint.Active {
+		status, err = dispatcher.post(ctx, endpoint, delivery)
+	}
+
+	now := dispatcher.now()
+	arg := db.RecordWebhookDeliveryAttemptParams{
+		Status:         util.WebhookDeliverySucceeded,
+		ResponseStatus: int32(status),
+		NextAttemptAt:  now,
+		DeliveredAt:    pgtype.Timestamptz{Time: now, Valid: true},
+		ID:             delivery.ID,
+	}
+	if err != nil {
+		arg.LastError = err.Error()
+		arg.DeliveredAt = pgtype.Timestamptz{}
+		arg.Status = util.WebhookDeliveryFailed
+		if attempt := delivery.Attempts + 1; endpoint.Active && attempt < dispatcher.config.MaxAttempts {
+			arg.Status = util.WebhookDeliveryPending
+			arg.NextAttemptAt = now.Add(dispatcher.retryDelay(delivery.Attempts))
+		}
+	}
+
+	if _, recordErr := dispatcher.store.RecordWebhookDeliveryAttempt(ctx, arg); recordErr != nil {
+		return fmt.Errorf("error recording attempt at webhook delivery %d: %w", delivery.ID, recordErr)
+	}
+	return nil
+}
+
+// post sends the signed delivery and returns the status the endpoint answered
+// with, failing unless it is a 2xx one
+func (dispatcher *Dispatcher) post(ctx context.Context, endpoint db.WebhookEndpoints, delivery db.WebhookDeliveries) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventIDHeader, strconv.FormatInt(delivery.EventID, 10))
+	request.Header.Set(EventTypeHeader, delivery.EventType)
+	request.Header.Set(DeliveryIDHeader, strconv.FormatInt(delivery.ID, 10))
+	request.Header.Set(SignatureHeader, Sign(endpoint.Secret, dispatcher.now(), delivery.Payload))
+
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("endpoint returned %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+// retryDelay is how long to wait after the attempt following attempts failed ones
+func (dispatcher *Dispatcher) retryDelay(attempts int32) time.Duration {
+	delay := dispatcher.config.RetryDelay
+	for i := int32(0); i < attempts && delay < dispatcher.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, dispatcher.config.MaxRetryDelay)
+}
+
+// Run posts the due deliveries every Interval until ctx is cancelled
+func (dispatcher *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := dispatcher.DispatchDue(ctx); err != nil {
+				log.Println("error dispatching webhook deliveries: ", err)
+			}
+		}
+	}
+}
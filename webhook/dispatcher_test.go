@@ -0,0 +1,251 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDeliveryStore hands out the due deliveries once and records the attempts
+type fakeDeliveryStore struct {
+	due       []db.WebhookDeliveries
+	endpoints map[int64]db.WebhookEndpoints
+	lookups   int
+	claims    []db.ClaimDueWebhookDeliveriesParams
+	attempts  []db.RecordWebhookDeliveryAttemptParams
+}
+
+func (store *fakeDeliveryStore) ClaimDueWebhookDeliveries(ctx context.Context, arg db.ClaimDueWebhookDeliveriesParams) ([]db.WebhookDeliveries, error) {
+	store.claims = append(store.claims, arg)
+
+	n := min(int(arg.Limit), len(store.due))
+	claimed := store.due[:n]
+	store.due = store.due[n:]
+	return claimed, nil
+}
+
+func (store *fakeDeliveryStore) GetWebhookEndpoint(ctx context.Context, id int64) (db.WebhookEndpoints, error) {
+	store.lookups++
+	endpoint, ok := store.endpoints[id]
+	if !ok {
+		return endpoint, db.ErrRecordNotFound
+	}
+	return endpoint, nil
+}
+
+func (store *fakeDeliveryStore) RecordWebhookDeliveryAttempt(ctx context.Context, arg db.RecordWebhookDeliveryAttemptParams) (db.WebhookDeliveries, error) {
+	store.attempts = append(store.attempts, arg)
+	return db.WebhookDeliveries{}, nil
+}
+
+func newTestDispatcher(store DeliveryStore) (*Dispatcher, time.Time) {
+	now := time.Now()
+	dispatcher := NewDispatcher(store, DispatcherConfig{
+		BatchSize:     2,
+		Lease:         time.Minute,
+		MaxAttempts:   3,
+		RetryDelay:    time.Minute,
+		MaxRetryDelay: time.Hour,
+	})
+	dispatcher.now = func() time.Time { return now }
+	// the test endpoints listen on loopback, which real deliveries may not reach
+	dispatcher.client = newClient(time.Second, func(addr netip.Addr) bool { return addr.IsLoopback() })
+	return dispatcher, now
+}
+
+func dueDelivery(id int64, endpointID int64, attempts int32) db.WebhookDeliveries {
+	return db.WebhookDeliveries{
+		ID:         id,
+		EndpointID: endpointID,
+		EventID:    100 + id,
+		EventType:  util.EventTransferReceived,
+		Payload:    []byte(`{"id":1}`),
+		Status:     util.WebhookDeliveryPending,
+		Attempts:   attempts,
+	}
+}
+
+func TestDispatcherSignsDeliveries(t *testing.T) {
+	var signatures []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		require.NoError(t, Verify("whsec_test", r.Header.Get(SignatureHeader), body, time.Minute, time.Now()))
+		require.Equal(t, util.EventTransferReceived, r.Header.Get(EventTypeHeader))
+		signatures = append(signatures, r.Header.Get(DeliveryIDHeader)+" "+r.Header.Get(EventIDHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	store := &fakeDeliveryStore{
+		due: []db.WebhookDeliveries{dueDelivery(1, 1, 0), dueDelivery(2, 1, 0), dueDelivery(3, 1, 0)},
+		endpoints: map[int64]db.WebhookEndpoints{
+			1: {ID: 1, Url: server.URL, Secret: "whsec_test", Active: true},
+		},
+	}
+	dispatcher, now := newTestDispatcher(store)
+
+	attempted, err := dispatcher.DispatchDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, attempted)
+
+	require.Equal(t, []string{"1 101", "2 102", "3 103"}, signatures)
+	require.Len(t, store.claims, 2)
+	require.Equal(t, now.Add(time.Minute), store.claims[0].LockedUntil.Time)
+
+	// the endpoint is looked up once per batch
+	require.Equal(t, 2, store.lookups)
+
+	require.Len(t, store.attempts, 3)
+	for _, attempt := range store.attempts {
+		require.Equal(t, util.WebhookDeliverySucceeded, attempt.Status)
+		require.Equal(t, int32(http.StatusOK), attempt.ResponseStatus)
+		require.Equal(t, now, attempt.DeliveredAt.Time)
+		require.Empty(t, attempt.LastError)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name          string
+		delivery      db.WebhookDeliveries
+		endpoint      db.WebhookEndpoints
+		checkResponse func(t *testing.T, attempt db.RecordWebhookDeliveryAttemptParams, now time.Time)
+	}{
+		{
+			name:     "FirstFailure",
+			delivery: dueDelivery(1, 1, 0),
+			endpoint: db.WebhookEndpoints{ID: 1, Url: server.URL, Active: true},
+			checkResponse: func(t *testing.T, attempt db.RecordWebhookDeliveryAttemptParams, now time.Time) {
+				require.Equal(t, util.WebhookDeliveryPending, attempt.Status)
+				require.Equal(t, int32(http.StatusServiceUnavailable), attempt.ResponseStatus)
+				require.Contains(t, attempt.LastError, "503")
+				require.Equal(t, now.Add(time.Minute), attempt.NextAttemptAt)
+				require.False(t, attempt.DeliveredAt.Valid)
+			},
+		},
+		{
+			name:     "BackoffDoubles",
+			delivery: dueDelivery(1, 1, 1),
+			endpoint: db.WebhookEndpoints{ID: 1, Url: server.URL, Active: true},
+			checkResponse: func(t *testing.T, attempt db.RecordWebhookDeliveryAttemptParams, now time.Time) {
+				require.Equal(t, util.WebhookDeliveryPending, attempt.Status)
+				require.Equal(t, now.Add(2*time.Minute), attempt.NextAttemptAt)
+			},
+		},
+		{
+			name:     "LastAttempt",
+			delivery: dueDelivery(1, 1, 2),
+			endpoint: db.WebhookEndpoints{ID: 1, Url: server.URL, Active: true},
+			checkResponse: func(t *testing.T, attempt db.RecordWebhookDeliveryAttemptParams, now time.Time) {
+				require.Equal(t, util.WebhookDeliveryFailed, attempt.Status)
+			},
+		},
+		{
+			name:     "Unreachable",
+			delivery: dueDelivery(1, 1, 0),
+			endpoint: db.WebhookEndpoints{ID: 1, Url: "http://127.0.0.1:1", Active: true},
+			checkResponse: func(t *testing.T, attempt db.RecordWebhookDeliveryAttemptParams, now time.Time) {
+				require.Equal(t, util.WebhookDeliveryPending, attempt.Status)
+				require.Zero(t, attempt.ResponseStatus)
+				require.NotEmpty(t, attempt.LastError)
+			},
+		},
+		{
+			name:     "EndpointDisabled",
+			delivery: dueDelivery(1, 1, 0),
+			endpoint: db.WebhookEndpoints{ID: 1, Url: server.URL},
+			checkResponse: func(t *testing.T, attempt db.RecordWebhookDeliveryAttemptParams, now time.Time) {
+				require.Equal(t, util.WebhookDeliveryFailed, attempt.Status)
+				require.Equal(t, errEndpointDisabled.Error(), attempt.LastError)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeDeliveryStore{
+				due:       []db.WebhookDeliveries{tc.delivery},
+				endpoints: map[int64]db.WebhookEndpoints{tc.endpoint.ID: tc.endpoint},
+			}
+			dispatcher, now := newTestDispatcher(store)
+
+			attempted, err := dispatcher.DispatchDue(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, attempted)
+
+			require.Len(t, store.attempts, 1)
+			require.Equal(t, tc.delivery.ID, store.attempts[0].ID)
+			tc.checkResponse(t, store.attempts[0], now)
+		})
+	}
+}
+
+func TestDispatcherRefusesPrivateAddresses(t *testing.T) {
+	posted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted = true
+	}))
+	defer server.Close()
+
+	store := &fakeDeliveryStore{
+		due: []db.WebhookDeliveries{dueDelivery(1, 1, 0)},
+		endpoints: map[int64]db.WebhookEndpoints{
+			1: {ID: 1, Url: server.URL, Secret: "whsec_test", Active: true},
+		},
+	}
+	dispatcher := NewDispatcher(store, DispatcherConfig{})
+
+	_, err := dispatcher.DispatchDue(context.Background())
+	require.NoError(t, err)
+
+	require.False(t, posted)
+	require.Len(t, store.attempts, 1)
+	require.Zero(t, store.attempts[0].ResponseStatus)
+	require.Contains(t, store.attempts[0].LastError, ErrForbiddenAddress.Error())
+}
+
+func TestDispatcherRefusesRedirects(t *testing.T) {
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	store := &fakeDeliveryStore{
+		due: []db.WebhookDeliveries{dueDelivery(1, 1, 0)},
+		endpoints: map[int64]db.WebhookEndpoints{
+			1: {ID: 1, Url: server.URL, Secret: "whsec_test", Active: true},
+		},
+	}
+	dispatcher, _ := newTestDispatcher(store)
+
+	_, err := dispatcher.DispatchDue(context.Background())
+	require.NoError(t, err)
+
+	require.False(t, redirected)
+	require.Len(t, store.attempts, 1)
+	require.Equal(t, util.WebhookDeliveryPending, store.attempts[0].Status)
+	require.Equal(t, int32(http.StatusTemporaryRedirect), store.attempts[0].ResponseStatus)
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignature(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret, "whsec_"))
+
+	otherSecret, err := NewSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, secret, otherSecret)
+
+	body := []byte(`{"id":1,"type":"transfer.received"}`)
+	signedAt := time.Now()
+	signature := Sign(secret, signedAt, body)
+
+	testCases := []struct {
+		name      string
+		secret    string
+		signature string
+		body      []byte
+		now       time.Time
+		checkErr  func(t *testing.T, err error)
+	}{
+		{
+			name:      "OK",
+			secret:    secret,
+			signature: signature,
+			body:      body,
+			now:       signedAt.Add(time.Minute),
+			checkErr: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "WrongSecret",
+			secret:    otherSecret,
+			signature: signature,
+			body:      body,
+			now:       signedAt,
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+			},
+		},
+		{
+			name:      "TamperedBody",
+			secret:    secret,
+			signature: signature,
+			body:      []byte(`{"id":1,"type":"transfer.sent"}`),
+			now:       signedAt,
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+			},
+		},
+		{
+			name:      "Replayed",
+			secret:    secret,
+			signature: signature,
+			body:      body,
+			now:       signedAt.Add(time.Hour),
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+			},
+		},
+		{
+			name:      "Malformed",
+			secret:    secret,
+			signature: "v1=deadbeef",
+			body:      body,
+			now:       signedAt,
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.signature, tc.body, 5*time.Minute, tc.now)
+			tc.checkErr(t, err)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/outbox"
+	"github.com/rouclec/simplebank/util"
+)
+
+// SubscriptionStore queues events for the webhook endpoints subscribed to them
+type SubscriptionStore interface {
+	CreateWebhookDeliveries(ctx context.Context, arg db.CreateWebhookDeliveriesParams) ([]db.WebhookDeliveries, error)
+}
+
+// Subscriptions is the outbox sink feeding webhooks: it queues a delivery of
+// each event for every endpoint its account's owner subscribed to it, for a
+// Dispatcher to post. An event relayed again is not queued twice.
+type Subscriptions struct {
+	store SubscriptionStore
+}
+
+// NewSubscriptions creates the sink queueing deliveries in store
+func NewSubscriptions(store SubscriptionStore) *Subscriptions {
+	return &Subscriptions{store: store}
+}
+
+func (subscriptions *Subscriptions) Deliver(ctx context.Context, event db.OutboxEvents) error {
+	if !util.IsSubscribableEvent(event.EventType) {
+		return nil
+	}
+
+	// transfer events carry the account they are published on, like account events
+	var payload db.AccountEvent
+	if err := json.Unmarshal(event.Payload, &payload); err != nil || payload.Account.Owner == "" {
+		// retrying cannot fix the event, and would hold back the rest of its account's
+		log.Printf("skipping webhooks of outbox event %d: no account owner in its payload", event.ID)
+		return nil
+	}
+
+	body, err := json.Marshal(outbox.NewMessage(event))
+	if err != nil {
+		return err
+	}
+
+	_, err = subscriptions.store.CreateWebhookDeliveries(ctx, db.CreateWebhookDeliveriesParams{
+		EventID:   event.ID,
+		EventType: event.EventType,
+		Payload:   body,
+		Owner:     payload.Account.Owner,
+	})
+	return err
+}
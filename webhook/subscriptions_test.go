@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	db "github.com/rouclec/simplebank/db/sqlc"
+	"github.com/rouclec/simplebank/outbox"
+	"github.com/rouclec/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSubscriptionStore struct {
+	queued []db.CreateWebhookDeliveriesParams
+}
+
+func (store *fakeSubscriptionStore) CreateWebhookDeliveries(ctx context.Context, arg db.CreateWebhookDeliveriesParams) ([]db.WebhookDeliveries, error) {
+	store.queued = append(store.queued, arg)
+	return nil, nil
+}
+
+func TestSubscriptions(t *testing.T) {
+	payload, err := json.Marshal(db.TransferEvent{
+		Account:  db.Accounts{ID: 7, Owner: "alice"},
+		Transfer: db.Transfers{ID: 3, ToAccountID: 7},
+	})
+	require.NoError(t, err)
+
+	event := db.OutboxEvents{
+		ID:            11,
+		AggregateType: util.AccountAggregate,
+		AggregateID:   "7",
+		EventType:     util.EventTransferReceived,
+		Payload:       payload,
+	}
+
+	store := &fakeSubscriptionStore{}
+	subscriptions := NewSubscriptions(store)
+
+	require.NoError(t, subscriptions.Deliver(context.Background(), event))
+	require.Len(t, store.queued, 1)
+	require.Equal(t, int64(11), store.queued[0].EventID)
+	require.Equal(t, util.EventTransferReceived, store.queued[0].EventType)
+	require.Equal(t, "alice", store.queued[0].Owner)
+
+	var message outbox.Message
+	require.NoError(t, json.Unmarshal(store.queued[0].Payload, &message))
+	require.Equal(t, outbox.NewMessage(event), message)
+
+	// events endpoints cannot subscribe to are not queued
+	userEvent := db.OutboxEvents{ID: 12, AggregateType: util.UserAggregate, AggregateID: "alice", EventType: util.EventUserCreated, Payload: []byte(`{}`)}
+	require.NoError(t, subscriptions.Deliver(context.Background(), userEvent))
+
+	// nor are events missing their owner, which retrying cannot fix
+	event.Payload = []byte(`{}`)
+	require.NoError(t, subscriptions.Deliver(context.Background(), event))
+	require.Len(t, store.queued, 1)
+}